@@ -0,0 +1,166 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/axellelanca/urlshortener/cmd"
+	"github.com/axellelanca/urlshortener/internal/database"
+	"github.com/axellelanca/urlshortener/internal/repository"
+	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/spf13/cobra"
+	"gorm.io/gorm"
+)
+
+// Variables qui stockeront les valeurs des flags --name et --admin de la commande api-key create
+var (
+	apiKeyNameFlag  string
+	apiKeyAdminFlag bool
+)
+
+// APIKeyCmd représente la commande 'api-key'
+var APIKeyCmd = &cobra.Command{
+	Use:   "api-key",
+	Short: "Gère les clés d'accès à l'API.",
+	Long: `Cette commande crée, liste et révoque les clés d'API exigées par les routes /api/v1
+quand auth.enabled est activé. Hors espace de travail, chaque clé ne voit et ne gère que les liens
+qu'elle a créés. Une clé d'administration (--admin) gère en plus les espaces de travail.
+
+Exemples:
+  url-shortener api-key create --name="équipe marketing"
+  url-shortener api-key create --name="exploitation" --admin
+  url-shortener api-key list
+  url-shortener api-key revoke 3`,
+}
+
+// APIKeyCreateCmd représente la commande 'api-key create'
+var APIKeyCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Crée une nouvelle clé d'API et l'affiche une seule fois.",
+	Run: func(cmdk *cobra.Command, args []string) {
+		withAPIKeyService(func(apiKeyService *services.APIKeyService) {
+			key, plaintext, err := apiKeyService.CreateAPIKey(apiKeyNameFlag, apiKeyAdminFlag)
+			if err != nil {
+				if errors.Is(err, services.ErrInvalidAPIKeyName) {
+					fmt.Println("Erreur: Le nom de la clé doit contenir entre 1 et 100 caractères")
+				} else {
+					fmt.Printf("Erreur lors de la création de la clé: %v\n", err)
+				}
+				os.Exit(1)
+			}
+
+			if key.IsAdmin {
+				fmt.Printf("Clé d'API d'administration %d (%s) créée.\n", key.ID, key.Name)
+			} else {
+				fmt.Printf("Clé d'API %d (%s) créée.\n", key.ID, key.Name)
+			}
+			fmt.Printf("Clé: %s\n", plaintext)
+			fmt.Println("Conservez-la maintenant : elle n'est pas stockée et ne pourra plus être affichée.")
+		})
+	},
+}
+
+// APIKeyListCmd représente la commande 'api-key list'
+var APIKeyListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Liste les clés d'API, y compris les clés révoquées.",
+	Run: func(cmdk *cobra.Command, args []string) {
+		withAPIKeyService(func(apiKeyService *services.APIKeyService) {
+			keys, err := apiKeyService.ListAPIKeys()
+			if err != nil {
+				fmt.Printf("Erreur lors de la lecture des clés: %v\n", err)
+				os.Exit(1)
+			}
+			if len(keys) == 0 {
+				fmt.Println("Aucune clé d'API.")
+				return
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "ID\tNOM\tPRÉFIXE\tADMIN\tCRÉÉE LE\tDERNIÈRE UTILISATION\tÉTAT")
+			for _, key := range keys {
+				lastUsed := "-"
+				if key.LastUsedAt != nil {
+					lastUsed = key.LastUsedAt.Format(time.RFC3339)
+				}
+				state := "active"
+				if key.RevokedAt != nil {
+					state = "révoquée le " + key.RevokedAt.Format(time.RFC3339)
+				}
+				admin := "non"
+				if key.IsAdmin {
+					admin = "oui"
+				}
+				fmt.Fprintf(w, "%d\t%s\t%s…\t%s\t%s\t%s\t%s\n",
+					key.ID, key.Name, key.Prefix, admin, key.CreatedAt.Format(time.RFC3339), lastUsed, state)
+			}
+			w.Flush()
+		})
+	},
+}
+
+// APIKeyRevokeCmd représente la commande 'api-key revoke'
+var APIKeyRevokeCmd = &cobra.Command{
+	Use:   "revoke ID",
+	Short: "Révoque une clé d'API : elle ne permet plus d'accéder à l'API.",
+	Long: `Cette commande révoque une clé d'API. Les liens créés avec cette clé sont conservés
+et restent gérables depuis la CLI.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmdk *cobra.Command, args []string) {
+		id, err := strconv.ParseUint(args[0], 10, 0)
+		if err != nil {
+			fmt.Println("Erreur: ID doit être un entier positif")
+			os.Exit(1)
+		}
+
+		withAPIKeyService(func(apiKeyService *services.APIKeyService) {
+			if err := apiKeyService.RevokeAPIKey(uint(id)); err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					fmt.Printf("Erreur: Aucune clé active avec l'ID %d\n", id)
+				} else {
+					fmt.Printf("Erreur lors de la révocation de la clé: %v\n", err)
+				}
+				os.Exit(1)
+			}
+			fmt.Printf("Clé d'API %d révoquée.\n", id)
+		})
+	},
+}
+
+// withAPIKeyService ouvre la base de données configurée et appelle 'run' avec un APIKeyService.
+func withAPIKeyService(run func(apiKeyService *services.APIKeyService)) {
+	// Charger la configuration chargée globalement via cmd.GetConfig()
+	cfg := cmd.GetConfig()
+
+	// Initialiser la connexion à la base de données configurée
+	db, err := database.Open(cfg)
+	if err != nil {
+		log.Fatalf("Échec de la connexion à la base de données: %v", err)
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		log.Fatalf("FATAL: Échec de l'obtention de la base de données SQL sous-jacente: %v", err)
+	}
+
+	// S'assurer que la connexion est fermée à la fin de l'exécution de la commande
+	defer sqlDB.Close()
+
+	run(services.NewAPIKeyService(repository.NewAPIKeyRepository(db)))
+}
+
+func init() {
+	APIKeyCreateCmd.Flags().StringVarP(&apiKeyNameFlag, "name", "n", "", "Nom de la clé (équipe, application...)")
+	APIKeyCreateCmd.Flags().BoolVar(&apiKeyAdminFlag, "admin", false, "Crée une clé d'administration, qui gère les espaces de travail")
+	APIKeyCreateCmd.MarkFlagRequired("name")
+
+	APIKeyCmd.AddCommand(APIKeyCreateCmd, APIKeyListCmd, APIKeyRevokeCmd)
+
+	// Ajouter la commande à RootCmd
+	cmd.RootCmd.AddCommand(APIKeyCmd)
+}
@@ -5,18 +5,28 @@ import (
 	"log"
 	"net/url" // Pour valider le format de l'URL
 	"os"
+	"time"
 
 	"github.com/axellelanca/urlshortener/cmd"
+	"github.com/axellelanca/urlshortener/internal/database"
 	"github.com/axellelanca/urlshortener/internal/repository"
 	"github.com/axellelanca/urlshortener/internal/services"
 	"github.com/spf13/cobra"
-	"gorm.io/driver/sqlite"
-	"gorm.io/gorm"
 )
 
 // Variable longURLFlag qui stockera la valeur du flag --url
 var longURLFlag string
 
+// Variable aliasFlag qui stockera la valeur du flag --alias
+var aliasFlag string
+
+// Variables ttlFlag, maxClicksFlag et fallbackURLFlag qui stockeront les valeurs des flags --ttl, --max-clicks et --fallback-url
+var (
+	ttlFlag         time.Duration
+	maxClicksFlag   int
+	fallbackURLFlag string
+)
+
 // CreateCmd représente la commande 'create'
 var CreateCmd = &cobra.Command{
 	Use:   "create",
@@ -24,7 +34,10 @@ var CreateCmd = &cobra.Command{
 	Long: `Cette commande raccourcit une URL longue fournie et affiche le code court généré.
 
 Exemple:
-  url-shortener create --url="https://www.google.com/search?q=go+lang"`,
+  url-shortener create --url="https://www.google.com/search?q=go+lang"
+  url-shortener create --url="https://example.com/soldes" --alias="spring-sale"
+  url-shortener create --url="https://example.com/offre" --ttl=72h --max-clicks=100
+  url-shortener create --url="https://example.com/offre" --fallback-url="https://example.com/maintenance"`,
 	Run: func(cmdc *cobra.Command, args []string) {
 		// Valider que le flag --url a été fourni
 		if longURLFlag == "" {
@@ -39,13 +52,23 @@ Exemple:
 			os.Exit(1)
 		}
 
+		if ttlFlag < 0 {
+			fmt.Println("Erreur: Le flag --ttl doit être positif")
+			os.Exit(1)
+		}
+		opts := services.CreateLinkOptions{Alias: aliasFlag, MaxClicks: maxClicksFlag, FallbackURL: fallbackURLFlag}
+		if ttlFlag > 0 {
+			expiresAt := time.Now().Add(ttlFlag)
+			opts.ExpiresAt = &expiresAt
+		}
+
 		// Charger la configuration chargée globalement via cmd.GetConfig()
 		cfg := cmd.GetConfig()
 
-		// Initialiser la connexion à la base de données SQLite
-		db, err := gorm.Open(sqlite.Open(cfg.Database.Name), &gorm.Config{})
+		// Initialiser la connexion à la base de données configurée
+		db, err := database.Open(cfg)
 		if err != nil {
-			log.Fatalf("Échec de la connexion à la base de données '%s': %v", cfg.Database.Name, err)
+			log.Fatalf("Échec de la connexion à la base de données: %v", err)
 		}
 
 		sqlDB, err := db.DB()
@@ -60,8 +83,9 @@ Exemple:
 		linkRepo := repository.NewLinkRepository(db)
 		linkService := services.NewLinkService(linkRepo)
 
-		// Appeler le LinkService et la fonction CreateLink pour créer le lien court
-		link, err := linkService.CreateLink(longURLFlag)
+		// Appeler le LinkService et la fonction CreateLink pour créer le lien court.
+		// La CLI agit sans clé d'API (caller nil) : le lien n'a pas de propriétaire.
+		link, err := linkService.CreateLink(nil, longURLFlag, opts)
 		if err != nil {
 			fmt.Printf("Erreur lors de la création du lien: %v\n", err)
 			os.Exit(1)
@@ -71,6 +95,15 @@ Exemple:
 		fmt.Printf("URL courte créée avec succès:\n")
 		fmt.Printf("Code: %s\n", link.Shortcode)
 		fmt.Printf("URL complète: %s\n", fullShortURL)
+		if link.ExpiresAt != nil {
+			fmt.Printf("Expire le: %s\n", link.ExpiresAt.Format(time.RFC3339))
+		}
+		if link.MaxClicks > 0 {
+			fmt.Printf("Clics maximum: %d\n", link.MaxClicks)
+		}
+		if link.FallbackURL != "" {
+			fmt.Printf("URL de secours: %s\n", link.FallbackURL)
+		}
 	},
 }
 
@@ -79,6 +112,10 @@ Exemple:
 func init() {
 	// Définir le flag --url pour la commande create
 	CreateCmd.Flags().StringVarP(&longURLFlag, "url", "u", "", "URL longue à raccourcir")
+	CreateCmd.Flags().StringVarP(&aliasFlag, "alias", "a", "", "Alias personnalisé à utiliser comme code court (optionnel)")
+	CreateCmd.Flags().DurationVar(&ttlFlag, "ttl", 0, "Durée de vie du lien, ex: 72h (optionnel, 0 pour un lien permanent)")
+	CreateCmd.Flags().IntVar(&maxClicksFlag, "max-clicks", 0, "Nombre de clics après lequel le lien expire (optionnel, 0 pour illimité)")
+	CreateCmd.Flags().StringVar(&fallbackURLFlag, "fallback-url", "", "URL de secours utilisée tant que l'URL longue est inaccessible (optionnel)")
 
 	// Marquer le flag comme requis
 	CreateCmd.MarkFlagRequired("url")
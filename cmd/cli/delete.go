@@ -0,0 +1,77 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/axellelanca/urlshortener/cmd"
+	"github.com/axellelanca/urlshortener/internal/database"
+	"github.com/axellelanca/urlshortener/internal/repository"
+	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/spf13/cobra"
+	"gorm.io/gorm"
+)
+
+// Variable deleteCodeFlag qui stockera la valeur du flag --code de la commande delete
+var deleteCodeFlag string
+
+// DeleteCmd représente la commande 'delete'
+var DeleteCmd = &cobra.Command{
+	Use:   "delete",
+	Short: "Supprime un lien court et ses statistiques.",
+	Long: `Cette commande supprime définitivement un lien court ainsi que tous les clics enregistrés pour ce lien.
+
+Exemple:
+  url-shortener delete --code="xyz123"`,
+	Run: func(cmdd *cobra.Command, args []string) {
+		if deleteCodeFlag == "" {
+			fmt.Println("Erreur: Le flag --code est requis")
+			os.Exit(1)
+		}
+
+		// Charger la configuration chargée globalement via cmd.GetConfig()
+		cfg := cmd.GetConfig()
+
+		// Initialiser la connexion à la base de données configurée
+		db, err := database.Open(cfg)
+		if err != nil {
+			log.Fatalf("Échec de la connexion à la base de données: %v", err)
+		}
+
+		sqlDB, err := db.DB()
+		if err != nil {
+			log.Fatalf("FATAL: Échec de l'obtention de la base de données SQL sous-jacente: %v", err)
+		}
+
+		// S'assurer que la connexion est fermée à la fin de l'exécution de la commande
+		defer sqlDB.Close()
+
+		// Initialiser les repositories et services nécessaires
+		linkRepo := repository.NewLinkRepository(db)
+		linkService := services.NewLinkService(linkRepo)
+
+		if err := linkService.DeleteLink(nil, deleteCodeFlag); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				fmt.Printf("Erreur: Aucun lien trouvé avec le code '%s'\n", deleteCodeFlag)
+			} else {
+				fmt.Printf("Erreur lors de la suppression du lien: %v\n", err)
+			}
+			os.Exit(1)
+		}
+
+		fmt.Printf("Lien %s supprimé avec succès.\n", deleteCodeFlag)
+	},
+}
+
+// init() s'exécute automatiquement lors de l'importation du package.
+// Il est utilisé pour définir les flags que cette commande accepte.
+func init() {
+	DeleteCmd.Flags().StringVarP(&deleteCodeFlag, "code", "c", "", "Code court du lien à supprimer")
+
+	DeleteCmd.MarkFlagRequired("code")
+
+	// Ajouter la commande à RootCmd
+	cmd.RootCmd.AddCommand(DeleteCmd)
+}
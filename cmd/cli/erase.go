@@ -0,0 +1,88 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/axellelanca/urlshortener/cmd"
+	"github.com/axellelanca/urlshortener/internal/database"
+	"github.com/axellelanca/urlshortener/internal/privacy"
+	"github.com/axellelanca/urlshortener/internal/repository"
+	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/spf13/cobra"
+)
+
+// Variable eraseIPFlag qui stockera la valeur du flag --ip de la commande erase-clicks
+var eraseIPFlag string
+
+// EraseClicksCmd représente la commande 'erase-clicks'
+var EraseClicksCmd = &cobra.Command{
+	Use:   "erase-clicks",
+	Short: "Supprime tous les clics enregistrés pour une adresse IP.",
+	Long: `Cette commande répond à une demande d'effacement : elle supprime définitivement tous les clics
+enregistrés pour une adresse IP, sur tous les liens, et affiche le nombre de clics supprimés.
+
+Les clics enregistrés en clair et, en mode privacy.ip_mode=hash, sous forme d'empreinte sont supprimés.
+En mode truncate, l'adresse stockée est commune à tout un réseau et ne permet plus d'identifier une personne :
+seuls les clics enregistrés en clair avant l'activation du mode sont supprimés.
+
+Exemple:
+  url-shortener erase-clicks --ip="203.0.113.42"`,
+	Run: func(cmde *cobra.Command, args []string) {
+		if eraseIPFlag == "" {
+			fmt.Println("Erreur: Le flag --ip est requis")
+			os.Exit(1)
+		}
+
+		// Charger la configuration chargée globalement via cmd.GetConfig()
+		cfg := cmd.GetConfig()
+
+		// L'empreinte d'une adresse dépend du mode et de la clé configurés pour le serveur.
+		anonymizer, err := privacy.NewIPAnonymizer(cfg.Privacy.IPMode, cfg.Privacy.HMACSecret)
+		if err != nil {
+			fmt.Printf("Erreur: Configuration de confidentialité invalide: %v\n", err)
+			os.Exit(1)
+		}
+
+		// Initialiser la connexion à la base de données configurée
+		db, err := database.Open(cfg)
+		if err != nil {
+			log.Fatalf("Échec de la connexion à la base de données: %v", err)
+		}
+
+		sqlDB, err := db.DB()
+		if err != nil {
+			log.Fatalf("FATAL: Échec de l'obtention de la base de données SQL sous-jacente: %v", err)
+		}
+
+		// S'assurer que la connexion est fermée à la fin de l'exécution de la commande
+		defer sqlDB.Close()
+
+		clickService := services.NewClickService(repository.NewClickRepository(db))
+
+		erased, err := clickService.EraseClicksByIP(eraseIPFlag, anonymizer)
+		if err != nil {
+			if errors.Is(err, services.ErrInvalidIP) {
+				fmt.Printf("Erreur: '%s' n'est pas une adresse IP valide\n", eraseIPFlag)
+			} else {
+				fmt.Printf("Erreur lors de la suppression des clics: %v\n", err)
+			}
+			os.Exit(1)
+		}
+
+		fmt.Printf("%d clic(s) supprimé(s) pour l'adresse %s.\n", erased, eraseIPFlag)
+	},
+}
+
+// init() s'exécute automatiquement lors de l'importation du package.
+// Il est utilisé pour définir les flags que cette commande accepte.
+func init() {
+	EraseClicksCmd.Flags().StringVar(&eraseIPFlag, "ip", "", "Adresse IP dont les clics doivent être supprimés")
+
+	EraseClicksCmd.MarkFlagRequired("ip")
+
+	// Ajouter la commande à RootCmd
+	cmd.RootCmd.AddCommand(EraseClicksCmd)
+}
@@ -0,0 +1,132 @@
+package cli
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/axellelanca/urlshortener/cmd"
+	"github.com/axellelanca/urlshortener/internal/database"
+	"github.com/axellelanca/urlshortener/internal/repository"
+	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/spf13/cobra"
+)
+
+// Variables qui stockeront les valeurs des flags de la commande list
+var (
+	listPageFlag     int
+	listPageSizeFlag int
+	listSortFlag     string
+	listAscFlag      bool
+	listURLFlag      string
+	listSinceFlag    string
+	listUntilFlag    string
+)
+
+// ListCmd représente la commande 'list'
+var ListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Liste les liens courts enregistrés.",
+	Long: `Cette commande affiche les liens courts sous forme de tableau, avec pagination,
+tri et filtrage par sous-chaîne d'URL ou par date de création.
+
+Exemples:
+  url-shortener list
+  url-shortener list --url="example.com" --sort=long_url --asc
+  url-shortener list --since=2025-01-01 --page=2 --page-size=50`,
+	Run: func(cmdl *cobra.Command, args []string) {
+		opts := services.ListLinksOptions{
+			Page:        listPageFlag,
+			PageSize:    listPageSizeFlag,
+			SortBy:      listSortFlag,
+			Desc:        !listAscFlag,
+			URLContains: listURLFlag,
+		}
+
+		var err error
+		if opts.CreatedAfter, err = parseDateFlag("since", listSinceFlag, time.Local); err != nil {
+			fmt.Printf("Erreur: %v\n", err)
+			os.Exit(1)
+		}
+		if opts.CreatedBefore, err = parseDateFlag("until", listUntilFlag, time.Local); err != nil {
+			fmt.Printf("Erreur: %v\n", err)
+			os.Exit(1)
+		}
+
+		// Charger la configuration chargée globalement via cmd.GetConfig()
+		cfg := cmd.GetConfig()
+
+		// Initialiser la connexion à la base de données configurée
+		db, err := database.Open(cfg)
+		if err != nil {
+			log.Fatalf("Échec de la connexion à la base de données: %v", err)
+		}
+
+		sqlDB, err := db.DB()
+		if err != nil {
+			log.Fatalf("FATAL: Échec de l'obtention de la base de données SQL sous-jacente: %v", err)
+		}
+
+		// S'assurer que la connexion est fermée à la fin de l'exécution de la commande
+		defer sqlDB.Close()
+
+		// Initialiser les repositories et services nécessaires
+		linkRepo := repository.NewLinkRepository(db)
+		linkService := services.NewLinkService(linkRepo)
+
+		page, err := linkService.ListLinks(nil, opts)
+		if err != nil {
+			fmt.Printf("Erreur lors de la récupération des liens: %v\n", err)
+			os.Exit(1)
+		}
+
+		if len(page.Links) == 0 {
+			fmt.Println("Aucun lien trouvé.")
+			return
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "CODE\tURL LONGUE\tCRÉÉ LE\tEXPIRE LE")
+		for _, link := range page.Links {
+			expires := "-"
+			if link.ExpiresAt != nil {
+				expires = link.ExpiresAt.Format(time.RFC3339)
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", link.Shortcode, link.LongURL, link.CreatedAt.Format(time.RFC3339), expires)
+		}
+		w.Flush()
+
+		fmt.Printf("\nPage %d (%d liens par page), %d lien(s) au total.\n", page.Page, page.PageSize, page.Total)
+	},
+}
+
+// parseDateFlag convertit la valeur d'un flag de date (RFC 3339 ou AAAA-MM-JJ).
+// Une date sans fuseau horaire est interprétée dans loc. Elle retourne nil si le flag n'a pas été fourni.
+func parseDateFlag(name, value string, loc *time.Location) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	for _, layout := range []string{time.RFC3339, time.DateOnly} {
+		if t, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return &t, nil
+		}
+	}
+	return nil, fmt.Errorf("le flag --%s doit être une date RFC 3339 ou au format AAAA-MM-JJ", name)
+}
+
+// init() s'exécute automatiquement lors de l'importation du package.
+// Il est utilisé pour définir les flags que cette commande accepte.
+func init() {
+	ListCmd.Flags().IntVar(&listPageFlag, "page", 1, "Numéro de la page à afficher")
+	ListCmd.Flags().IntVar(&listPageSizeFlag, "page-size", services.DefaultPageSize, "Nombre de liens par page")
+	ListCmd.Flags().StringVar(&listSortFlag, "sort", "created_at", "Champ de tri: created_at, short_code ou long_url")
+	ListCmd.Flags().BoolVar(&listAscFlag, "asc", false, "Trier par ordre croissant (décroissant par défaut)")
+	ListCmd.Flags().StringVar(&listURLFlag, "url", "", "Ne garder que les liens dont l'URL longue contient cette chaîne")
+	ListCmd.Flags().StringVar(&listSinceFlag, "since", "", "Ne garder que les liens créés à partir de cette date")
+	ListCmd.Flags().StringVar(&listUntilFlag, "until", "", "Ne garder que les liens créés avant cette date")
+
+	// Ajouter la commande à RootCmd
+	cmd.RootCmd.AddCommand(ListCmd)
+}
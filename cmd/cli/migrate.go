@@ -1,60 +1,182 @@
 package cli
 
 import (
+	"errors"
 	"fmt"
 	"log"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
 
 	"github.com/axellelanca/urlshortener/cmd"
-	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/database"
+	"github.com/axellelanca/urlshortener/internal/migrations"
 	"github.com/spf13/cobra"
-	"gorm.io/driver/sqlite"
-	"gorm.io/gorm"
 )
 
+// Variable migrateDirFlag qui stockera la valeur du flag --dir de la commande migrate create
+var migrateDirFlag string
+
 // MigrateCmd représente la commande 'migrate'
 var MigrateCmd = &cobra.Command{
 	Use:   "migrate",
 	Short: "Exécute les migrations de la base de données pour créer ou mettre à jour les tables.",
-	Long: `Cette commande se connecte à la base de données configurée (SQLite)
-et exécute les migrations automatiques de GORM pour créer les tables 'links' et 'clicks'
-basées sur les modèles Go.`,
-	Run: func(cmdm *cobra.Command, args []string) {
-		// Charger la configuration chargée globalement via cmd.GetConfig()
-		cfg := cmd.GetConfig()
+	Long: `Cette commande se connecte à la base de données configurée (SQLite, PostgreSQL ou MySQL)
+et applique les migrations versionnées embarquées dans le binaire. Sans sous-commande, elle équivaut à 'migrate up'.
 
-		// Initialiser la connexion à la base de données SQLite avec GORM.
-		var DB *gorm.DB
-		var err error
+Les migrations appliquées sont enregistrées dans la table 'schema_migrations'. Une base créée
+par une version précédente de cette commande est reprise automatiquement à partir de la migration 1.
 
-		log.Printf("Tentative de connexion à la base de données : %s", cfg.Database.Name) // Correction: Path au lieu de Name
+Exemples:
+  url-shortener migrate up
+  url-shortener migrate down 1
+  url-shortener migrate status
+  url-shortener migrate create add_link_title`,
+	Run: func(cmdm *cobra.Command, args []string) {
+		runMigrateUp()
+	},
+}
 
-		DB, err = gorm.Open(sqlite.Open(cfg.Database.Name), &gorm.Config{}) // Correction: Path au lieu de Name
-		if err != nil {
-			log.Fatalf("Échec de la connexion à la base de données '%s': %v", cfg.Database.Name, err) // Correction: Path au lieu de Name
+// MigrateUpCmd représente la commande 'migrate up'
+var MigrateUpCmd = &cobra.Command{
+	Use:   "up",
+	Short: "Applique toutes les migrations en attente.",
+	Run: func(cmdm *cobra.Command, args []string) {
+		runMigrateUp()
+	},
+}
+
+// MigrateDownCmd représente la commande 'migrate down'
+var MigrateDownCmd = &cobra.Command{
+	Use:   "down [N]",
+	Short: "Annule les N dernières migrations appliquées (1 par défaut).",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmdm *cobra.Command, args []string) {
+		steps := 1
+		if len(args) == 1 {
+			n, err := strconv.Atoi(args[0])
+			if err != nil || n < 1 {
+				fmt.Println("Erreur: N doit être un entier positif")
+				os.Exit(1)
+			}
+			steps = n
 		}
 
-		log.Println("Connexion à la base de données SQLite réussie !")
+		withMigrator(func(migrator *migrations.Migrator) {
+			rolledBack, err := migrator.Down(steps)
+			for _, migration := range rolledBack {
+				fmt.Printf("Migration %04d_%s annulée.\n", migration.Version, migration.Name)
+			}
+			if err != nil {
+				log.Fatalf("Échec de l'annulation des migrations: %v", err)
+			}
+			if len(rolledBack) == 0 {
+				fmt.Println("Aucune migration à annuler.")
+			}
+		})
+	},
+}
+
+// MigrateStatusCmd représente la commande 'migrate status'
+var MigrateStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Affiche l'état de chaque migration.",
+	Run: func(cmdm *cobra.Command, args []string) {
+		withMigrator(func(migrator *migrations.Migrator) {
+			statuses, err := migrator.Status()
+			if err != nil {
+				log.Fatalf("Échec de la lecture de l'état des migrations: %v", err)
+			}
 
-		sqlDB, err := DB.DB() // Correction: DB au lieu de db
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "VERSION\tNOM\tÉTAT\tAPPLIQUÉE LE")
+			for _, status := range statuses {
+				state, appliedAt := "en attente", "-"
+				if status.Applied {
+					state, appliedAt = "appliquée", status.AppliedAt.Format(time.RFC3339)
+				}
+				fmt.Fprintf(w, "%04d\t%s\t%s\t%s\n", status.Version, status.Name, state, appliedAt)
+			}
+			w.Flush()
+		})
+	},
+}
+
+// MigrateCreateCmd représente la commande 'migrate create'
+var MigrateCreateCmd = &cobra.Command{
+	Use:   "create NOM",
+	Short: "Crée les fichiers vides d'une nouvelle migration pour chaque dialecte.",
+	Long: `Cette commande crée les fichiers up et down d'une nouvelle migration pour SQLite, PostgreSQL et MySQL,
+numérotés à la suite des migrations existantes. Elle s'exécute depuis la racine des sources :
+les fichiers doivent être complétés puis le binaire recompilé pour que la migration soit embarquée.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmdm *cobra.Command, args []string) {
+		created, err := migrations.Create(migrateDirFlag, args[0])
 		if err != nil {
-			log.Fatalf("FATAL: Échec de l'obtention de la base de données SQL sous-jacente: %v", err)
+			if errors.Is(err, migrations.ErrInvalidMigrationName) {
+				fmt.Printf("Erreur: Nom de migration invalide '%s' (lettres minuscules, chiffres et '_')\n", args[0])
+			} else {
+				fmt.Printf("Erreur lors de la création de la migration: %v\n", err)
+			}
+			os.Exit(1)
 		}
-		// Assurez-vous que la connexion est fermée après la migration.
-		defer sqlDB.Close()
+		for _, path := range created {
+			fmt.Printf("Créé: %s\n", path)
+		}
+	},
+}
 
-		// Exécuter les migrations automatiques de GORM.
-		// Utilisez DB.AutoMigrate() et passez-lui les pointeurs vers tous vos modèles.
-		err = DB.AutoMigrate(&models.Link{}, &models.Click{})
+// runMigrateUp applique les migrations en attente.
+func runMigrateUp() {
+	withMigrator(func(migrator *migrations.Migrator) {
+		applied, err := migrator.Up()
+		for _, migration := range applied {
+			fmt.Printf("Migration %04d_%s appliquée.\n", migration.Version, migration.Name)
+		}
 		if err != nil {
 			log.Fatalf("Échec des migrations: %v", err)
 		}
 
 		// Pas touche au log
 		fmt.Println("Migrations de la base de données exécutées avec succès.")
-	},
+	})
+}
+
+// withMigrator ouvre la base de données configurée et appelle 'run' avec un Migrator pour cette base.
+func withMigrator(run func(migrator *migrations.Migrator)) {
+	// Charger la configuration chargée globalement via cmd.GetConfig()
+	cfg := cmd.GetConfig()
+
+	// Initialiser la connexion à la base de données configurée.
+	log.Printf("Tentative de connexion à la base de données : %s", database.Describe(cfg))
+
+	DB, err := database.Open(cfg)
+	if err != nil {
+		log.Fatalf("Échec de la connexion à la base de données: %v", err)
+	}
+
+	log.Println("Connexion à la base de données réussie !")
+
+	sqlDB, err := DB.DB()
+	if err != nil {
+		log.Fatalf("FATAL: Échec de l'obtention de la base de données SQL sous-jacente: %v", err)
+	}
+	// Assurez-vous que la connexion est fermée après la migration.
+	defer sqlDB.Close()
+
+	migrator, err := migrations.NewMigrator(DB)
+	if err != nil {
+		log.Fatalf("Échec du chargement des migrations: %v", err)
+	}
+	run(migrator)
 }
 
 func init() {
+	MigrateCreateCmd.Flags().StringVar(&migrateDirFlag, "dir", migrations.DefaultDir, "Répertoire des fichiers de migration dans les sources")
+
+	MigrateCmd.AddCommand(MigrateUpCmd, MigrateDownCmd, MigrateStatusCmd, MigrateCreateCmd)
+
 	// Ajouter la commande à RootCmd
 	cmd.RootCmd.AddCommand(MigrateCmd)
 }
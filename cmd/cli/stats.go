@@ -4,19 +4,33 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"text/tabwriter"
+	"time"
 
 	"github.com/axellelanca/urlshortener/cmd"
+	"github.com/axellelanca/urlshortener/internal/database"
 	"github.com/axellelanca/urlshortener/internal/repository"
 	"github.com/axellelanca/urlshortener/internal/services"
 	"github.com/spf13/cobra"
 
-	"gorm.io/driver/sqlite" // Driver SQLite pour GORM
 	"gorm.io/gorm"
 )
 
 // Variable shortCodeFlag qui stockera la valeur du flag --code
 var shortCodeFlag string
 
+// Variables qui stockeront les valeurs des flags de détail (--by, --referrers) et de période (--from, --to, --tz)
+var (
+	statsByFlag          string
+	statsReferrersFlag   int
+	statsBreakdownFlag   string
+	statsExcludeBotsFlag bool
+	statsVisitorsFlag    bool
+	statsFromFlag        string
+	statsToFlag          string
+	statsTZFlag          string
+)
+
 // StatsCmd représente la commande 'stats'
 var StatsCmd = &cobra.Command{
 	Use:   "stats",
@@ -24,8 +38,19 @@ var StatsCmd = &cobra.Command{
 	Long: `Cette commande permet de récupérer et d'afficher le nombre total de clics
 pour une URL courte spécifique en utilisant son code.
 
-Exemple:
-  url-shortener stats --code="xyz123"`,
+Avec --by, le détail des clics par heure, jour ou semaine est affiché sous forme de tableau.
+Avec --referrers, les N domaines qui ont envoyé le plus de clics sont affichés.
+Avec --breakdown, les clics sont répartis par navigateur, système, classe d'appareil, pays ou ville.
+Avec --exclude-bots, les clics de robots sont ignorés dans le total et la répartition.
+Avec --visitors, le nombre estimé de visiteurs uniques est détaillé par jour (UTC).
+Les détails peuvent être restreints à une période avec --from et --to.
+
+Exemples:
+  url-shortener stats --code="xyz123"
+  url-shortener stats --code="xyz123" --by=day --from=2025-06-01 --tz=Europe/Paris
+  url-shortener stats --code="xyz123" --referrers=5 --from=2025-06-01
+  url-shortener stats --code="xyz123" --breakdown=device --exclude-bots
+  url-shortener stats --code="xyz123" --visitors --from=2025-06-01`,
 	Run: func(cmds *cobra.Command, args []string) {
 		// Valider que le flag --code a été fourni
 		if shortCodeFlag == "" {
@@ -33,13 +58,33 @@ Exemple:
 			os.Exit(1)
 		}
 
+		// Lit la période des détails avant d'ouvrir la base
+		loc, err := time.LoadLocation(statsTZFlag)
+		if err != nil {
+			fmt.Printf("Erreur: Fuseau horaire inconnu '%s'\n", statsTZFlag)
+			os.Exit(1)
+		}
+		var from, to time.Time
+		if t, err := parseDateFlag("from", statsFromFlag, loc); err != nil {
+			fmt.Printf("Erreur: %v\n", err)
+			os.Exit(1)
+		} else if t != nil {
+			from = *t
+		}
+		if t, err := parseDateFlag("to", statsToFlag, loc); err != nil {
+			fmt.Printf("Erreur: %v\n", err)
+			os.Exit(1)
+		} else if t != nil {
+			to = *t
+		}
+
 		// Charger la configuration chargée globalement via cmd.GetConfig()
 		cfg := cmd.GetConfig()
 
-		// Initialiser la connexion à la base de données SQLite avec GORM
-		db, err := gorm.Open(sqlite.Open(cfg.Database.Name), &gorm.Config{})
+		// Initialiser la connexion à la base de données configurée
+		db, err := database.Open(cfg)
 		if err != nil {
-			log.Fatalf("Échec de la connexion à la base de données '%s': %v", cfg.Database.Name, err)
+			log.Fatalf("Échec de la connexion à la base de données: %v", err)
 		}
 
 		sqlDB, err := db.DB()
@@ -53,9 +98,11 @@ Exemple:
 		// Initialiser les repositories et services nécessaires
 		linkRepo := repository.NewLinkRepository(db)
 		linkService := services.NewLinkService(linkRepo)
+		clickService := services.NewClickService(repository.NewClickRepository(db))
+		visitorService := services.NewVisitorService(repository.NewVisitorRepository(db))
 
 		// Appeler GetLinkStats pour récupérer le lien et ses statistiques
-		link, totalClicks, err := linkService.GetLinkStats(shortCodeFlag)
+		link, totalClicks, err := linkService.GetLinkStats(nil, shortCodeFlag)
 		if err != nil {
 			if err == gorm.ErrRecordNotFound {
 				fmt.Printf("Erreur: Aucun lien trouvé avec le code '%s'\n", shortCodeFlag)
@@ -68,14 +115,151 @@ Exemple:
 		fmt.Printf("Statistiques pour le code court: %s\n", link.Shortcode)
 		fmt.Printf("URL longue: %s\n", link.LongURL)
 		fmt.Printf("Total de clics: %d\n", totalClicks)
+		visitors, err := visitorService.GetUniqueVisitors(link.ID, time.Time{}, time.Time{})
+		if err != nil {
+			fmt.Printf("Erreur lors de l'estimation des visiteurs uniques: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Visiteurs uniques (estimation): %d\n", visitors.Total)
+		if statsExcludeBotsFlag {
+			humanClicks, err := clickService.CountClicks(link.ID, true)
+			if err != nil {
+				fmt.Printf("Erreur lors du décompte des clics hors robots: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Printf("Clics hors robots: %d\n", humanClicks)
+		}
+		fallbackClicks, err := clickService.CountFallbackClicks(link.ID, statsExcludeBotsFlag)
+		if err != nil {
+			fmt.Printf("Erreur lors du décompte des clics redirigés vers l'URL de secours: %v\n", err)
+			os.Exit(1)
+		}
+		if fallbackClicks > 0 || link.FallbackURL != "" {
+			fmt.Printf("Clics redirigés vers l'URL de secours: %d\n", fallbackClicks)
+		}
+		if link.ExpiresAt != nil {
+			fmt.Printf("Expire le: %s\n", link.ExpiresAt.Format(time.RFC3339))
+		}
+		if link.MaxClicks > 0 {
+			fmt.Printf("Clics maximum: %d\n", link.MaxClicks)
+		}
+		if services.IsLinkExpired(link, totalClicks, time.Now()) {
+			fmt.Println("Statut: expiré")
+		}
+
+		if statsByFlag != "" {
+			printTimeSeries(clickService, link.ID, services.TimeSeriesOptions{
+				Interval: statsByFlag,
+				From:     from,
+				To:       to,
+				Location: loc,
+			})
+		}
+		if statsReferrersFlag > 0 {
+			printReferrers(clickService, link.ID, from, to, statsReferrersFlag)
+		}
+		if statsBreakdownFlag != "" {
+			printBreakdown(clickService, link.ID, statsBreakdownFlag, services.BreakdownOptions{
+				From:        from,
+				To:          to,
+				ExcludeBots: statsExcludeBotsFlag,
+			})
+		}
+		if statsVisitorsFlag {
+			printVisitors(visitorService, link.ID, from, to)
+		}
 	},
 }
 
+// printBreakdown affiche la répartition des clics d'un lien selon une dimension sous forme de tableau.
+func printBreakdown(clickService *services.ClickService, linkID uint, dimension string, opts services.BreakdownOptions) {
+	breakdown, err := clickService.GetClickBreakdown(linkID, dimension, opts)
+	if err != nil {
+		fmt.Printf("Erreur lors du calcul de la répartition: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("\nRépartition par %s:\n", breakdown.Dimension)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VALEUR\tCLICS")
+	for _, value := range breakdown.Values {
+		fmt.Fprintf(w, "%s\t%d\n", value.Value, value.Count)
+	}
+	fmt.Fprintf(w, "(inconnu)\t%d\n", breakdown.Unknown)
+	w.Flush()
+}
+
+// printVisitors affiche le nombre estimé de visiteurs uniques d'un lien par jour (UTC) sous forme de tableau.
+func printVisitors(visitorService *services.VisitorService, linkID uint, from, to time.Time) {
+	visitors, err := visitorService.GetUniqueVisitors(linkID, from, to)
+	if err != nil {
+		fmt.Printf("Erreur lors de l'estimation des visiteurs uniques: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("\nVisiteurs uniques par jour (UTC):\n")
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "JOUR\tVISITEURS")
+	for _, day := range visitors.Days {
+		fmt.Fprintf(w, "%s\t%d\n", day.Day.Format(time.DateOnly), day.Count)
+	}
+	fmt.Fprintf(w, "PÉRIODE\t%d\n", visitors.Total)
+	w.Flush()
+}
+
+// printTimeSeries affiche le nombre de clics d'un lien par intervalle de temps sous forme de tableau.
+func printTimeSeries(clickService *services.ClickService, linkID uint, opts services.TimeSeriesOptions) {
+	series, err := clickService.GetClickTimeSeries(linkID, opts)
+	if err != nil {
+		fmt.Printf("Erreur lors du calcul de la série temporelle: %v\n", err)
+		os.Exit(1)
+	}
+
+	layout := time.DateOnly
+	if series.Interval == services.IntervalHour {
+		layout = "2006-01-02 15:04"
+	}
+	fmt.Printf("\nClics par %s (%s):\n", series.Interval, series.Location)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PÉRIODE\tCLICS")
+	for _, bucket := range series.Buckets {
+		fmt.Fprintf(w, "%s\t%d\n", bucket.Start.Format(layout), bucket.Count)
+	}
+	fmt.Fprintf(w, "TOTAL\t%d\n", series.Total)
+	w.Flush()
+}
+
+// printReferrers affiche les domaines qui ont envoyé le plus de clics vers un lien, puis les clics directs.
+func printReferrers(clickService *services.ClickService, linkID uint, from, to time.Time, limit int) {
+	stats, err := clickService.GetTopReferrers(linkID, from, to, limit)
+	if err != nil {
+		fmt.Printf("Erreur lors du calcul des référents: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("\nPrincipaux référents:\n")
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "DOMAINE\tCLICS")
+	for _, referrer := range stats.Referrers {
+		fmt.Fprintf(w, "%s\t%d\n", referrer.Value, referrer.Count)
+	}
+	fmt.Fprintf(w, "(direct)\t%d\n", stats.Direct)
+	w.Flush()
+}
+
 // init() s'exécute automatiquement lors de l'importation du package.
 // Il est utilisé pour définir les flags que cette commande accepte.
 func init() {
 	// Définir le flag --code pour la commande stats
 	StatsCmd.Flags().StringVarP(&shortCodeFlag, "code", "c", "", "Code court du lien pour lequel afficher les statistiques")
+	StatsCmd.Flags().StringVar(&statsByFlag, "by", "", "Détaille les clics par intervalle: hour, day ou week")
+	StatsCmd.Flags().IntVar(&statsReferrersFlag, "referrers", 0, "Affiche les N principaux domaines référents")
+	StatsCmd.Flags().StringVar(&statsBreakdownFlag, "breakdown", "", "Répartit les clics par dimension: browser, os, device, country ou city")
+	StatsCmd.Flags().BoolVar(&statsExcludeBotsFlag, "exclude-bots", false, "Ignore les clics de robots")
+	StatsCmd.Flags().BoolVar(&statsVisitorsFlag, "visitors", false, "Détaille les visiteurs uniques par jour")
+	StatsCmd.Flags().StringVar(&statsFromFlag, "from", "", "Début de la période détaillée (RFC 3339 ou AAAA-MM-JJ)")
+	StatsCmd.Flags().StringVar(&statsToFlag, "to", "", "Fin de la période détaillée (RFC 3339 ou AAAA-MM-JJ), maintenant par défaut")
+	StatsCmd.Flags().StringVar(&statsTZFlag, "tz", "UTC", "Fuseau horaire des intervalles (ex: Europe/Paris)")
 
 	// Marquer le flag comme requis
 	StatsCmd.MarkFlagRequired("code")
@@ -0,0 +1,106 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+
+	"github.com/axellelanca/urlshortener/cmd"
+	"github.com/axellelanca/urlshortener/internal/database"
+	"github.com/axellelanca/urlshortener/internal/repository"
+	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/spf13/cobra"
+	"gorm.io/gorm"
+)
+
+// Variables qui stockeront les valeurs des flags --code, --url et --fallback-url de la commande update
+var (
+	updateCodeFlag        string
+	updateURLFlag         string
+	updateFallbackURLFlag string
+)
+
+// UpdateCmd représente la commande 'update'
+var UpdateCmd = &cobra.Command{
+	Use:   "update",
+	Short: "Change l'URL longue ou l'URL de secours d'un lien court.",
+	Long: `Cette commande modifie l'URL cible et/ou l'URL de secours d'un lien court existant.
+Le code court et les statistiques du lien sont conservés.
+Une URL de secours vide (--fallback-url="") supprime celle du lien.
+
+Exemple:
+  url-shortener update --code="xyz123" --url="https://www.example.com/nouvelle-page"
+  url-shortener update --code="xyz123" --fallback-url="https://www.example.com/maintenance"`,
+	Run: func(cmdu *cobra.Command, args []string) {
+		urlChanged := cmdu.Flags().Changed("url")
+		fallbackChanged := cmdu.Flags().Changed("fallback-url")
+		if updateCodeFlag == "" || (!urlChanged && !fallbackChanged) {
+			fmt.Println("Erreur: Le flag --code et au moins un des flags --url ou --fallback-url sont requis")
+			os.Exit(1)
+		}
+
+		var opts services.UpdateLinkOptions
+		if urlChanged {
+			if _, err := url.ParseRequestURI(updateURLFlag); err != nil {
+				fmt.Printf("Erreur: URL invalide '%s': %v\n", updateURLFlag, err)
+				os.Exit(1)
+			}
+			opts.LongURL = &updateURLFlag
+		}
+		if fallbackChanged {
+			opts.FallbackURL = &updateFallbackURLFlag
+		}
+
+		// Charger la configuration chargée globalement via cmd.GetConfig()
+		cfg := cmd.GetConfig()
+
+		// Initialiser la connexion à la base de données configurée
+		db, err := database.Open(cfg)
+		if err != nil {
+			log.Fatalf("Échec de la connexion à la base de données: %v", err)
+		}
+
+		sqlDB, err := db.DB()
+		if err != nil {
+			log.Fatalf("FATAL: Échec de l'obtention de la base de données SQL sous-jacente: %v", err)
+		}
+
+		// S'assurer que la connexion est fermée à la fin de l'exécution de la commande
+		defer sqlDB.Close()
+
+		// Initialiser les repositories et services nécessaires
+		linkRepo := repository.NewLinkRepository(db)
+		linkService := services.NewLinkService(linkRepo)
+
+		link, err := linkService.UpdateLink(nil, updateCodeFlag, opts)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				fmt.Printf("Erreur: Aucun lien trouvé avec le code '%s'\n", updateCodeFlag)
+			} else {
+				fmt.Printf("Erreur lors de la modification du lien: %v\n", err)
+			}
+			os.Exit(1)
+		}
+
+		fmt.Printf("Lien %s modifié avec succès.\n", link.Shortcode)
+		fmt.Printf("URL longue: %s\n", link.LongURL)
+		if link.FallbackURL != "" {
+			fmt.Printf("URL de secours: %s\n", link.FallbackURL)
+		}
+	},
+}
+
+// init() s'exécute automatiquement lors de l'importation du package.
+// Il est utilisé pour définir les flags que cette commande accepte.
+func init() {
+	UpdateCmd.Flags().StringVarP(&updateCodeFlag, "code", "c", "", "Code court du lien à modifier")
+	UpdateCmd.Flags().StringVarP(&updateURLFlag, "url", "u", "", "Nouvelle URL longue")
+	UpdateCmd.Flags().StringVar(&updateFallbackURLFlag, "fallback-url", "", "Nouvelle URL de secours, vide pour la supprimer")
+
+	UpdateCmd.MarkFlagRequired("code")
+
+	// Ajouter la commande à RootCmd
+	cmd.RootCmd.AddCommand(UpdateCmd)
+}
@@ -0,0 +1,272 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/axellelanca/urlshortener/cmd"
+	"github.com/axellelanca/urlshortener/internal/database"
+	"github.com/axellelanca/urlshortener/internal/repository"
+	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/spf13/cobra"
+	"gorm.io/gorm"
+)
+
+// Variables qui stockeront les valeurs des flags des sous-commandes de workspace
+var (
+	workspaceNameFlag     string
+	workspaceIDFlag       uint
+	memberKeyFlag         uint
+	memberRoleFlag        string
+	workspaceFallbackFlag string
+)
+
+// WorkspaceCmd représente la commande 'workspace'
+var WorkspaceCmd = &cobra.Command{
+	Use:   "workspace",
+	Short: "Gère les espaces de travail et leurs membres.",
+	Long: `Cette commande crée, liste et supprime les espaces de travail, et gère leurs membres.
+Un membre est une clé d'API avec un rôle : owner (gère les liens et les membres), editor (gère les liens)
+ou viewer (consulte les liens et leurs statistiques). Une requête API agit dans un espace de travail
+en envoyant l'en-tête X-Workspace-ID.
+
+Exemples:
+  url-shortener workspace create --name="marketing"
+  url-shortener workspace list
+  url-shortener workspace set-member --workspace=1 --key=2 --role=editor
+  url-shortener workspace members 1
+  url-shortener workspace set-fallback --workspace=1 --url="https://example.com/maintenance"
+  url-shortener workspace remove-member --workspace=1 --key=2
+  url-shortener workspace delete 1`,
+}
+
+// WorkspaceCreateCmd représente la commande 'workspace create'
+var WorkspaceCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Crée un espace de travail.",
+	Run: func(cmdw *cobra.Command, args []string) {
+		withWorkspaceService(func(workspaceService *services.WorkspaceService) {
+			workspace, err := workspaceService.CreateWorkspace(nil, workspaceNameFlag)
+			if err != nil {
+				fmt.Printf("Erreur lors de la création de l'espace de travail: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Printf("Espace de travail %d (%s) créé.\n", workspace.ID, workspace.Name)
+		})
+	},
+}
+
+// WorkspaceListCmd représente la commande 'workspace list'
+var WorkspaceListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Liste les espaces de travail.",
+	Run: func(cmdw *cobra.Command, args []string) {
+		withWorkspaceService(func(workspaceService *services.WorkspaceService) {
+			workspaces, err := workspaceService.ListWorkspaces(nil)
+			if err != nil {
+				fmt.Printf("Erreur lors de la lecture des espaces de travail: %v\n", err)
+				os.Exit(1)
+			}
+			if len(workspaces) == 0 {
+				fmt.Println("Aucun espace de travail.")
+				return
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "ID\tNOM\tCRÉÉ LE\tURL DE SECOURS")
+			for _, workspace := range workspaces {
+				fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", workspace.ID, workspace.Name,
+					workspace.CreatedAt.Format(time.RFC3339), workspace.FallbackURL)
+			}
+			w.Flush()
+		})
+	},
+}
+
+// WorkspaceDeleteCmd représente la commande 'workspace delete'
+var WorkspaceDeleteCmd = &cobra.Command{
+	Use:   "delete ID",
+	Short: "Supprime un espace de travail qui ne possède plus de liens.",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmdw *cobra.Command, args []string) {
+		workspaceID := parseIDArg(args[0])
+
+		withWorkspaceService(func(workspaceService *services.WorkspaceService) {
+			if err := workspaceService.DeleteWorkspace(nil, workspaceID); err != nil {
+				switch {
+				case errors.Is(err, gorm.ErrRecordNotFound):
+					fmt.Printf("Erreur: Aucun espace de travail avec l'ID %d\n", workspaceID)
+				case errors.Is(err, repository.ErrWorkspaceNotEmpty):
+					fmt.Printf("Erreur: L'espace de travail %d possède encore des liens, supprimez-les d'abord\n", workspaceID)
+				default:
+					fmt.Printf("Erreur lors de la suppression de l'espace de travail: %v\n", err)
+				}
+				os.Exit(1)
+			}
+			fmt.Printf("Espace de travail %d supprimé.\n", workspaceID)
+		})
+	},
+}
+
+// WorkspaceSetFallbackCmd représente la commande 'workspace set-fallback'
+var WorkspaceSetFallbackCmd = &cobra.Command{
+	Use:   "set-fallback",
+	Short: "Définit l'URL de secours par défaut des liens d'un espace de travail.",
+	Long: `Cette commande définit l'URL vers laquelle redirigent les liens de l'espace de travail
+sans URL de secours propre, tant que le moniteur déclare leur URL longue inaccessible.
+Une URL vide (--url="") supprime l'URL de secours de l'espace.`,
+	Run: func(cmdw *cobra.Command, args []string) {
+		withWorkspaceService(func(workspaceService *services.WorkspaceService) {
+			workspace, err := workspaceService.SetFallbackURL(nil, workspaceIDFlag, workspaceFallbackFlag)
+			if err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					fmt.Printf("Erreur: Aucun espace de travail avec l'ID %d\n", workspaceIDFlag)
+				} else {
+					fmt.Printf("Erreur lors de la modification de l'espace de travail: %v\n", err)
+				}
+				os.Exit(1)
+			}
+			if workspace.FallbackURL == "" {
+				fmt.Printf("URL de secours de l'espace de travail %d supprimée.\n", workspace.ID)
+			} else {
+				fmt.Printf("URL de secours de l'espace de travail %d: %s\n", workspace.ID, workspace.FallbackURL)
+			}
+		})
+	},
+}
+
+// WorkspaceMembersCmd représente la commande 'workspace members'
+var WorkspaceMembersCmd = &cobra.Command{
+	Use:   "members ID",
+	Short: "Liste les membres d'un espace de travail.",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmdw *cobra.Command, args []string) {
+		workspaceID := parseIDArg(args[0])
+
+		withWorkspaceService(func(workspaceService *services.WorkspaceService) {
+			members, err := workspaceService.ListMembers(nil, workspaceID)
+			if err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					fmt.Printf("Erreur: Aucun espace de travail avec l'ID %d\n", workspaceID)
+				} else {
+					fmt.Printf("Erreur lors de la lecture des membres: %v\n", err)
+				}
+				os.Exit(1)
+			}
+			if len(members) == 0 {
+				fmt.Println("Aucun membre.")
+				return
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "CLÉ\tRÔLE\tAJOUTÉE LE")
+			for _, member := range members {
+				fmt.Fprintf(w, "%d\t%s\t%s\n", member.APIKeyID, member.Role, member.CreatedAt.Format(time.RFC3339))
+			}
+			w.Flush()
+		})
+	},
+}
+
+// WorkspaceSetMemberCmd représente la commande 'workspace set-member'
+var WorkspaceSetMemberCmd = &cobra.Command{
+	Use:   "set-member",
+	Short: "Ajoute une clé d'API à un espace de travail, ou change son rôle.",
+	Run: func(cmdw *cobra.Command, args []string) {
+		withWorkspaceService(func(workspaceService *services.WorkspaceService) {
+			member, err := workspaceService.SetMember(nil, workspaceIDFlag, memberKeyFlag, memberRoleFlag)
+			if err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					fmt.Printf("Erreur: Aucun espace de travail avec l'ID %d\n", workspaceIDFlag)
+				} else {
+					fmt.Printf("Erreur lors de l'ajout du membre: %v\n", err)
+				}
+				os.Exit(1)
+			}
+			fmt.Printf("Clé %d membre de l'espace de travail %d avec le rôle %s.\n",
+				member.APIKeyID, member.WorkspaceID, member.Role)
+		})
+	},
+}
+
+// WorkspaceRemoveMemberCmd représente la commande 'workspace remove-member'
+var WorkspaceRemoveMemberCmd = &cobra.Command{
+	Use:   "remove-member",
+	Short: "Retire une clé d'API d'un espace de travail.",
+	Run: func(cmdw *cobra.Command, args []string) {
+		withWorkspaceService(func(workspaceService *services.WorkspaceService) {
+			if err := workspaceService.RemoveMember(nil, workspaceIDFlag, memberKeyFlag); err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					fmt.Printf("Erreur: La clé %d n'est pas membre de l'espace de travail %d\n", memberKeyFlag, workspaceIDFlag)
+				} else {
+					fmt.Printf("Erreur lors du retrait du membre: %v\n", err)
+				}
+				os.Exit(1)
+			}
+			fmt.Printf("Clé %d retirée de l'espace de travail %d.\n", memberKeyFlag, workspaceIDFlag)
+		})
+	},
+}
+
+// parseIDArg convertit un argument en identifiant, ou termine la commande s'il n'est pas un entier positif.
+func parseIDArg(arg string) uint {
+	id, err := strconv.ParseUint(arg, 10, 0)
+	if err != nil || id == 0 {
+		fmt.Println("Erreur: ID doit être un entier positif")
+		os.Exit(1)
+	}
+	return uint(id)
+}
+
+// withWorkspaceService ouvre la base de données configurée et appelle 'run' avec un WorkspaceService.
+// La CLI agit sans clé d'API (caller nil) : elle a tous les droits d'administration.
+func withWorkspaceService(run func(workspaceService *services.WorkspaceService)) {
+	// Charger la configuration chargée globalement via cmd.GetConfig()
+	cfg := cmd.GetConfig()
+
+	// Initialiser la connexion à la base de données configurée
+	db, err := database.Open(cfg)
+	if err != nil {
+		log.Fatalf("Échec de la connexion à la base de données: %v", err)
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		log.Fatalf("FATAL: Échec de l'obtention de la base de données SQL sous-jacente: %v", err)
+	}
+
+	// S'assurer que la connexion est fermée à la fin de l'exécution de la commande
+	defer sqlDB.Close()
+
+	run(services.NewWorkspaceService(repository.NewWorkspaceRepository(db), repository.NewAPIKeyRepository(db)))
+}
+
+func init() {
+	WorkspaceCreateCmd.Flags().StringVarP(&workspaceNameFlag, "name", "n", "", "Nom de l'espace de travail")
+	WorkspaceCreateCmd.MarkFlagRequired("name")
+
+	for _, memberCmd := range []*cobra.Command{WorkspaceSetMemberCmd, WorkspaceRemoveMemberCmd} {
+		memberCmd.Flags().UintVarP(&workspaceIDFlag, "workspace", "w", 0, "ID de l'espace de travail")
+		memberCmd.Flags().UintVarP(&memberKeyFlag, "key", "k", 0, "ID de la clé d'API")
+		memberCmd.MarkFlagRequired("workspace")
+		memberCmd.MarkFlagRequired("key")
+	}
+	WorkspaceSetMemberCmd.Flags().StringVarP(&memberRoleFlag, "role", "r", "", "Rôle du membre : owner, editor ou viewer")
+	WorkspaceSetMemberCmd.MarkFlagRequired("role")
+
+	WorkspaceSetFallbackCmd.Flags().UintVarP(&workspaceIDFlag, "workspace", "w", 0, "ID de l'espace de travail")
+	WorkspaceSetFallbackCmd.Flags().StringVarP(&workspaceFallbackFlag, "url", "u", "", "URL de secours, vide pour la supprimer")
+	WorkspaceSetFallbackCmd.MarkFlagRequired("workspace")
+	WorkspaceSetFallbackCmd.MarkFlagRequired("url")
+
+	WorkspaceCmd.AddCommand(WorkspaceCreateCmd, WorkspaceListCmd, WorkspaceDeleteCmd, WorkspaceSetFallbackCmd,
+		WorkspaceMembersCmd, WorkspaceSetMemberCmd, WorkspaceRemoveMemberCmd)
+
+	// Ajouter la commande à RootCmd
+	cmd.RootCmd.AddCommand(WorkspaceCmd)
+}
@@ -1,6 +1,8 @@
 package server
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
@@ -10,18 +12,23 @@ import (
 	"time"
 
 	"github.com/axellelanca/urlshortener/cmd"
+	"github.com/axellelanca/urlshortener/internal/analytics"
 	"github.com/axellelanca/urlshortener/internal/api"
+	"github.com/axellelanca/urlshortener/internal/database"
+	"github.com/axellelanca/urlshortener/internal/metrics"
+	"github.com/axellelanca/urlshortener/internal/migrations"
 	"github.com/axellelanca/urlshortener/internal/models"
 	"github.com/axellelanca/urlshortener/internal/monitor"
+	"github.com/axellelanca/urlshortener/internal/notify"
+	"github.com/axellelanca/urlshortener/internal/privacy"
 	"github.com/axellelanca/urlshortener/internal/repository"
 	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/axellelanca/urlshortener/internal/spool"
 	"github.com/axellelanca/urlshortener/internal/workers"
 	"github.com/gin-gonic/gin"
-	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 
 	"github.com/spf13/cobra"
-	// Driver SQLite pour GORM
 )
 
 // RunServerCmd représente la commande 'run-server' de Cobra.
@@ -41,18 +48,35 @@ var RunServerCmd = &cobra.Command{
 
 		var err error
 
-		log.Printf("Tentative de connexion à la base de données : %s", cmd.Cfg.Database.Name)
+		log.Printf("Tentative de connexion à la base de données : %s", database.Describe(cmd.Cfg))
 
-		DB, err = gorm.Open(sqlite.Open(cmd.Cfg.Database.Name), &gorm.Config{})
+		DB, err = database.Open(cmd.Cfg)
 		if err != nil {
-			log.Fatalf("Échec de la connexion à la base de données '%s': %v", cmd.Cfg.Database.Name, err)
+			log.Fatalf("Échec de la connexion à la base de données: %v", err)
 		}
 
-		log.Println("Connexion à la base de données SQLite réussie !")
+		log.Println("Connexion à la base de données réussie !")
+
+		// Le serveur n'applique pas les migrations lui-même : il signale seulement un schéma en retard.
+		if migrator, err := migrations.NewMigrator(DB); err != nil {
+			log.Printf("Attention: impossible de charger les migrations: %v", err)
+		} else if pending, err := migrator.Pending(); err != nil {
+			log.Printf("Attention: impossible de vérifier les migrations en attente: %v", err)
+		} else if len(pending) > 0 {
+			log.Printf("Attention: %d migration(s) en attente, lancez la commande 'migrate up'.", len(pending))
+		}
 
 		// TODO : Initialiser les repositories.
 		// Créez des instances de GormLinkRepository et GormClickRepository.
-		linkRepo := repository.NewLinkRepository(DB)
+		var linkRepo repository.LinkRepository = repository.NewLinkRepository(DB)
+		if cmd.Cfg.Cache.Enabled {
+			api.LinkCache = repository.NewCachedLinkRepository(linkRepo, cmd.Cfg.Cache.Size,
+				time.Duration(cmd.Cfg.Cache.TTLSeconds)*time.Second,
+				time.Duration(cmd.Cfg.Cache.NegativeTTLSeconds)*time.Second)
+			linkRepo = api.LinkCache
+			metrics.RegisterLinkCache(api.LinkCache)
+			log.Printf("Cache des liens activé (%d entrées, TTL %ds).", cmd.Cfg.Cache.Size, cmd.Cfg.Cache.TTLSeconds)
+		}
 		clickRepo := repository.NewClickRepository(DB)
 		// Laissez le log
 		log.Println("Repositories initialisés.")
@@ -60,27 +84,152 @@ var RunServerCmd = &cobra.Command{
 		// Créez des instances de LinkService et ClickService, en leur passant les repositories nécessaires.
 		// Laissez le log
 		linkService := services.NewLinkService(linkRepo)
-		//clickService := services.NewClickService(clickRepo)
+		clickService := services.NewClickService(clickRepo)
+		visitorService := services.NewVisitorService(repository.NewVisitorRepository(DB))
+		apiKeyRepo := repository.NewAPIKeyRepository(DB)
+		apiKeyService := services.NewAPIKeyService(apiKeyRepo)
+		workspaceRepo := repository.NewWorkspaceRepository(DB)
+		workspaceService := services.NewWorkspaceService(workspaceRepo, apiKeyRepo)
 		log.Println("Services métiers initialisés.")
 
+		// Configuration des workers de clics : taille des lots, spool sur disque
+		// et enrichissements appliqués à chaque clic avant sa persistance.
+		workerCfg := workers.ClickWorkerConfig{
+			WorkerCount:   cmd.Cfg.Analytics.WorkerCount,
+			BatchSize:     cmd.Cfg.Analytics.BatchSize,
+			FlushInterval: time.Duration(cmd.Cfg.Analytics.FlushIntervalMs) * time.Millisecond,
+			Enrichers: []workers.ClickEnricher{
+				workers.UserAgentEnricher{},
+				workers.VisitorEnricher{Visitors: visitorService},
+			},
+			Observers: []workers.ClickObserver{
+				workers.UniqueVisitorObserver{Visitors: visitorService},
+				workers.PendingClicksObserver{Links: linkService},
+			},
+		}
+
+		// La géolocalisation n'est active que si une base GeoIP est configurée.
+		var geoResolver *analytics.GeoIPResolver
+		if cmd.Cfg.GeoIP.DatabasePath != "" {
+			geoResolver, err = analytics.OpenGeoIP(cmd.Cfg.GeoIP.DatabasePath)
+			if err != nil {
+				log.Printf("Attention: géolocalisation des clics désactivée: %v", err)
+			} else {
+				workerCfg.Enrichers = append(workerCfg.Enrichers, workers.GeoIPEnricher{Resolver: geoResolver})
+				log.Printf("Base GeoIP chargée depuis '%s'.", cmd.Cfg.GeoIP.DatabasePath)
+			}
+		}
+
+		// L'anonymisation des adresses IP vient en dernier : les enrichissements précédents
+		// (géolocalisation) ont besoin de l'adresse en clair.
+		ipAnonymizer, err := privacy.NewIPAnonymizer(cmd.Cfg.Privacy.IPMode, cmd.Cfg.Privacy.HMACSecret)
+		if err != nil {
+			log.Fatalf("Configuration de confidentialité invalide: %v", err)
+		}
+		if ipAnonymizer.Enabled() {
+			workerCfg.Enrichers = append(workerCfg.Enrichers, workers.IPAnonymizerEnricher{Anonymizer: ipAnonymizer})
+			log.Printf("Anonymisation des adresses IP des clics activée (mode: %s).", ipAnonymizer.Mode())
+		}
+
+		// Ouvre le spool sur disque et rejoue les clics restés en attente lors de l'exécution précédente,
+		// avant que les workers ne commencent à recevoir de nouveaux clics.
+		if cmd.Cfg.Analytics.SpoolDir != "" {
+			api.ClickSpool, err = spool.Open(cmd.Cfg.Analytics.SpoolDir, int64(cmd.Cfg.Analytics.SpoolSegmentKB)*1024, ipAnonymizer)
+			if err != nil {
+				log.Fatalf("Échec de l'ouverture du spool de clics: %v", err)
+			}
+			workerCfg.Spool = api.ClickSpool
+			replayed, err := workers.ReplaySpool(workerCfg, clickRepo)
+			if err != nil {
+				log.Printf("Attention: rejeu du spool de clics incomplet, les segments en échec seront retentés au prochain démarrage: %v", err)
+			}
+			log.Printf("Spool de clics ouvert dans '%s', %d clic(s) rejoué(s).", cmd.Cfg.Analytics.SpoolDir, replayed)
+		}
+
 		// Le channel est bufferisé avec la taille configurée.
 		// Passez le channel et le clickRepo aux workers.
 		api.ClickEventsChannel = make(chan models.ClickEvent, cmd.Cfg.Analytics.BufferSize)
-		go workers.StartClickWorkers(cmd.Cfg.Analytics.WorkerCount, api.ClickEventsChannel, clickRepo)
+		clickWorkers := workers.StartClickWorkers(workerCfg, api.ClickEventsChannel, clickRepo)
+		metrics.RegisterClickEventsChannel(
+			func() int { return len(api.ClickEventsChannel) },
+			func() int { return cap(api.ClickEventsChannel) })
 		log.Printf("Channel d'événements de clic initialisé avec un buffer de %d. %d worker(s) de clics démarré(s).",
 			cmd.Cfg.Analytics.BufferSize, cmd.Cfg.Analytics.WorkerCount)
 
 		// Utilisez l'intervalle configuré (cfg.Monitor.IntervalMinutes).
-		// Lancez le moniteur dans sa propre goroutine.
+		// Lancez le moniteur dans sa propre goroutine, arrêtée par l'annulation de monitorCtx.
 		monitorInterval := time.Duration(cmd.Cfg.Monitor.IntervalMinutes) * time.Minute
-		urlMonitor := monitor.NewUrlMonitor(linkRepo, monitorInterval) // Le moniteur a besoin du linkRepo et de l'interval
-		go urlMonitor.Start()
+		// Les changements d'état détectés par le moniteur sont envoyés sur les canaux de notification configurés.
+		notifier, err := notify.NewDispatcherFromConfig(cmd.Cfg)
+		if err != nil {
+			log.Fatalf("Configuration des notifications invalide: %v", err)
+		}
+		log.Printf("%d canal(aux) de notification configuré(s).", notifier.Len())
+
+		healthyStatuses, err := monitor.ParseStatusSet(cmd.Cfg.Monitor.HealthyStatuses)
+		if err != nil {
+			log.Fatalf("Configuration du moniteur invalide: %v", err)
+		}
+		contentMode, err := monitor.ParseContentMode(cmd.Cfg.Monitor.ContentCheck)
+		if err != nil {
+			log.Fatalf("Configuration du moniteur invalide: %v", err)
+		}
+		tlsWarning := time.Duration(cmd.Cfg.Monitor.TLSWarnDays) * 24 * time.Hour
+		linkCheckRepo := repository.NewLinkCheckRepository(DB)
+		urlMonitor := monitor.NewUrlMonitor(linkRepo, linkCheckRepo, notifier, monitor.Config{ // Le moniteur a besoin du linkRepo et de l'interval
+			Interval:           monitorInterval,
+			Concurrency:        cmd.Cfg.Monitor.Concurrency,
+			PerHostConcurrency: cmd.Cfg.Monitor.PerHostConcurrency,
+			Timeout:            time.Duration(cmd.Cfg.Monitor.TimeoutSeconds) * time.Second,
+			HistoryRetention:   time.Duration(cmd.Cfg.Monitor.HistoryDays) * 24 * time.Hour,
+			HealthyStatuses:    healthyStatuses,
+			FailureThreshold:   cmd.Cfg.Monitor.FailureThreshold,
+			RecoveryThreshold:  cmd.Cfg.Monitor.RecoveryThreshold,
+			TLSWarning:         tlsWarning,
+			ContentMode:        contentMode,
+			ContentMaxBytes:    int64(cmd.Cfg.Monitor.ContentMaxKB) * 1024,
+		})
+		monitorCtx, stopMonitor := context.WithCancel(context.Background())
+		defer stopMonitor()
+		monitorDone := make(chan struct{})
+		go func() {
+			defer close(monitorDone)
+			urlMonitor.Start(monitorCtx)
+		}()
 		log.Printf("Moniteur d'URLs démarré avec un intervalle de %v.", monitorInterval)
 
+		// La politique de rétention des clics tourne dans sa propre goroutine, arrêtée avec le moniteur.
+		retentionDone := make(chan struct{})
+		if cmd.Cfg.Privacy.RetentionDays > 0 {
+			action := cmd.Cfg.Privacy.RetentionAction
+			if action != workers.RetentionDelete && action != workers.RetentionAggregate {
+				log.Fatalf("Action de rétention invalide '%s': utilisez %s ou %s.", action, workers.RetentionDelete, workers.RetentionAggregate)
+			}
+			retentionJob := workers.NewRetentionJob(clickRepo, visitorService,
+				time.Duration(cmd.Cfg.Privacy.RetentionDays)*24*time.Hour, action,
+				time.Duration(cmd.Cfg.Privacy.RetentionIntervalMinutes)*time.Minute)
+			go func() {
+				defer close(retentionDone)
+				retentionJob.Start(monitorCtx)
+			}()
+		} else {
+			close(retentionDone)
+		}
+
 		// Passez les services nécessaires aux fonctions de configuration des routes.
 		// Pas toucher au log
 		router := gin.Default()
-		api.SetupRoutes(router, linkService)
+		// Sans proxy de confiance, les en-têtes X-Forwarded-For ne sont pas pris en compte :
+		// un client ne peut pas usurper une autre adresse pour contourner la limite de débit.
+		if err := router.SetTrustedProxies(cmd.Cfg.Server.TrustedProxies); err != nil {
+			log.Fatalf("Liste des proxies de confiance invalide: %v", err)
+		}
+		// Les redirections utilisent l'URL de secours des liens que le moniteur déclare inaccessibles.
+		api.SetupRoutes(router, linkService, clickService, visitorService, apiKeyService, workspaceService,
+			services.NewHealthService(linkCheckRepo, tlsWarning), services.NewFallbackService(workspaceRepo, urlMonitor))
+		if !cmd.Cfg.Auth.Enabled {
+			log.Println("Attention: authentification désactivée, l'API /api/v1 est accessible sans clé, limitée aux liens créés sans clé.")
+		}
 		log.Println("Routes API configurées.")
 
 		// Créer le serveur HTTP Gin
@@ -91,10 +240,14 @@ var RunServerCmd = &cobra.Command{
 		}
 
 		// Démarrer le serveur Gin dans une goroutine anonyme pour ne pas bloquer.
-		log.Printf("Démarrage du serveur sur %s", srv.Addr)
-		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("Erreur lors du démarrage du serveur: %v", err)
-		}
+		// Une erreur de démarrage (port déjà utilisé...) est remontée par serverErr.
+		serverErr := make(chan error, 1)
+		go func() {
+			log.Printf("Démarrage du serveur sur %s", srv.Addr)
+			if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				serverErr <- err
+			}
+		}()
 
 		log.Printf("Serveur démarré sur le port %s", port)
 
@@ -104,17 +257,102 @@ var RunServerCmd = &cobra.Command{
 		signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM) // Attendre Ctrl+C ou signal d'arrêt
 
 		// Bloquer jusqu'à ce qu'un signal d'arrêt soit reçu.
-		<-quit
-		log.Println("Signal d'arrêt reçu. Arrêt du serveur...")
+		select {
+		case <-quit:
+			log.Println("Signal d'arrêt reçu. Arrêt du serveur...")
+		case err := <-serverErr:
+			log.Printf("Erreur lors du démarrage du serveur: %v. Arrêt des processus de fond...", err)
+		}
+
+		// Toutes les étapes de l'arrêt partagent le même délai maximal.
+		shutdownTimeout := time.Duration(cmd.Cfg.Server.ShutdownTimeoutSeconds) * time.Second
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+
+		// 1. Arrêt propre du serveur HTTP : plus de nouvelles connexions, les requêtes en cours se terminent.
+		if err := srv.Shutdown(ctx); err != nil {
+			log.Printf("Erreur lors de l'arrêt du serveur HTTP: %v", err)
+		}
+
+		// 2. Arrêt du moniteur et de la politique de rétention : la vérification en cours s'interrompt au prochain lien.
+		stopMonitor()
+
+		// 3. Fermeture du channel : les workers écrivent leurs derniers lots puis se terminent.
+		// Si l'arrêt du serveur HTTP a dépassé son délai, les handlers encore en cours envoient leurs clics au spool.
+		api.CloseClickEvents()
+		log.Println("Arrêt en cours... Attente de la fin des workers de clics.")
+		if !waitOrTimeout(ctx, clickWorkers.Wait) {
+			log.Println("Attention: délai d'arrêt dépassé avant la fin des workers de clics.")
+		}
+		if !waitOrTimeout(ctx, func() { <-monitorDone }) {
+			log.Println("Attention: délai d'arrêt dépassé avant la fin du moniteur d'URLs.")
+		}
+		if !waitOrTimeout(ctx, notifier.Wait) {
+			log.Println("Attention: délai d'arrêt dépassé avant l'envoi des dernières notifications.")
+		}
+		if !waitOrTimeout(ctx, func() { <-retentionDone }) {
+			log.Println("Attention: délai d'arrêt dépassé avant la fin de la politique de rétention.")
+		}
+
+		// 4. Les clics que les workers n'ont pas eu le temps de lire sont conservés dans le spool
+		// pour le prochain démarrage.
+		if api.ClickSpool != nil {
+			spoolPendingClicks()
+			if err := api.ClickSpool.Close(); err != nil {
+				log.Printf("Erreur lors de la fermeture du spool de clics: %v", err)
+			}
+		}
 
-		// Arrêt propre du serveur HTTP avec un timeout.
-		log.Println("Arrêt en cours... Donnez un peu de temps aux workers pour finir.")
-		time.Sleep(5 * time.Second)
+		// Les workers ne géolocalisent plus aucun clic : la base GeoIP peut être libérée.
+		if geoResolver != nil {
+			if err := geoResolver.Close(); err != nil {
+				log.Printf("Erreur lors de la fermeture de la base GeoIP: %v", err)
+			}
+		}
+
+		// 5. Fermeture de la connexion à la base de données.
+		if sqlDB, err := DB.DB(); err == nil {
+			if err := sqlDB.Close(); err != nil {
+				log.Printf("Erreur lors de la fermeture de la base de données: %v", err)
+			}
+		}
 
 		log.Println("Serveur arrêté proprement.")
 	},
 }
 
+// waitOrTimeout exécute la fonction bloquante 'wait' et retourne true si elle se termine
+// avant l'expiration de ctx, false sinon.
+func waitOrTimeout(ctx context.Context, wait func()) bool {
+	done := make(chan struct{})
+	go func() {
+		wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// spoolPendingClicks vide ClickEventsChannel, qui doit être fermé, et écrit les événements restants dans le spool.
+func spoolPendingClicks() {
+	var pending []models.ClickEvent
+	for event := range api.ClickEventsChannel {
+		pending = append(pending, event)
+	}
+	if len(pending) == 0 {
+		return
+	}
+	if err := api.ClickSpool.Append(pending...); err != nil {
+		log.Printf("Erreur: %d clic(s) en attente n'ont pas pu être écrits dans le spool: %v", len(pending), err)
+		return
+	}
+	log.Printf("%d clic(s) en attente écrits dans le spool.", len(pending))
+}
+
 func init() {
 	cmd.RootCmd.AddCommand(RunServerCmd)
 }
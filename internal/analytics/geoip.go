@@ -0,0 +1,63 @@
+package analytics
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/oschwald/maxminddb-golang"
+)
+
+// GeoIPResolver résout le pays et la ville d'une adresse IP à partir d'une base locale
+// au format MaxMind DB (GeoLite2-Country, GeoLite2-City ou équivalent). Aucun appel réseau n'est fait.
+type GeoIPResolver struct {
+	reader *maxminddb.Reader
+}
+
+// geoRecord est le sous-ensemble des champs MaxMind DB utilisés.
+// Les bases "Country" n'ont pas de champ city : City reste alors vide.
+type geoRecord struct {
+	Country struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"country"`
+	RegisteredCountry struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"registered_country"`
+	City struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"city"`
+}
+
+// OpenGeoIP ouvre la base MaxMind DB située à 'path'. Le fichier est projeté en mémoire.
+func OpenGeoIP(path string) (*GeoIPResolver, error) {
+	reader, err := maxminddb.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open GeoIP database '%s': %w", path, err)
+	}
+	return &GeoIPResolver{reader: reader}, nil
+}
+
+// Lookup retourne le code pays ISO 3166-1 alpha-2 et le nom anglais de la ville de l'adresse IP.
+// Les valeurs inconnues (adresse privée, absente de la base, illisible) sont retournées vides.
+func (g *GeoIPResolver) Lookup(ip string) (country, city string) {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return "", ""
+	}
+
+	var record geoRecord
+	if err := g.reader.Lookup(parsed, &record); err != nil {
+		return "", ""
+	}
+
+	country = record.Country.ISOCode
+	if country == "" {
+		// Pays d'enregistrement du bloc d'adresses, à défaut de localisation plus précise.
+		country = record.RegisteredCountry.ISOCode
+	}
+	return country, record.City.Names["en"]
+}
+
+// Close libère la base MaxMind DB.
+func (g *GeoIPResolver) Close() error {
+	return g.reader.Close()
+}
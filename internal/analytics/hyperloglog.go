@@ -0,0 +1,85 @@
+package analytics
+
+import (
+	"errors"
+	"math"
+	"math/bits"
+)
+
+// hllPrecision est le nombre de bits du hash utilisés pour choisir un registre.
+// 2^12 = 4096 registres d'un octet : 4 Ko par sketch pour une erreur type d'environ 1,6 %.
+const hllPrecision = 12
+
+const hllRegisters = 1 << hllPrecision
+
+// ErrInvalidSketch est retournée quand un sketch sérialisé n'a pas la taille attendue.
+var ErrInvalidSketch = errors.New("invalid HyperLogLog sketch")
+
+// HyperLogLog estime le nombre d'éléments distincts d'un ensemble avec une mémoire constante.
+// Deux sketches se fusionnent sans perte (union des ensembles), ce qui permet de stocker
+// un sketch par lien et par jour et d'en déduire le nombre de visiteurs uniques sur toute période.
+type HyperLogLog struct {
+	registers [hllRegisters]uint8
+}
+
+// NewHyperLogLog crée un sketch vide.
+func NewHyperLogLog() *HyperLogLog {
+	return &HyperLogLog{}
+}
+
+// Add ajoute à l'ensemble l'élément dont 'hash' est un hash 64 bits uniformément distribué.
+func (h *HyperLogLog) Add(hash uint64) {
+	index := hash >> (64 - hllPrecision)
+	// Rang du premier bit à 1 dans les bits restants, le bit sentinelle bornant le résultat.
+	rank := uint8(bits.LeadingZeros64(hash<<hllPrecision|1<<(hllPrecision-1)) + 1)
+	if rank > h.registers[index] {
+		h.registers[index] = rank
+	}
+}
+
+// Merge ajoute à h les éléments de 'other'.
+func (h *HyperLogLog) Merge(other *HyperLogLog) {
+	for i, rank := range other.registers {
+		if rank > h.registers[i] {
+			h.registers[i] = rank
+		}
+	}
+}
+
+// Estimate retourne le nombre estimé d'éléments distincts.
+func (h *HyperLogLog) Estimate() uint64 {
+	const m = float64(hllRegisters)
+	alpha := 0.7213 / (1 + 1.079/m)
+
+	sum := 0.0
+	zeros := 0
+	for _, rank := range h.registers {
+		sum += math.Ldexp(1, -int(rank))
+		if rank == 0 {
+			zeros++
+		}
+	}
+
+	estimate := alpha * m * m / sum
+	// Correction des petits effectifs : comptage linéaire sur les registres vides.
+	if estimate <= 2.5*m && zeros > 0 {
+		estimate = m * math.Log(m/float64(zeros))
+	}
+	return uint64(estimate + 0.5)
+}
+
+// MarshalBinary retourne les registres du sketch, pour leur stockage en base.
+func (h *HyperLogLog) MarshalBinary() ([]byte, error) {
+	data := make([]byte, hllRegisters)
+	copy(data, h.registers[:])
+	return data, nil
+}
+
+// UnmarshalBinary remplace les registres du sketch par ceux produits par MarshalBinary.
+func (h *HyperLogLog) UnmarshalBinary(data []byte) error {
+	if len(data) != hllRegisters {
+		return ErrInvalidSketch
+	}
+	copy(h.registers[:], data)
+	return nil
+}
@@ -0,0 +1,135 @@
+package analytics
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+// splitmix64 produit des hashes 64 bits uniformément distribués et reproductibles.
+func splitmix64(x uint64) uint64 {
+	x += 0x9e3779b97f4a7c15
+	x = (x ^ (x >> 30)) * 0xbf58476d1ce4e5b9
+	x = (x ^ (x >> 27)) * 0x94d049bb133111eb
+	return x ^ (x >> 31)
+}
+
+// sketchOf retourne un sketch contenant les éléments [from, to).
+func sketchOf(from, to uint64) *HyperLogLog {
+	h := NewHyperLogLog()
+	for i := from; i < to; i++ {
+		h.Add(splitmix64(i))
+	}
+	return h
+}
+
+func TestHyperLogLogEstimate(t *testing.T) {
+	tests := []struct {
+		name      string
+		distinct  uint64
+		repeats   int     // Nombre d'ajouts de chaque élément
+		tolerance float64 // Erreur relative admise
+	}{
+		{"empty", 0, 1, 0},
+		{"single element", 1, 1, 0},
+		{"duplicates", 10, 50, 0},
+		{"small set", 100, 1, 0.05},
+		{"linear counting range", 5000, 2, 0.05},
+		{"large set", 100000, 1, 0.05},
+		{"very large set", 1000000, 1, 0.05},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHyperLogLog()
+			for r := 0; r < tt.repeats; r++ {
+				for i := uint64(0); i < tt.distinct; i++ {
+					h.Add(splitmix64(i))
+				}
+			}
+			got := h.Estimate()
+			if diff := math.Abs(float64(got) - float64(tt.distinct)); diff > tt.tolerance*float64(tt.distinct) {
+				t.Errorf("Estimate() = %d, want %d ± %.0f%%", got, tt.distinct, tt.tolerance*100)
+			}
+		})
+	}
+}
+
+func TestHyperLogLogSentinel(t *testing.T) {
+	tests := []struct {
+		name     string
+		hash     uint64
+		register int
+		wantRank uint8
+	}{
+		{"first bit set", 1 << (63 - hllPrecision), 0, 1},
+		{"third bit set", 1 << (61 - hllPrecision), 0, 3},
+		{"no bit set", 0, 0, 64 - hllPrecision + 1},
+		{"last register, no bit set", (hllRegisters - 1) << (64 - hllPrecision), hllRegisters - 1, 64 - hllPrecision + 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHyperLogLog()
+			h.Add(tt.hash)
+			if got := h.registers[tt.register]; got != tt.wantRank {
+				t.Errorf("register %d = %d, want %d", tt.register, got, tt.wantRank)
+			}
+		})
+	}
+}
+
+func TestHyperLogLogMerge(t *testing.T) {
+	tests := []struct {
+		name         string
+		left, right  [2]uint64
+		wantDistinct uint64
+	}{
+		{"disjoint", [2]uint64{0, 20000}, [2]uint64{20000, 50000}, 50000},
+		{"overlapping", [2]uint64{0, 30000}, [2]uint64{10000, 40000}, 40000},
+		{"identical", [2]uint64{0, 10000}, [2]uint64{0, 10000}, 10000},
+		{"empty right", [2]uint64{0, 10000}, [2]uint64{0, 0}, 10000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			merged := sketchOf(tt.left[0], tt.left[1])
+			merged.Merge(sketchOf(tt.right[0], tt.right[1]))
+
+			union := sketchOf(min(tt.left[0], tt.right[0]), max(tt.left[1], tt.right[1]))
+			if tt.right[0] == tt.right[1] {
+				union = sketchOf(tt.left[0], tt.left[1])
+			}
+			if merged.registers != union.registers {
+				t.Error("merged sketch differs from the sketch of the union")
+			}
+			got := merged.Estimate()
+			if diff := math.Abs(float64(got) - float64(tt.wantDistinct)); diff > 0.05*float64(tt.wantDistinct) {
+				t.Errorf("Estimate() = %d, want %d ± 5%%", got, tt.wantDistinct)
+			}
+		})
+	}
+}
+
+func TestHyperLogLogBinary(t *testing.T) {
+	original := sketchOf(0, 5000)
+	data, err := original.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	restored := NewHyperLogLog()
+	if err := restored.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if restored.registers != original.registers {
+		t.Fatal("round trip altered the registers")
+	}
+
+	data[0]++
+	if restored.registers[0] == data[0] {
+		t.Error("sketch shares its registers with the unmarshalled data")
+	}
+
+	for _, size := range []int{0, hllRegisters - 1, hllRegisters + 1} {
+		if err := NewHyperLogLog().UnmarshalBinary(make([]byte, size)); !errors.Is(err, ErrInvalidSketch) {
+			t.Errorf("UnmarshalBinary(%d bytes) error = %v, want ErrInvalidSketch", size, err)
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package analytics
+
+import (
+	"net/url"
+	"strings"
+)
+
+// maxHostLength est la longueur maximale d'un nom de domaine (RFC 1035).
+const maxHostLength = 253
+
+// NormalizeReferrer réduit la valeur de l'en-tête Referer au nom d'hôte du site d'origine :
+// en minuscules, sans port ni préfixe "www.". Une valeur vide ou illisible donne une chaîne vide,
+// qui désigne un accès direct (lien tapé, favori, application sans en-tête Referer).
+func NormalizeReferrer(raw string) string {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return ""
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil || u.Hostname() == "" {
+		return ""
+	}
+
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	host = strings.TrimPrefix(host, "www.")
+	if len(host) > maxHostLength {
+		return ""
+	}
+	return host
+}
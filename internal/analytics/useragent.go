@@ -0,0 +1,131 @@
+package analytics
+
+import (
+	_ "embed" // Pour embarquer le jeu de règles dans le binaire
+	"encoding/json"
+	"regexp"
+)
+
+// Classes d'appareil produites par ParseUserAgent.
+const (
+	DeviceDesktop = "desktop"
+	DeviceMobile  = "mobile"
+	DeviceTablet  = "tablet"
+)
+
+// uaRulesJSON est le jeu de règles de reconnaissance des User-Agents, embarqué dans le binaire.
+// Les règles de chaque liste sont évaluées dans l'ordre : la première qui correspond l'emporte,
+// d'où par exemple Edge avant Chrome, dont il reprend le User-Agent.
+//
+//go:embed ua_rules.json
+var uaRulesJSON []byte
+
+// UserAgentInfo est le résultat de l'analyse d'un User-Agent.
+// Les familles inconnues sont laissées vides.
+type UserAgentInfo struct {
+	Browser     string
+	OS          string
+	DeviceClass string
+	IsBot       bool
+}
+
+// familyRule associe une expression régulière à une famille de navigateur, de système ou d'appareil.
+type familyRule struct {
+	value   string
+	pattern *regexp.Regexp
+	exclude *regexp.Regexp // Optionnel : la règle ne s'applique pas si cette expression correspond
+}
+
+// userAgentRules est la forme compilée de ua_rules.json.
+type userAgentRules struct {
+	bots          []*regexp.Regexp
+	browsers      []familyRule
+	os            []familyRule
+	devices       []familyRule
+	defaultDevice string
+}
+
+// defaultRules est compilé une seule fois au chargement du package : une erreur dans le
+// fichier embarqué est une erreur de programmation et fait échouer le démarrage.
+var defaultRules = mustCompileRules(uaRulesJSON)
+
+// ParseUserAgent déduit du User-Agent la famille de navigateur, la famille de système,
+// la classe d'appareil et s'il s'agit d'un robot. Un User-Agent vide est considéré comme un robot :
+// les navigateurs en envoient toujours un.
+func ParseUserAgent(userAgent string) UserAgentInfo {
+	if userAgent == "" {
+		return UserAgentInfo{IsBot: true}
+	}
+
+	info := UserAgentInfo{
+		Browser:     matchFamily(defaultRules.browsers, userAgent),
+		OS:          matchFamily(defaultRules.os, userAgent),
+		DeviceClass: matchFamily(defaultRules.devices, userAgent),
+	}
+	if info.DeviceClass == "" {
+		info.DeviceClass = defaultRules.defaultDevice
+	}
+	for _, bot := range defaultRules.bots {
+		if bot.MatchString(userAgent) {
+			info.IsBot = true
+			break
+		}
+	}
+	return info
+}
+
+// matchFamily retourne la valeur de la première règle qui correspond au User-Agent.
+func matchFamily(rules []familyRule, userAgent string) string {
+	for _, rule := range rules {
+		if rule.pattern.MatchString(userAgent) && (rule.exclude == nil || !rule.exclude.MatchString(userAgent)) {
+			return rule.value
+		}
+	}
+	return ""
+}
+
+// mustCompileRules décode et compile le jeu de règles.
+func mustCompileRules(data []byte) *userAgentRules {
+	type rawRule struct {
+		Family  string `json:"family"`
+		Class   string `json:"class"`
+		Pattern string `json:"pattern"`
+		Exclude string `json:"exclude"`
+	}
+	var raw struct {
+		Bots          []string  `json:"bots"`
+		Browsers      []rawRule `json:"browsers"`
+		OS            []rawRule `json:"os"`
+		Devices       []rawRule `json:"devices"`
+		DefaultDevice string    `json:"default_device"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		panic("analytics: invalid ua_rules.json: " + err.Error())
+	}
+
+	compile := func(rules []rawRule) []familyRule {
+		compiled := make([]familyRule, 0, len(rules))
+		for _, r := range rules {
+			rule := familyRule{value: r.Family, pattern: regexp.MustCompile(r.Pattern)}
+			if rule.value == "" {
+				rule.value = r.Class
+			}
+			if r.Exclude != "" {
+				rule.exclude = regexp.MustCompile(r.Exclude)
+			}
+			compiled = append(compiled, rule)
+		}
+		return compiled
+	}
+
+	rules := &userAgentRules{
+		browsers:      compile(raw.Browsers),
+		os:            compile(raw.OS),
+		devices:       compile(raw.Devices),
+		defaultDevice: raw.DefaultDevice,
+	}
+	for _, pattern := range raw.Bots {
+		rules.bots = append(rules.bots, regexp.MustCompile(pattern))
+	}
+	return rules
+}
@@ -4,12 +4,20 @@ import (
 	"errors"
 	"log"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/axellelanca/urlshortener/cmd"
+	"github.com/axellelanca/urlshortener/internal/analytics"
+	"github.com/axellelanca/urlshortener/internal/config"
+	"github.com/axellelanca/urlshortener/internal/metrics"
+	"github.com/axellelanca/urlshortener/internal/middleware"
 	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/repository"
 	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/axellelanca/urlshortener/internal/spool"
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"gorm.io/gorm" // Pour gérer gorm.ErrRecordNotFound
 )
 
@@ -17,37 +25,129 @@ import (
 // aux workers asynchrones. Il est bufferisé pour ne pas bloquer les requêtes de redirection.
 var ClickEventsChannel chan models.ClickEvent
 
+// clickEventsMu protège la fermeture de ClickEventsChannel : les handlers le détiennent en lecture
+// pour envoyer un clic, CloseClickEvents en écriture pour fermer le channel.
+var (
+	clickEventsMu     sync.RWMutex
+	clickEventsClosed bool
+)
+
+// CloseClickEvents ferme ClickEventsChannel pour que les workers terminent.
+// Les clics des requêtes encore en cours, quand l'arrêt du serveur HTTP a dépassé son délai,
+// partent ensuite dans le spool au lieu d'être envoyés sur le channel fermé.
+func CloseClickEvents() {
+	clickEventsMu.Lock()
+	defer clickEventsMu.Unlock()
+	if !clickEventsClosed {
+		clickEventsClosed = true
+		close(ClickEventsChannel)
+	}
+}
+
+// ClickSpool reçoit les événements de clic quand ClickEventsChannel est plein, pour qu'ils
+// soient persistés plus tard au lieu d'être perdus. nil si le spool est désactivé.
+var ClickSpool *spool.Spool
+
+// LinkCache est le cache des liens lus par les redirections, dont les compteurs sont exposés par /health.
+// nil si le cache est désactivé.
+var LinkCache *repository.CachedLinkRepository
+
 // SetupRoutes configure toutes les routes de l'API Gin et injecte les dépendances nécessaires
-func SetupRoutes(router *gin.Engine, linkService *services.LinkService) {
+// Quand l'authentification est activée, les routes /api/v1 exigent une clé d'API valide.
+func SetupRoutes(router *gin.Engine, linkService *services.LinkService, clickService *services.ClickService, visitorService *services.VisitorService, apiKeyService *services.APIKeyService, workspaceService *services.WorkspaceService, healthService *services.HealthService, fallbackService *services.FallbackService) {
 	// Le channel est initialisé ici.
 	if ClickEventsChannel == nil {
 		// La taille du buffer doit être configurable via Viper (cfg.Analytics.BufferSize)
 		ClickEventsChannel = make(chan models.ClickEvent, cmd.Cfg.Analytics.BufferSize)
 	}
 
+	// Limiteurs de débit par IP, un pour la création et un pour les redirections.
+	createLimit, redirectLimit := rateLimitMiddlewares()
+
+	// Mesure la durée de toutes les requêtes, par route.
+	router.Use(middleware.Metrics())
+
 	router.GET("/health", HealthCheckHandler)
+	router.GET("/metrics", gin.WrapH(promhttp.Handler()))
 
 	// Doivent être au format /api/v1/
 	// POST /links
 	// GET /links/:shortCode/stats
 	apiV1 := router.Group("/api/v1")
+	if cmd.Cfg.Auth.Enabled {
+		apiV1.Use(middleware.APIKeyAuth(apiKeyService, workspaceService))
+	}
 	{
-		apiV1.POST("/links", CreateShortLinkHandler(linkService))
-		apiV1.GET("/links/:shortCode/stats", GetLinkStatsHandler(linkService))
+		// Une clé membre d'un espace de travail accède toujours aux liens et à leurs statistiques dans un espace.
+		links := apiV1.Group("/links", middleware.RequireWorkspace())
+		links.POST("", createLimit, CreateShortLinkHandler(linkService))
+		links.GET("", ListLinksHandler(linkService))
+		links.GET("/:shortCode", GetLinkHandler(linkService))
+		links.PATCH("/:shortCode", UpdateLinkHandler(linkService))
+		links.DELETE("/:shortCode", DeleteLinkHandler(linkService))
+		links.GET("/:shortCode/stats", GetLinkStatsHandler(linkService, clickService, visitorService))
+		links.GET("/:shortCode/stats/timeseries", GetLinkTimeSeriesHandler(linkService, clickService))
+		links.GET("/:shortCode/stats/referrers", GetLinkReferrersHandler(linkService, clickService))
+		links.GET("/:shortCode/stats/breakdown", GetLinkBreakdownHandler(linkService, clickService, ""))
+		links.GET("/:shortCode/stats/countries", GetLinkBreakdownHandler(linkService, clickService, "country"))
+		links.GET("/:shortCode/stats/visitors", GetLinkVisitorsHandler(linkService, visitorService))
+		links.GET("/:shortCode/health", GetLinkHealthHandler(linkService, healthService))
+
+		apiV1.GET("/workspaces", ListWorkspacesHandler(workspaceService))
+		apiV1.POST("/workspaces", CreateWorkspaceHandler(workspaceService))
+		apiV1.PATCH("/workspaces/:workspaceID", UpdateWorkspaceHandler(workspaceService))
+		apiV1.DELETE("/workspaces/:workspaceID", DeleteWorkspaceHandler(workspaceService))
+		apiV1.GET("/workspaces/:workspaceID/members", ListMembersHandler(workspaceService))
+		apiV1.PUT("/workspaces/:workspaceID/members/:apiKeyID", SetMemberHandler(workspaceService))
+		apiV1.DELETE("/workspaces/:workspaceID/members/:apiKeyID", RemoveMemberHandler(workspaceService))
 	}
 
 	// Route de Redirection (au niveau racine pour les short codes)
-	router.GET("/:shortCode", RedirectHandler(linkService))
+	router.GET("/:shortCode", redirectLimit, RedirectHandler(linkService, fallbackService))
+}
+
+// rateLimitMiddlewares construit les middlewares de limitation de débit à partir de la configuration.
+// Une règle désactivée est remplacée par un middleware qui laisse passer toutes les requêtes.
+func rateLimitMiddlewares() (create gin.HandlerFunc, redirect gin.HandlerFunc) {
+	cfg := cmd.Cfg.RateLimit
+	idleTimeout := time.Duration(cfg.IdleTimeoutMinutes) * time.Minute
+
+	build := func(rule config.RateLimitRule) gin.HandlerFunc {
+		if !cfg.Enabled || rule.RequestsPerMinute <= 0 {
+			return func(c *gin.Context) { c.Next() }
+		}
+		return middleware.RateLimit(middleware.NewRateLimiter(rule.RequestsPerMinute, rule.Burst, idleTimeout))
+	}
+	return build(cfg.Create), build(cfg.Redirect)
 }
 
 // HealthCheckHandler gère la route /health pour vérifier l'état du service.
+// Les compteurs du cache des liens y sont ajoutés quand il est activé.
 func HealthCheckHandler(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+	response := gin.H{"status": "ok"}
+	if LinkCache != nil {
+		stats := LinkCache.Stats()
+		response["cache"] = gin.H{
+			"hits":      stats.Hits,
+			"misses":    stats.Misses,
+			"evictions": stats.Evictions,
+			"entries":   stats.Entries,
+			"capacity":  stats.Capacity,
+		}
+	}
+	c.JSON(http.StatusOK, response)
 }
 
 // CreateLinkRequest représente le corps de la requête JSON pour la création d'un lien.
 type CreateLinkRequest struct {
 	LongURL string `json:"long_url" binding:"required,url"` // 'binding:required' pour validation, 'url' pour format URL
+	Alias   string `json:"alias"`                           // Alias personnalisé optionnel (ex: "spring-sale")
+	// ExpiresAt est la date d'expiration optionnelle au format RFC 3339.
+	ExpiresAt *time.Time `json:"expires_at"`
+	// MaxClicks est le nombre de clics optionnel après lequel le lien expire (0 = illimité).
+	MaxClicks int `json:"max_clicks" binding:"min=0"`
+	// FallbackURL est l'URL de secours optionnelle, utilisée tant que le moniteur déclare l'URL longue inaccessible.
+	FallbackURL string `json:"fallback_url"`
 }
 
 // CreateShortLinkHandler gère la création d'une URL courte.
@@ -61,67 +161,291 @@ func CreateShortLinkHandler(linkService *services.LinkService) gin.HandlerFunc {
 			return
 		}
 
-		link, _ := linkService.CreateLink(req.LongURL)
+		link, err := linkService.CreateLink(middleware.CallerFromContext(c), req.LongURL, services.CreateLinkOptions{
+			Alias:       req.Alias,
+			ExpiresAt:   req.ExpiresAt,
+			MaxClicks:   req.MaxClicks,
+			FallbackURL: req.FallbackURL,
+		})
+		if err != nil {
+			switch {
+			case errors.Is(err, services.ErrAliasTaken):
+				c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			case errors.Is(err, services.ErrForbidden):
+				c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			case errors.Is(err, services.ErrInvalidAlias), errors.Is(err, services.ErrReservedAlias),
+				errors.Is(err, services.ErrInvalidExpiration), errors.Is(err, services.ErrInvalidMaxClicks),
+				errors.Is(err, services.ErrInvalidFallbackURL):
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			default:
+				log.Printf("Error creating link for %s: %v", req.LongURL, err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			}
+			return
+		}
 
 		// Retourne le code court et l'URL longue dans la réponse JSON.
-		c.JSON(http.StatusCreated, gin.H{
-			"short_code":     link.Shortcode,
-			"long_url":       link.LongURL,
-			"full_short_url": cmd.Cfg.Server.BaseURL + link.Shortcode,
+		c.JSON(http.StatusCreated, linkJSON(link))
+	}
+}
+
+// linkJSON construit la représentation JSON d'un lien commune à toutes les routes /links.
+func linkJSON(link *models.Link) gin.H {
+	return gin.H{
+		"short_code":     link.Shortcode,
+		"long_url":       link.LongURL,
+		"full_short_url": cmd.Cfg.Server.BaseURL + "/" + link.Shortcode,
+		"created_at":     link.CreatedAt,
+		"expires_at":     link.ExpiresAt,
+		"max_clicks":     link.MaxClicks,
+		"fallback_url":   link.FallbackURL,
+	}
+}
+
+// ListLinksHandler gère la liste paginée des liens.
+// Paramètres de requête : page, page_size, sort (created_at, short_code, long_url), order (asc, desc),
+// url (sous-chaîne de l'URL longue), created_after et created_before (RFC 3339 ou AAAA-MM-JJ).
+func ListLinksHandler(linkService *services.LinkService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		opts := services.ListLinksOptions{
+			SortBy:      c.Query("sort"),
+			URLContains: c.Query("url"),
+		}
+
+		var err error
+		if opts.Page, err = intQuery(c, "page", 1); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if opts.PageSize, err = intQuery(c, "page_size", services.DefaultPageSize); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		switch c.DefaultQuery("order", "desc") {
+		case "asc":
+		case "desc":
+			opts.Desc = true
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "order must be 'asc' or 'desc'"})
+			return
+		}
+		if opts.CreatedAfter, err = timeQuery(c, "created_after", time.UTC); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if opts.CreatedBefore, err = timeQuery(c, "created_before", time.UTC); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		page, err := linkService.ListLinks(middleware.CallerFromContext(c), opts)
+		if err != nil {
+			if errors.Is(err, services.ErrInvalidSortField) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			log.Printf("Error listing links: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+
+		links := make([]gin.H, 0, len(page.Links))
+		for i := range page.Links {
+			links = append(links, linkJSON(&page.Links[i]))
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"links":     links,
+			"page":      page.Page,
+			"page_size": page.PageSize,
+			"total":     page.Total,
+		})
+	}
+}
+
+// GetLinkHandler gère la récupération d'un lien par son code court.
+func GetLinkHandler(linkService *services.LinkService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		shortCode := c.Param("shortCode")
+
+		link, err := linkService.GetLinkByShortCode(middleware.CallerFromContext(c), shortCode)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Short code not found"})
+				return
+			}
+			log.Printf("Error retrieving link for %s: %v", shortCode, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+
+		c.JSON(http.StatusOK, linkJSON(link))
+	}
+}
+
+// UpdateLinkRequest représente le corps de la requête JSON pour la modification d'un lien.
+// Les champs absents ne sont pas modifiés ; au moins l'un d'eux doit être présent.
+type UpdateLinkRequest struct {
+	LongURL     *string `json:"long_url" binding:"omitempty,url"` // Nouvelle URL cible
+	FallbackURL *string `json:"fallback_url"`                     // Nouvelle URL de secours, "" pour la supprimer
+}
+
+// UpdateLinkHandler gère la modification de l'URL cible et de l'URL de secours d'un lien.
+func UpdateLinkHandler(linkService *services.LinkService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		shortCode := c.Param("shortCode")
+
+		var req UpdateLinkRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if req.LongURL == nil && req.FallbackURL == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "long_url or fallback_url is required"})
+			return
+		}
+
+		link, err := linkService.UpdateLink(middleware.CallerFromContext(c), shortCode, services.UpdateLinkOptions{
+			LongURL:     req.LongURL,
+			FallbackURL: req.FallbackURL,
 		})
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Short code not found"})
+				return
+			}
+			if errors.Is(err, services.ErrInvalidFallbackURL) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			if errors.Is(err, services.ErrForbidden) {
+				c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+				return
+			}
+			log.Printf("Error updating link %s: %v", shortCode, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+
+		c.JSON(http.StatusOK, linkJSON(link))
+	}
+}
+
+// DeleteLinkHandler gère la suppression d'un lien et de ses clics.
+func DeleteLinkHandler(linkService *services.LinkService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		shortCode := c.Param("shortCode")
+
+		if err := linkService.DeleteLink(middleware.CallerFromContext(c), shortCode); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Short code not found"})
+				return
+			}
+			if errors.Is(err, services.ErrForbidden) {
+				c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+				return
+			}
+			log.Printf("Error deleting link %s: %v", shortCode, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+
+		c.Status(http.StatusNoContent)
 	}
 }
 
 // RedirectHandler gère la redirection d'une URL courte vers l'URL longue et l'enregistrement asynchrone des clics.
-func RedirectHandler(linkService *services.LinkService) gin.HandlerFunc {
+// Tant que le moniteur déclare l'URL longue inaccessible, la redirection se fait vers l'URL de secours du lien
+// ou de son espace de travail, si elle existe, et le clic est marqué comme tel.
+func RedirectHandler(linkService *services.LinkService, fallbackService *services.FallbackService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Récupère le shortCode de l'URL avec c.Param
 		shortCode := c.Param("shortCode")
 
-		link, err := linkService.GetLinkByShortCode(shortCode)
+		link, err := linkService.ResolveLink(shortCode)
 		if err != nil {
 			// Si le lien n'est pas trouvé, retourner HTTP 404 Not Found.
 			if errors.Is(err, gorm.ErrRecordNotFound) {
+				metrics.RedirectsTotal.WithLabelValues(metrics.RedirectNotFound).Inc()
 				c.JSON(http.StatusNotFound, gin.H{"error": "Short code not found"})
 				return
 			}
+			// Si le lien a expiré (date ou nombre de clics), retourner HTTP 410 Gone.
+			if errors.Is(err, services.ErrLinkExpired) {
+				metrics.RedirectsTotal.WithLabelValues(metrics.RedirectExpired).Inc()
+				c.JSON(http.StatusGone, gin.H{"error": "Link has expired"})
+				return
+			}
 			// Gérer d'autres erreurs potentielles de la base de données ou du service
+			metrics.RedirectsTotal.WithLabelValues(metrics.RedirectError).Inc()
 			log.Printf("Error retrieving link for %s: %v", shortCode, err)
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
 			return
 		}
 
+		target, fallback := fallbackService.FallbackTarget(link)
+		if !fallback {
+			target = link.LongURL
+		}
+
 		clickEvent := models.ClickEvent{
 			LinkID:    link.ID,
 			Timestamp: time.Now(),
 			UserAgent: c.Request.UserAgent(),
 			IPAddress: c.ClientIP(),
+			Referrer:  analytics.NormalizeReferrer(c.Request.Referer()),
+			Fallback:  fallback,
 		}
 
-		// Utilise un `select` avec un `default` pour éviter de bloquer si le channel est plein.
-		// Pour le default, juste un message à afficher :
-		// log.Printf("Warning: ClickEventsChannel is full, dropping click event for %s.", shortCode)
+		queueClickEvent(linkService, clickEvent, shortCode)
+
+		if fallback {
+			metrics.RedirectsTotal.WithLabelValues(metrics.RedirectFallback).Inc()
+		} else {
+			metrics.RedirectsTotal.WithLabelValues(metrics.RedirectFound).Inc()
+		}
+		c.Redirect(http.StatusFound, target)
+	}
+}
+
+// queueClickEvent envoie un événement de clic aux workers sans bloquer la redirection.
+// Quand le channel est plein, ou déjà fermé par l'arrêt du serveur, le clic part dans le spool
+// sur disque, ou est perdu sans spool : sa redirection en attente est alors décomptée,
+// les workers ne l'écrivant pas.
+func queueClickEvent(linkService *services.LinkService, clickEvent models.ClickEvent, shortCode string) {
+	clickEventsMu.RLock()
+	defer clickEventsMu.RUnlock()
+
+	if !clickEventsClosed {
 		select {
 		case ClickEventsChannel <- clickEvent:
-			// Le clic a été envoyé avec succès.
+			return // Le clic a été envoyé avec succès.
 		default:
-			// Le channel est plein, le clic est perdu.
-			log.Printf("Warning: ClickEventsChannel is full, dropping click event for %s.", shortCode)
+			metrics.ClickEventsChannelFull.Inc()
 		}
+	}
 
-		c.Redirect(http.StatusFound, link.LongURL)
+	linkService.ReleaseClicks(clickEvent.LinkID, 1)
+	if ClickSpool == nil {
+		metrics.ClickEventsDropped.Inc()
+		log.Printf("Warning: ClickEventsChannel is full or closed, dropping click event for %s.", shortCode)
+	} else if err := ClickSpool.Append(clickEvent); err != nil {
+		metrics.ClickEventsDropped.Inc()
+		log.Printf("Warning: ClickEventsChannel is full or closed and spooling failed, dropping click event for %s: %v", shortCode, err)
 	}
 }
 
 // GetLinkStatsHandler gère la récupération des statistiques pour un lien spécifique.
-func GetLinkStatsHandler(linkService *services.LinkService) gin.HandlerFunc {
+// Avec le paramètre de requête exclude_bots=true, total_clicks et fallback_clicks ne comptent pas les clics de robots.
+func GetLinkStatsHandler(linkService *services.LinkService, clickService *services.ClickService, visitorService *services.VisitorService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		shortCode := c.Param("shortCode")
+		excludeBots := c.Query("exclude_bots") == "true"
 
 		// Gérer le cas où le lien n'est pas trouvé.
 		// toujours avec l'erreur Gorm ErrRecordNotFound
 		// Gérer d'autres erreurs
-		link, totalClicks, err := linkService.GetLinkStats(shortCode)
+		link, totalClicks, err := linkService.GetLinkStats(middleware.CallerFromContext(c), shortCode)
 		if err != nil {
 			if errors.Is(err, gorm.ErrRecordNotFound) {
 				c.JSON(http.StatusNotFound, gin.H{"error": "Short code not found"})
@@ -132,11 +456,263 @@ func GetLinkStatsHandler(linkService *services.LinkService) gin.HandlerFunc {
 			return
 		}
 
+		// L'expiration se base sur tous les clics ; seul le total affiché peut ignorer les robots.
+		expired := services.IsLinkExpired(link, totalClicks, time.Now())
+		if excludeBots {
+			if totalClicks, err = clickService.CountClicks(link.ID, true); err != nil {
+				log.Printf("Error counting human clicks for %s: %v", shortCode, err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+				return
+			}
+		}
+		fallbackClicks, err := clickService.CountFallbackClicks(link.ID, excludeBots)
+		if err != nil {
+			log.Printf("Error counting fallback clicks for %s: %v", shortCode, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+		visitors, err := visitorService.GetUniqueVisitors(link.ID, time.Time{}, time.Time{})
+		if err != nil {
+			log.Printf("Error estimating unique visitors for %s: %v", shortCode, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+
 		// Retourne les statistiques dans la réponse JSON.
+		c.JSON(http.StatusOK, gin.H{
+			"short_code":      link.Shortcode,
+			"long_url":        link.LongURL,
+			"total_clicks":    totalClicks,
+			"fallback_clicks": fallbackClicks,
+			"unique_visitors": visitors.Total,
+			"bots_excluded":   excludeBots,
+			"expires_at":      link.ExpiresAt,
+			"max_clicks":      link.MaxClicks,
+			"expired":         expired,
+		})
+	}
+}
+
+// GetLinkVisitorsHandler gère l'estimation des visiteurs uniques d'un lien, au total et par jour (UTC).
+// Paramètres de requête : from et to (RFC 3339 ou AAAA-MM-JJ, en UTC), étendus aux journées entières.
+func GetLinkVisitorsHandler(linkService *services.LinkService, visitorService *services.VisitorService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		shortCode := c.Param("shortCode")
+
+		from, to, err := periodQuery(c, time.UTC)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		link, err := linkService.GetLinkByShortCode(middleware.CallerFromContext(c), shortCode)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Short code not found"})
+				return
+			}
+			log.Printf("Error retrieving link for %s: %v", shortCode, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+
+		visitors, err := visitorService.GetUniqueVisitors(link.ID, from, to)
+		if err != nil {
+			if errors.Is(err, services.ErrInvalidTimeRange) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			log.Printf("Error estimating unique visitors for %s: %v", shortCode, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+
+		days := make([]gin.H, 0, len(visitors.Days))
+		for _, day := range visitors.Days {
+			days = append(days, gin.H{"day": day.Day.Format(time.DateOnly), "unique_visitors": day.Count})
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"short_code":      link.Shortcode,
+			"unique_visitors": visitors.Total,
+			"days":            days,
+		})
+	}
+}
+
+// GetLinkTimeSeriesHandler gère la récupération du nombre de clics d'un lien par intervalle de temps.
+// Paramètres de requête : interval (hour, day, week ; day par défaut), from et to (RFC 3339 ou AAAA-MM-JJ)
+// et tz (nom de fuseau IANA, ex: Europe/Paris ; UTC par défaut).
+func GetLinkTimeSeriesHandler(linkService *services.LinkService, clickService *services.ClickService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		shortCode := c.Param("shortCode")
+
+		loc, err := time.LoadLocation(c.DefaultQuery("tz", "UTC"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "unknown time zone"})
+			return
+		}
+		from, to, err := periodQuery(c, loc)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		opts := services.TimeSeriesOptions{
+			Interval: c.DefaultQuery("interval", services.IntervalDay),
+			From:     from,
+			To:       to,
+			Location: loc,
+		}
+
+		link, err := linkService.GetLinkByShortCode(middleware.CallerFromContext(c), shortCode)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Short code not found"})
+				return
+			}
+			log.Printf("Error retrieving link for %s: %v", shortCode, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+
+		series, err := clickService.GetClickTimeSeries(link.ID, opts)
+		if err != nil {
+			if errors.Is(err, services.ErrInvalidInterval) || errors.Is(err, services.ErrInvalidTimeRange) ||
+				errors.Is(err, services.ErrTooManyBuckets) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			log.Printf("Error retrieving time series for %s: %v", shortCode, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+
+		points := make([]gin.H, 0, len(series.Buckets))
+		for _, bucket := range series.Buckets {
+			points = append(points, gin.H{"start": bucket.Start, "clicks": bucket.Count})
+		}
 		c.JSON(http.StatusOK, gin.H{
 			"short_code":   link.Shortcode,
-			"long_url":     link.LongURL,
-			"total_clicks": totalClicks,
+			"interval":     series.Interval,
+			"tz":           series.Location.String(),
+			"from":         series.From.In(series.Location),
+			"to":           series.To.In(series.Location),
+			"total_clicks": series.Total,
+			"points":       points,
+		})
+	}
+}
+
+// GetLinkReferrersHandler gère le classement des domaines référents d'un lien.
+// Paramètres de requête : limit (10 par défaut), from et to (RFC 3339 ou AAAA-MM-JJ, en UTC).
+// Les clics sans en-tête Referer sont comptés à part dans "direct".
+func GetLinkReferrersHandler(linkService *services.LinkService, clickService *services.ClickService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		shortCode := c.Param("shortCode")
+
+		limit, err := intQuery(c, "limit", services.DefaultTopLimit)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		from, to, err := periodQuery(c, time.UTC)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		link, err := linkService.GetLinkByShortCode(middleware.CallerFromContext(c), shortCode)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Short code not found"})
+				return
+			}
+			log.Printf("Error retrieving link for %s: %v", shortCode, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+
+		stats, err := clickService.GetTopReferrers(link.ID, from, to, limit)
+		if err != nil {
+			if errors.Is(err, services.ErrInvalidTimeRange) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			log.Printf("Error retrieving referrers for %s: %v", shortCode, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+
+		referrers := make([]gin.H, 0, len(stats.Referrers))
+		for _, referrer := range stats.Referrers {
+			referrers = append(referrers, gin.H{"host": referrer.Value, "clicks": referrer.Count})
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"short_code": link.Shortcode,
+			"referrers":  referrers,
+			"direct":     stats.Direct,
+		})
+	}
+}
+
+// GetLinkBreakdownHandler gère la répartition des clics d'un lien par navigateur, système, classe d'appareil,
+// pays ou ville. Si 'dimension' est vide, elle est lue dans le paramètre de requête by
+// (browser, os, device, country, city). Autres paramètres de requête : limit (10 par défaut),
+// exclude_bots (true/false), from et to (RFC 3339 ou AAAA-MM-JJ, en UTC).
+func GetLinkBreakdownHandler(linkService *services.LinkService, clickService *services.ClickService, dimension string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		shortCode := c.Param("shortCode")
+		by := dimension
+		if by == "" {
+			by = c.Query("by")
+		}
+
+		limit, err := intQuery(c, "limit", services.DefaultTopLimit)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		from, to, err := periodQuery(c, time.UTC)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		link, err := linkService.GetLinkByShortCode(middleware.CallerFromContext(c), shortCode)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Short code not found"})
+				return
+			}
+			log.Printf("Error retrieving link for %s: %v", shortCode, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+
+		breakdown, err := clickService.GetClickBreakdown(link.ID, by, services.BreakdownOptions{
+			From:        from,
+			To:          to,
+			ExcludeBots: c.Query("exclude_bots") == "true",
+			Limit:       limit,
+		})
+		if err != nil {
+			if errors.Is(err, services.ErrInvalidDimension) || errors.Is(err, services.ErrInvalidTimeRange) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			log.Printf("Error retrieving click breakdown for %s: %v", shortCode, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+
+		values := make([]gin.H, 0, len(breakdown.Values))
+		for _, value := range breakdown.Values {
+			values = append(values, gin.H{"value": value.Value, "clicks": value.Count})
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"short_code": link.Shortcode,
+			"by":         breakdown.Dimension,
+			"values":     values,
+			"unknown":    breakdown.Unknown,
 		})
 	}
 }
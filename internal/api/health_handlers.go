@@ -0,0 +1,134 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/middleware"
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// formatHealthState retourne l'état d'une URL tel qu'il est exposé par l'API.
+func formatHealthState(up bool) string {
+	if up {
+		return "up"
+	}
+	return "down"
+}
+
+// GetLinkHealthHandler gère la récupération de l'état de l'URL longue d'un lien d'après l'historique du moniteur :
+// dernière vérification, taux de disponibilité, changements d'état récents, certificat TLS et changements de contenu.
+// Paramètres de requête : from et to (RFC 3339 ou AAAA-MM-JJ, en UTC) ; les 7 derniers jours par défaut.
+func GetLinkHealthHandler(linkService *services.LinkService, healthService *services.HealthService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		shortCode := c.Param("shortCode")
+
+		from, to, err := periodQuery(c, time.UTC)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		link, err := linkService.GetLinkByShortCode(middleware.CallerFromContext(c), shortCode)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Short code not found"})
+				return
+			}
+			log.Printf("Error retrieving link for %s: %v", shortCode, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+
+		health, err := healthService.GetLinkHealth(link.ID, from, to)
+		if err != nil {
+			if errors.Is(err, services.ErrInvalidTimeRange) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			log.Printf("Error retrieving link health for %s: %v", shortCode, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+
+		// Sans vérification, l'état est inconnu ; sans vérification sur la période, le taux de disponibilité aussi.
+		// L'état est celui déclaré par le moniteur, qui peut différer du résultat de la dernière vérification
+		// tant que le nombre de vérifications consécutives requis n'est pas atteint.
+		state := "unknown"
+		var lastCheck gin.H
+		if health.Last != nil {
+			state = formatHealthState(health.Last.StateUp)
+			redirects := health.Last.Redirects
+			if redirects == nil {
+				redirects = models.RedirectChain{}
+			}
+			lastCheck = gin.H{
+				"checked_at":  health.Last.CheckedAt,
+				"result":      formatHealthState(health.Last.Up),
+				"status_code": health.Last.StatusCode,
+				"latency_ms":  health.Last.LatencyMs,
+				"error_class": health.Last.ErrorClass,
+				"final_url":   health.Last.FinalURL,
+				"redirects":   redirects,
+				// Champs relevés seulement pour une URL en HTTPS, et pour l'empreinte quand elle est demandée
+				"tls_expires_at": health.Last.TLSExpiresAt,
+				"content_hash":   health.Last.ContentHash,
+			}
+		}
+		var uptime *float64
+		if health.Checks > 0 {
+			uptime = &health.UptimePercent
+		}
+
+		transitions := make([]gin.H, 0, len(health.Transitions))
+		for _, transition := range health.Transitions {
+			transitions = append(transitions, gin.H{
+				"at":          transition.At,
+				"from":        formatHealthState(!transition.Up),
+				"to":          formatHealthState(transition.Up),
+				"status_code": transition.StatusCode,
+				"error_class": transition.ErrorClass,
+			})
+		}
+
+		// Sans certificat relevé (URL en HTTP ou jamais accessible), 'tls' est nul.
+		var tlsInfo gin.H
+		if health.TLSExpiresAt != nil {
+			tlsInfo = gin.H{
+				"expires_at": health.TLSExpiresAt,
+				"days_left":  int(time.Until(*health.TLSExpiresAt).Hours() / 24),
+				"expiring":   health.TLSExpiring,
+			}
+		}
+		contentChanges := make([]gin.H, 0, len(health.ContentChanges))
+		for _, change := range health.ContentChanges {
+			contentChanges = append(contentChanges, gin.H{
+				"at":            change.At,
+				"hash":          change.Hash,
+				"previous_hash": change.PreviousHash,
+			})
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"short_code":     link.Shortcode,
+			"long_url":       link.LongURL,
+			"state":          state,
+			"last_check":     lastCheck,
+			"from":           health.From,
+			"to":             health.To,
+			"checks":         health.Checks,
+			"uptime_percent": uptime,
+			"transitions":    transitions,
+			"tls":            tlsInfo,
+			"content": gin.H{
+				"hash":    health.ContentHash,
+				"changes": contentChanges,
+			},
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package api
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// intQuery lit un paramètre de requête entier, en retournant 'def' s'il est absent.
+func intQuery(c *gin.Context, name string, def int) (int, error) {
+	raw := c.Query(name)
+	if raw == "" {
+		return def, nil
+	}
+	value, err := strconv.Atoi(raw)
+	if err != nil {
+		return 0, fmt.Errorf("query parameter '%s' must be an integer", name)
+	}
+	return value, nil
+}
+
+// timeQuery lit un paramètre de requête de date au format RFC 3339 ou AAAA-MM-JJ.
+// Une date sans fuseau horaire est interprétée dans loc. Elle retourne nil si le paramètre est absent.
+func timeQuery(c *gin.Context, name string, loc *time.Location) (*time.Time, error) {
+	raw := c.Query(name)
+	if raw == "" {
+		return nil, nil
+	}
+	for _, layout := range []string{time.RFC3339, time.DateOnly} {
+		if t, err := time.ParseInLocation(layout, raw, loc); err == nil {
+			return &t, nil
+		}
+	}
+	return nil, fmt.Errorf("query parameter '%s' must be a RFC 3339 date or YYYY-MM-DD", name)
+}
+
+// periodQuery lit les paramètres de requête 'from' et 'to' d'une période.
+// Une borne absente est retournée à sa valeur zéro.
+func periodQuery(c *gin.Context, loc *time.Location) (from, to time.Time, err error) {
+	fromPtr, err := timeQuery(c, "from", loc)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	toPtr, err := timeQuery(c, "to", loc)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	if fromPtr != nil {
+		from = *fromPtr
+	}
+	if toPtr != nil {
+		to = *toPtr
+	}
+	return from, to, nil
+}
+
+// idParam lit un paramètre de chemin qui doit être un identifiant entier strictement positif.
+func idParam(c *gin.Context, name string) (uint, error) {
+	value, err := strconv.ParseUint(c.Param(name), 10, 0)
+	if err != nil || value == 0 {
+		return 0, fmt.Errorf("path parameter '%s' must be a positive integer", name)
+	}
+	return uint(value), nil
+}
@@ -0,0 +1,221 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/axellelanca/urlshortener/internal/middleware"
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/repository"
+	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// CreateWorkspaceRequest représente le corps de la requête JSON pour la création d'un espace de travail.
+type CreateWorkspaceRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+// UpdateWorkspaceRequest représente le corps de la requête JSON pour la modification d'un espace de travail.
+type UpdateWorkspaceRequest struct {
+	FallbackURL *string `json:"fallback_url" binding:"required"` // URL de secours par défaut des liens, "" pour la supprimer
+}
+
+// SetMemberRequest représente le corps de la requête JSON pour l'ajout d'un membre ou le changement de son rôle.
+type SetMemberRequest struct {
+	Role string `json:"role" binding:"required"` // owner, editor ou viewer
+}
+
+// workspaceJSON construit la représentation JSON d'un espace de travail.
+// 'role' est le rôle de l'appelant, omis s'il est vide (clé d'administration).
+func workspaceJSON(workspace *models.Workspace, role string) gin.H {
+	result := gin.H{
+		"id":           workspace.ID,
+		"name":         workspace.Name,
+		"created_at":   workspace.CreatedAt,
+		"fallback_url": workspace.FallbackURL,
+	}
+	if role != "" {
+		result["role"] = role
+	}
+	return result
+}
+
+// memberJSON construit la représentation JSON d'un membre d'un espace de travail.
+func memberJSON(member *models.WorkspaceMember) gin.H {
+	return gin.H{
+		"api_key_id": member.APIKeyID,
+		"role":       member.Role,
+		"added_at":   member.CreatedAt,
+	}
+}
+
+// workspaceError répond à une erreur du WorkspaceService avec le code de statut correspondant.
+func workspaceError(c *gin.Context, err error, action string) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Workspace or member not found"})
+	case errors.Is(err, services.ErrForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrWorkspaceNameTaken), errors.Is(err, services.ErrLastOwner),
+		errors.Is(err, repository.ErrWorkspaceNotEmpty):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrInvalidWorkspaceName), errors.Is(err, services.ErrInvalidRole),
+		errors.Is(err, services.ErrUnknownAPIKey), errors.Is(err, services.ErrInvalidFallbackURL):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		log.Printf("Error %s: %v", action, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+	}
+}
+
+// ListWorkspacesHandler liste les espaces de travail de l'appelant (tous pour une clé d'administration).
+func ListWorkspacesHandler(workspaceService *services.WorkspaceService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		workspaces, err := workspaceService.ListWorkspaces(middleware.CallerFromContext(c))
+		if err != nil {
+			workspaceError(c, err, "listing workspaces")
+			return
+		}
+
+		result := make([]gin.H, 0, len(workspaces))
+		for i := range workspaces {
+			result = append(result, workspaceJSON(&workspaces[i].Workspace, workspaces[i].Role))
+		}
+		c.JSON(http.StatusOK, gin.H{"workspaces": result})
+	}
+}
+
+// CreateWorkspaceHandler crée un espace de travail. Réservé aux clés d'administration.
+func CreateWorkspaceHandler(workspaceService *services.WorkspaceService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req CreateWorkspaceRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		workspace, err := workspaceService.CreateWorkspace(middleware.CallerFromContext(c), req.Name)
+		if err != nil {
+			workspaceError(c, err, "creating workspace "+req.Name)
+			return
+		}
+		c.JSON(http.StatusCreated, workspaceJSON(workspace, ""))
+	}
+}
+
+// UpdateWorkspaceHandler modifie l'URL de secours par défaut des liens d'un espace de travail.
+// Réservé à ses propriétaires.
+func UpdateWorkspaceHandler(workspaceService *services.WorkspaceService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		workspaceID, err := idParam(c, "workspaceID")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		var req UpdateWorkspaceRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		workspace, err := workspaceService.SetFallbackURL(middleware.CallerFromContext(c), workspaceID, *req.FallbackURL)
+		if err != nil {
+			workspaceError(c, err, "updating workspace")
+			return
+		}
+		c.JSON(http.StatusOK, workspaceJSON(workspace, ""))
+	}
+}
+
+// DeleteWorkspaceHandler supprime un espace de travail sans liens. Réservé aux clés d'administration.
+func DeleteWorkspaceHandler(workspaceService *services.WorkspaceService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		workspaceID, err := idParam(c, "workspaceID")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if err := workspaceService.DeleteWorkspace(middleware.CallerFromContext(c), workspaceID); err != nil {
+			workspaceError(c, err, "deleting workspace")
+			return
+		}
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// ListMembersHandler liste les membres d'un espace de travail. Réservé à ses propriétaires.
+func ListMembersHandler(workspaceService *services.WorkspaceService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		workspaceID, err := idParam(c, "workspaceID")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		members, err := workspaceService.ListMembers(middleware.CallerFromContext(c), workspaceID)
+		if err != nil {
+			workspaceError(c, err, "listing workspace members")
+			return
+		}
+
+		result := make([]gin.H, 0, len(members))
+		for i := range members {
+			result = append(result, memberJSON(&members[i]))
+		}
+		c.JSON(http.StatusOK, gin.H{"members": result})
+	}
+}
+
+// SetMemberHandler ajoute une clé d'API à un espace de travail ou change son rôle. Réservé à ses propriétaires.
+func SetMemberHandler(workspaceService *services.WorkspaceService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		workspaceID, err := idParam(c, "workspaceID")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		apiKeyID, err := idParam(c, "apiKeyID")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		var req SetMemberRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		member, err := workspaceService.SetMember(middleware.CallerFromContext(c), workspaceID, apiKeyID, req.Role)
+		if err != nil {
+			workspaceError(c, err, "setting workspace member")
+			return
+		}
+		c.JSON(http.StatusOK, memberJSON(member))
+	}
+}
+
+// RemoveMemberHandler retire une clé d'API d'un espace de travail. Réservé à ses propriétaires.
+func RemoveMemberHandler(workspaceService *services.WorkspaceService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		workspaceID, err := idParam(c, "workspaceID")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		apiKeyID, err := idParam(c, "apiKeyID")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if err := workspaceService.RemoveMember(middleware.CallerFromContext(c), workspaceID, apiKeyID); err != nil {
+			workspaceError(c, err, "removing workspace member")
+			return
+		}
+		c.Status(http.StatusNoContent)
+	}
+}
@@ -7,21 +7,97 @@ import (
 	"github.com/spf13/viper" // La bibliothèque pour la gestion de configuration
 )
 
+// RateLimitRule décrit une limite de débit par adresse IP.
+// Une valeur de RequestsPerMinute inférieure ou égale à 0 désactive la limite.
+type RateLimitRule struct {
+	RequestsPerMinute float64 `mapstructure:"requests_per_minute"`
+	Burst             int     `mapstructure:"burst"`
+}
+
+// NotificationChannel décrit un canal de notification des changements d'état des liens.
+// Sans 'links' ni 'workspaces', le canal reçoit les événements de tous les liens.
+type NotificationChannel struct {
+	Name       string   `mapstructure:"name"`
+	Type       string   `mapstructure:"type"`      // webhook, slack ou email
+	URL        string   `mapstructure:"url"`       // webhook et slack
+	Secret     string   `mapstructure:"secret"`    // webhook : clé de signature HMAC-SHA256
+	SMTPAddr   string   `mapstructure:"smtp_addr"` // email : hôte:port du serveur SMTP
+	Username   string   `mapstructure:"username"`
+	Password   string   `mapstructure:"password"`
+	From       string   `mapstructure:"from"`
+	To         []string `mapstructure:"to"`
+	Links      []string `mapstructure:"links"`      // Codes courts des liens suivis
+	Workspaces []uint   `mapstructure:"workspaces"` // Espaces de travail dont tous les liens sont suivis
+}
+
 type Config struct {
 	Server struct {
-		Port    int    `mapstructure:"port"`
-		BaseURL string `mapstructure:"base_url"`
+		Port                   int      `mapstructure:"port"`
+		BaseURL                string   `mapstructure:"base_url"`
+		ShutdownTimeoutSeconds int      `mapstructure:"shutdown_timeout_seconds"`
+		TrustedProxies         []string `mapstructure:"trusted_proxies"` // Adresses ou réseaux (CIDR) des reverse proxies de confiance
 	} `mapstructure:"server"`
 	Database struct {
-		Name string `mapstructure:"name"`
+		Driver                 string `mapstructure:"driver"`
+		DSN                    string `mapstructure:"dsn"`
+		Name                   string `mapstructure:"name"`
+		MaxOpenConns           int    `mapstructure:"max_open_conns"`
+		MaxIdleConns           int    `mapstructure:"max_idle_conns"`
+		ConnMaxLifetimeMinutes int    `mapstructure:"conn_max_lifetime_minutes"`
+		ConnMaxIdleTimeMinutes int    `mapstructure:"conn_max_idle_time_minutes"`
 	} `mapstructure:"database"`
 	Analytics struct {
-		BufferSize  int `mapstructure:"buffer_size"`
-		WorkerCount int `mapstructure:"worker_count"`
+		BufferSize      int    `mapstructure:"buffer_size"`
+		WorkerCount     int    `mapstructure:"worker_count"`
+		BatchSize       int    `mapstructure:"batch_size"`
+		FlushIntervalMs int    `mapstructure:"flush_interval_ms"`
+		SpoolDir        string `mapstructure:"spool_dir"`
+		SpoolSegmentKB  int    `mapstructure:"spool_segment_kb"`
 	} `mapstructure:"analytics"`
+	Auth struct {
+		Enabled bool `mapstructure:"enabled"`
+	} `mapstructure:"auth"`
+	Cache struct {
+		Enabled            bool `mapstructure:"enabled"`
+		Size               int  `mapstructure:"size"`
+		TTLSeconds         int  `mapstructure:"ttl_seconds"`
+		NegativeTTLSeconds int  `mapstructure:"negative_ttl_seconds"`
+	} `mapstructure:"cache"`
 	Monitor struct {
-		IntervalMinutes int `mapstructure:"interval_minutes"`
+		IntervalMinutes    int      `mapstructure:"interval_minutes"`
+		Concurrency        int      `mapstructure:"concurrency"`
+		PerHostConcurrency int      `mapstructure:"per_host_concurrency"`
+		TimeoutSeconds     int      `mapstructure:"timeout_seconds"`
+		HistoryDays        int      `mapstructure:"history_days"`
+		HealthyStatuses    []string `mapstructure:"healthy_statuses"`
+		FailureThreshold   int      `mapstructure:"failure_threshold"`
+		RecoveryThreshold  int      `mapstructure:"recovery_threshold"`
+		TLSWarnDays        int      `mapstructure:"tls_warn_days"`
+		ContentCheck       string   `mapstructure:"content_check"`
+		ContentMaxKB       int      `mapstructure:"content_max_kb"`
 	} `mapstructure:"monitor"`
+	Notifications struct {
+		TimeoutSeconds    int                   `mapstructure:"timeout_seconds"`
+		MaxAttempts       int                   `mapstructure:"max_attempts"`
+		RetryDelaySeconds int                   `mapstructure:"retry_delay_seconds"`
+		Channels          []NotificationChannel `mapstructure:"channels"`
+	} `mapstructure:"notifications"`
+	GeoIP struct {
+		DatabasePath string `mapstructure:"database_path"`
+	} `mapstructure:"geoip"`
+	Privacy struct {
+		IPMode                   string `mapstructure:"ip_mode"`
+		HMACSecret               string `mapstructure:"hmac_secret"`
+		RetentionDays            int    `mapstructure:"retention_days"`
+		RetentionAction          string `mapstructure:"retention_action"`
+		RetentionIntervalMinutes int    `mapstructure:"retention_interval_minutes"`
+	} `mapstructure:"privacy"`
+	RateLimit struct {
+		Enabled            bool          `mapstructure:"enabled"`
+		IdleTimeoutMinutes int           `mapstructure:"idle_timeout_minutes"`
+		Create             RateLimitRule `mapstructure:"create"`
+		Redirect           RateLimitRule `mapstructure:"redirect"`
+	} `mapstructure:"ratelimit"`
 }
 
 func LoadConfig() (*Config, error) {
@@ -35,9 +111,49 @@ func LoadConfig() (*Config, error) {
 
 	viper.SetDefault("server.port", 8080)
 	viper.SetDefault("server.base_url", "http://localhost:8080")
+	viper.SetDefault("server.shutdown_timeout_seconds", 15)
+	viper.SetDefault("server.trusted_proxies", []string{})
+	viper.SetDefault("database.driver", "sqlite")
 	viper.SetDefault("database.name", "default_db")
+	viper.SetDefault("database.max_open_conns", 0)
+	viper.SetDefault("database.max_idle_conns", 2)
+	viper.SetDefault("database.conn_max_lifetime_minutes", 0)
+	viper.SetDefault("database.conn_max_idle_time_minutes", 0)
 	viper.SetDefault("analytics.buffer_size", 100)
+	viper.SetDefault("analytics.worker_count", 5)
+	viper.SetDefault("analytics.batch_size", 50)
+	viper.SetDefault("analytics.flush_interval_ms", 1000)
+	viper.SetDefault("analytics.spool_dir", "click_spool")
+	viper.SetDefault("analytics.spool_segment_kb", 4096)
+	viper.SetDefault("auth.enabled", true)
+	viper.SetDefault("cache.enabled", true)
+	viper.SetDefault("cache.size", 10000)
+	viper.SetDefault("cache.ttl_seconds", 60)
+	viper.SetDefault("cache.negative_ttl_seconds", 10)
 	viper.SetDefault("monitor.interval_minutes", 5)
+	viper.SetDefault("monitor.concurrency", 20)
+	viper.SetDefault("monitor.per_host_concurrency", 2)
+	viper.SetDefault("monitor.timeout_seconds", 5)
+	viper.SetDefault("monitor.history_days", 30)
+	viper.SetDefault("monitor.healthy_statuses", []string{"2xx", "3xx"})
+	viper.SetDefault("monitor.failure_threshold", 2)
+	viper.SetDefault("monitor.recovery_threshold", 1)
+	viper.SetDefault("monitor.tls_warn_days", 14)
+	viper.SetDefault("monitor.content_check", "none")
+	viper.SetDefault("monitor.content_max_kb", 512)
+	viper.SetDefault("notifications.timeout_seconds", 10)
+	viper.SetDefault("notifications.max_attempts", 3)
+	viper.SetDefault("notifications.retry_delay_seconds", 2)
+	viper.SetDefault("privacy.ip_mode", "none")
+	viper.SetDefault("privacy.retention_days", 0)
+	viper.SetDefault("privacy.retention_action", "delete")
+	viper.SetDefault("privacy.retention_interval_minutes", 60)
+	viper.SetDefault("ratelimit.enabled", true)
+	viper.SetDefault("ratelimit.idle_timeout_minutes", 10)
+	viper.SetDefault("ratelimit.create.requests_per_minute", 10)
+	viper.SetDefault("ratelimit.create.burst", 5)
+	viper.SetDefault("ratelimit.redirect.requests_per_minute", 600)
+	viper.SetDefault("ratelimit.redirect.burst", 100)
 
 	if err := viper.ReadInConfig(); err != nil {
 		var configFileNotFoundError viper.ConfigFileNotFoundError
@@ -52,8 +168,8 @@ func LoadConfig() (*Config, error) {
 		log.Fatalf("Impossible de désérialiser la configuration: %v", err)
 	}
 
-	log.Printf("Configuration loaded: Server Port=%d, DB Name=%s, Analytics Buffer=%d, Monitor Interval=%dmin",
-		cfg.Server.Port, cfg.Database.Name, cfg.Analytics.BufferSize, cfg.Monitor.IntervalMinutes)
+	log.Printf("Configuration loaded: Server Port=%d, DB Driver=%s, DB Name=%s, Analytics Buffer=%d, Monitor Interval=%dmin",
+		cfg.Server.Port, cfg.Database.Driver, cfg.Database.Name, cfg.Analytics.BufferSize, cfg.Monitor.IntervalMinutes)
 
 	return &cfg, nil
 }
@@ -0,0 +1,85 @@
+package database
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/config"
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// Pilotes de base de données acceptés par database.driver.
+const (
+	DriverSQLite   = "sqlite"
+	DriverPostgres = "postgres"
+	DriverMySQL    = "mysql"
+)
+
+// Open ouvre la connexion à la base de données configurée et applique les réglages du pool de connexions.
+// C'est le point d'entrée unique utilisé par le serveur et toutes les commandes CLI.
+func Open(cfg *config.Config) (*gorm.DB, error) {
+	dialector, err := dialectorFor(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := gorm.Open(dialector, &gorm.Config{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %w", Describe(cfg), err)
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get underlying SQL database: %w", err)
+	}
+	pool := cfg.Database
+	sqlDB.SetMaxOpenConns(pool.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(pool.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(time.Duration(pool.ConnMaxLifetimeMinutes) * time.Minute)
+	sqlDB.SetConnMaxIdleTime(time.Duration(pool.ConnMaxIdleTimeMinutes) * time.Minute)
+
+	return db, nil
+}
+
+// dialectorFor retourne le dialecte GORM du pilote configuré.
+// Pour SQLite, le DSN vaut par défaut database.name (chemin du fichier de base).
+func dialectorFor(cfg *config.Config) (gorm.Dialector, error) {
+	switch cfg.Database.Driver {
+	case DriverSQLite, "":
+		dsn := cfg.Database.DSN
+		if dsn == "" {
+			dsn = cfg.Database.Name
+		}
+		return sqlite.Open(dsn), nil
+	case DriverPostgres:
+		if cfg.Database.DSN == "" {
+			return nil, fmt.Errorf("database.dsn is required for driver '%s'", DriverPostgres)
+		}
+		return postgres.Open(cfg.Database.DSN), nil
+	case DriverMySQL:
+		if cfg.Database.DSN == "" {
+			return nil, fmt.Errorf("database.dsn is required for driver '%s'", DriverMySQL)
+		}
+		return mysql.Open(cfg.Database.DSN), nil
+	default:
+		return nil, fmt.Errorf("unsupported database driver '%s': use %s, %s or %s",
+			cfg.Database.Driver, DriverSQLite, DriverPostgres, DriverMySQL)
+	}
+}
+
+// Describe retourne une description de la base configurée, utilisable dans les logs.
+// Le DSN des serveurs de base de données n'est pas inclus, car il peut contenir un mot de passe.
+func Describe(cfg *config.Config) string {
+	switch cfg.Database.Driver {
+	case DriverSQLite, "":
+		if cfg.Database.DSN != "" {
+			return fmt.Sprintf("SQLite database '%s'", cfg.Database.DSN)
+		}
+		return fmt.Sprintf("SQLite database '%s'", cfg.Database.Name)
+	default:
+		return fmt.Sprintf("%s database", cfg.Database.Driver)
+	}
+}
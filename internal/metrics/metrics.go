@@ -0,0 +1,140 @@
+package metrics
+
+import (
+	"github.com/axellelanca/urlshortener/internal/repository"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// namespace préfixe le nom de toutes les métriques du service.
+const namespace = "urlshortener"
+
+// Valeurs du label 'result' de RedirectsTotal.
+const (
+	RedirectFound    = "redirected"
+	RedirectFallback = "fallback" // Redirigée vers l'URL de secours, l'URL longue étant déclarée inaccessible
+	RedirectNotFound = "not_found"
+	RedirectExpired  = "expired"
+	RedirectError    = "error"
+)
+
+// Métriques exposées sur /metrics au format Prometheus.
+// Elles sont enregistrées dans le registre par défaut dès l'import du package.
+var (
+	// HTTPRequestDuration mesure la durée des requêtes HTTP par route (motif Gin, pas chemin réel).
+	HTTPRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Durée des requêtes HTTP par méthode, route et code de statut.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	// RedirectsTotal compte les requêtes de redirection selon leur issue.
+	RedirectsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "redirects_total",
+		Help:      "Requêtes de redirection par issue (redirected, fallback, not_found, expired, error).",
+	}, []string{"result"})
+
+	// ClickEventsChannelFull compte les clics qui n'ont pas pu être envoyés aux workers, le channel étant plein.
+	ClickEventsChannelFull = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "click_events_channel_full_total",
+		Help:      "Événements de clic refusés par le channel plein (envoyés au spool s'il est configuré).",
+	})
+
+	// ClickEventsDropped compte les clics définitivement perdus : channel plein ou écriture en échec, sans spool utilisable.
+	ClickEventsDropped = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "click_events_dropped_total",
+		Help:      "Événements de clic perdus faute de spool, après un échec du spool ou dont le lien a été supprimé avant leur rejeu.",
+	})
+
+	// ClickPersistFailures compte les lots de clics dont l'écriture en base a échoué.
+	ClickPersistFailures = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "click_persist_failures_total",
+		Help:      "Lots de clics dont l'écriture en base a échoué.",
+	})
+
+	// ClicksPersisted compte les clics écrits en base par les workers.
+	ClicksPersisted = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "clicks_persisted_total",
+		Help:      "Clics écrits en base par les workers.",
+	})
+
+	// MonitorLinkUp vaut 1 si l'URL longue d'un lien était accessible lors de la dernière vérification, 0 sinon.
+	MonitorLinkUp = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "monitor_link_up",
+		Help:      "État de l'URL longue de chaque lien lors de la dernière vérification (1 accessible, 0 inaccessible).",
+	}, []string{"short_code"})
+
+	// MonitorChecksTotal compte les vérifications d'URL effectuées par le moniteur, selon leur résultat.
+	MonitorChecksTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "monitor_checks_total",
+		Help:      "Vérifications d'URL par résultat (up, down).",
+	}, []string{"result"})
+
+	// MonitorCycleDuration est la durée du dernier cycle de vérification du moniteur.
+	MonitorCycleDuration = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "monitor_cycle_duration_seconds",
+		Help:      "Durée du dernier cycle de vérification des URLs.",
+	})
+
+	// MonitorCyclesSkipped compte les cycles non lancés parce que le précédent n'était pas terminé.
+	MonitorCyclesSkipped = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "monitor_cycles_skipped_total",
+		Help:      "Cycles de vérification ignorés car le cycle précédent était toujours en cours.",
+	})
+
+	// NotificationsTotal compte les notifications de changement d'état par canal, selon le résultat de leur envoi.
+	NotificationsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "notifications_total",
+		Help:      "Notifications de changement d'état par canal et par résultat (sent, failed).",
+	}, []string{"channel", "result"})
+)
+
+// RegisterClickEventsChannel expose la profondeur et la capacité du channel des événements de clic.
+// Les fonctions sont appelées à chaque collecte. À n'appeler qu'une fois.
+func RegisterClickEventsChannel(length, capacity func() int) {
+	promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "click_events_channel_length",
+		Help:      "Nombre d'événements de clic en attente dans le channel.",
+	}, func() float64 { return float64(length()) })
+	promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "click_events_channel_capacity",
+		Help:      "Capacité du channel des événements de clic.",
+	}, func() float64 { return float64(capacity()) })
+}
+
+// RegisterLinkCache expose les compteurs du cache des liens. À n'appeler qu'une fois.
+func RegisterLinkCache(cache *repository.CachedLinkRepository) {
+	promauto.NewCounterFunc(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "link_cache_hits_total",
+		Help:      "Lectures de liens servies par le cache.",
+	}, func() float64 { return float64(cache.Stats().Hits) })
+	promauto.NewCounterFunc(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "link_cache_misses_total",
+		Help:      "Lectures de liens transmises à la base.",
+	}, func() float64 { return float64(cache.Stats().Misses) })
+	promauto.NewCounterFunc(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "link_cache_evictions_total",
+		Help:      "Entrées retirées du cache pour faire de la place.",
+	}, func() float64 { return float64(cache.Stats().Evictions) })
+	promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "link_cache_entries",
+		Help:      "Nombre d'entrées actuellement dans le cache des liens.",
+	}, func() float64 { return float64(cache.Stats().Entries) })
+}
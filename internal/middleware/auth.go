@@ -0,0 +1,103 @@
+package middleware
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// callerContextKey est la clé sous laquelle APIKeyAuth range le Caller dans le contexte Gin.
+const callerContextKey = "caller"
+
+// workspaceHeader est l'en-tête par lequel une requête choisit l'espace de travail dans lequel elle agit.
+// Sans cet en-tête, la requête porte sur les liens personnels de la clé.
+const workspaceHeader = "X-Workspace-ID"
+
+// APIKeyAuth exige une clé d'API valide, présentée dans l'en-tête 'Authorization: Bearer <clé>'
+// ou 'X-API-Key', et range le Caller correspondant, avec l'espace de travail demandé par
+// l'en-tête X-Workspace-ID et le rôle qu'y tient la clé, dans le contexte de la requête.
+// Les requêtes sans clé ou avec une clé inconnue ou révoquée reçoivent une réponse 401 ;
+// celles qui visent un espace dont la clé n'est pas membre reçoivent une réponse 403.
+func APIKeyAuth(apiKeyService *services.APIKeyService, workspaceService *services.WorkspaceService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		plaintext := apiKeyFromRequest(c.Request)
+		if plaintext == "" {
+			c.Header("WWW-Authenticate", "Bearer")
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Missing API key"})
+			return
+		}
+
+		key, err := apiKeyService.Authenticate(plaintext)
+		if err != nil {
+			if errors.Is(err, services.ErrInvalidAPIKey) {
+				c.Header("WWW-Authenticate", "Bearer")
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid API key"})
+				return
+			}
+			log.Printf("Error authenticating API key: %v", err)
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+
+		var workspaceID uint64
+		if header := c.GetHeader(workspaceHeader); header != "" {
+			if workspaceID, err = strconv.ParseUint(header, 10, 0); err != nil || workspaceID == 0 {
+				c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "X-Workspace-ID must be a positive integer"})
+				return
+			}
+		}
+
+		caller, err := workspaceService.ResolveCaller(key, uint(workspaceID))
+		if err != nil {
+			switch {
+			case errors.Is(err, gorm.ErrRecordNotFound):
+				c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "Workspace not found"})
+			case errors.Is(err, services.ErrNotWorkspaceMember):
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			default:
+				log.Printf("Error resolving workspace %d for API key %d: %v", workspaceID, key.ID, err)
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			}
+			return
+		}
+
+		c.Set(callerContextKey, caller)
+		c.Next()
+	}
+}
+
+// RequireWorkspace refuse avec une réponse 400 les requêtes d'une clé membre d'un espace de travail
+// qui ne choisissent pas d'espace par l'en-tête X-Workspace-ID. Il suit APIKeyAuth sur les routes des liens.
+func RequireWorkspace() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if CallerFromContext(c).WorkspaceRequired() {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": services.ErrWorkspaceRequired.Error()})
+			return
+		}
+		c.Next()
+	}
+}
+
+// CallerFromContext retourne le Caller authentifié par APIKeyAuth. Si la requête n'est pas passée par
+// ce middleware (authentification désactivée), il retourne un Caller anonyme, jamais nil :
+// l'API n'agit pas sans restriction.
+func CallerFromContext(c *gin.Context) *services.Caller {
+	if value, ok := c.Get(callerContextKey); ok {
+		return value.(*services.Caller)
+	}
+	return &services.Caller{}
+}
+
+// apiKeyFromRequest extrait la clé d'API des en-têtes de la requête.
+func apiKeyFromRequest(r *http.Request) string {
+	if scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " "); ok && strings.EqualFold(scheme, "Bearer") {
+		return strings.TrimSpace(token)
+	}
+	return strings.TrimSpace(r.Header.Get("X-API-Key"))
+}
@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/metrics"
+	"github.com/gin-gonic/gin"
+)
+
+// unmatchedRoute est le label de route des requêtes qui ne correspondent à aucune route,
+// pour ne pas créer une série par chemin inconnu.
+const unmatchedRoute = "unmatched"
+
+// Metrics mesure la durée de chaque requête et l'enregistre dans metrics.HTTPRequestDuration.
+// La route est le motif Gin (ex: /api/v1/links/:shortCode), ce qui borne le nombre de séries.
+func Metrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+		metrics.HTTPRequestDuration.
+			WithLabelValues(c.Request.Method, route, strconv.Itoa(c.Writer.Status())).
+			Observe(time.Since(start).Seconds())
+	}
+}
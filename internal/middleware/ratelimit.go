@@ -0,0 +1,133 @@
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// tokenBucket est l'état du seau de jetons d'un client.
+type tokenBucket struct {
+	tokens   float64   // Jetons disponibles (fractionnaires entre deux recharges)
+	lastSeen time.Time // Dernière requête, sert à la recharge et à l'éviction des seaux inactifs
+}
+
+// RateLimiter applique un algorithme de seau de jetons (token bucket) par clé.
+// Chaque clé dispose de 'burst' jetons au maximum, rechargés au rythme de 'rate' jetons par seconde.
+// Les seaux inactifs depuis plus de 'idleTimeout' sont supprimés pour borner la mémoire.
+type RateLimiter struct {
+	rate        float64 // Jetons rechargés par seconde
+	burst       int     // Capacité maximale du seau
+	idleTimeout time.Duration
+	buckets     map[string]*tokenBucket
+	lastSweep   time.Time
+	mu          sync.Mutex // Protège buckets et lastSweep, Allow étant appelé par plusieurs requêtes en parallèle
+}
+
+// RateLimitResult décrit la décision prise pour une requête.
+type RateLimitResult struct {
+	Allowed    bool
+	Limit      int           // Capacité du seau
+	Remaining  int           // Jetons restants après la requête
+	RetryAfter time.Duration // Délai avant le prochain jeton disponible (si refusée)
+	ResetAfter time.Duration // Délai avant que le seau soit de nouveau plein
+}
+
+// NewRateLimiter crée un RateLimiter autorisant 'requestsPerMinute' requêtes par minute
+// en régime établi, avec des rafales d'au plus 'burst' requêtes.
+func NewRateLimiter(requestsPerMinute float64, burst int, idleTimeout time.Duration) *RateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &RateLimiter{
+		rate:        requestsPerMinute / 60,
+		burst:       burst,
+		idleTimeout: idleTimeout,
+		buckets:     make(map[string]*tokenBucket),
+		lastSweep:   time.Now(),
+	}
+}
+
+// Allow consomme un jeton pour la clé donnée s'il en reste un.
+func (l *RateLimiter) Allow(key string) RateLimitResult {
+	now := time.Now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.sweep(now)
+
+	b, exists := l.buckets[key]
+	if !exists {
+		b = &tokenBucket{tokens: float64(l.burst), lastSeen: now}
+		l.buckets[key] = b
+	} else {
+		// Recharge proportionnelle au temps écoulé depuis la dernière requête.
+		elapsed := now.Sub(b.lastSeen).Seconds()
+		b.tokens = math.Min(float64(l.burst), b.tokens+elapsed*l.rate)
+		b.lastSeen = now
+	}
+
+	result := RateLimitResult{Limit: l.burst}
+	if b.tokens >= 1 {
+		b.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = l.durationFor(1 - b.tokens)
+	}
+	result.Remaining = int(b.tokens)
+	result.ResetAfter = l.durationFor(float64(l.burst) - b.tokens)
+	return result
+}
+
+// durationFor retourne le temps nécessaire pour recharger 'tokens' jetons.
+func (l *RateLimiter) durationFor(tokens float64) time.Duration {
+	if tokens <= 0 {
+		return 0
+	}
+	return time.Duration(tokens / l.rate * float64(time.Second))
+}
+
+// sweep supprime les seaux inactifs. Le balayage est paresseux : il n'a lieu qu'au plus
+// une fois par 'idleTimeout', pendant un appel à Allow, ce qui évite une goroutine dédiée.
+// L'appelant doit détenir le verrou.
+func (l *RateLimiter) sweep(now time.Time) {
+	if l.idleTimeout <= 0 || now.Sub(l.lastSweep) < l.idleTimeout {
+		return
+	}
+	for key, b := range l.buckets {
+		if now.Sub(b.lastSeen) >= l.idleTimeout {
+			delete(l.buckets, key)
+		}
+	}
+	l.lastSweep = now
+}
+
+// RateLimit retourne un middleware Gin qui limite le débit par adresse IP cliente.
+// Les en-têtes X-RateLimit-* sont ajoutés à chaque réponse ; une requête refusée
+// reçoit une réponse 429 avec l'en-tête Retry-After.
+func RateLimit(limiter *RateLimiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		result := limiter.Allow(c.ClientIP())
+
+		c.Header("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		c.Header("X-RateLimit-Reset", strconv.Itoa(ceilSeconds(result.ResetAfter)))
+
+		if !result.Allowed {
+			c.Header("Retry-After", strconv.Itoa(ceilSeconds(result.RetryAfter)))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "Too many requests"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// ceilSeconds arrondit une durée à la seconde supérieure, comme l'attendent les en-têtes HTTP.
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,124 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ageBucket fait comme si la dernière requête de 'key' datait de 'age'.
+func ageBucket(l *RateLimiter, key string, age time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.buckets[key].lastSeen = l.buckets[key].lastSeen.Add(-age)
+}
+
+func TestRateLimiterAllow(t *testing.T) {
+	tests := []struct {
+		name          string
+		perMinute     float64
+		burst         int
+		requests      int           // Requêtes envoyées d'un coup
+		idle          time.Duration // Attente simulée avant une dernière requête
+		wantAllowed   int           // Requêtes acceptées parmi 'requests'
+		wantLast      bool          // Décision pour la dernière requête
+		wantRemaining int           // Jetons restants après la dernière requête
+	}{
+		{"burst then refused", 60, 3, 5, 0, 3, false, 0},
+		{"one token refilled", 60, 3, 3, 1500 * time.Millisecond, 3, true, 0},
+		{"refill capped at burst", 60, 3, 3, time.Hour, 3, true, 2},
+		{"burst below one", 60, 0, 2, 0, 1, false, 0},
+		{"slow rate", 1, 2, 2, 30 * time.Second, 2, false, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := NewRateLimiter(tt.perMinute, tt.burst, 0)
+			allowed := 0
+			for i := 0; i < tt.requests; i++ {
+				if l.Allow("client").Allowed {
+					allowed++
+				}
+			}
+			if allowed != tt.wantAllowed {
+				t.Fatalf("allowed %d requests, want %d", allowed, tt.wantAllowed)
+			}
+
+			ageBucket(l, "client", tt.idle)
+			result := l.Allow("client")
+			if result.Allowed != tt.wantLast || result.Remaining != tt.wantRemaining {
+				t.Errorf("last request = %+v, want allowed=%v remaining=%d", result, tt.wantLast, tt.wantRemaining)
+			}
+			if !result.Allowed && result.RetryAfter <= 0 {
+				t.Errorf("refused request without Retry-After: %+v", result)
+			}
+		})
+	}
+}
+
+func TestRateLimiterKeysAreIndependent(t *testing.T) {
+	l := NewRateLimiter(60, 1, 0)
+	if !l.Allow("a").Allowed {
+		t.Fatal("first request of a refused")
+	}
+	if l.Allow("a").Allowed {
+		t.Fatal("second request of a allowed")
+	}
+	if !l.Allow("b").Allowed {
+		t.Fatal("first request of b refused because of a")
+	}
+}
+
+func TestRateLimiterSweep(t *testing.T) {
+	l := NewRateLimiter(60, 1, time.Minute)
+	l.Allow("idle")
+	l.Allow("active")
+	ageBucket(l, "idle", 2*time.Minute)
+	l.lastSweep = l.lastSweep.Add(-2 * time.Minute)
+
+	l.Allow("active")
+	if _, ok := l.buckets["idle"]; ok {
+		t.Error("idle bucket not swept")
+	}
+	if _, ok := l.buckets["active"]; !ok {
+		t.Error("active bucket swept")
+	}
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(RateLimit(NewRateLimiter(60, 2, 0)))
+	router.GET("/", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	tests := []struct {
+		wantStatus     int
+		wantRemaining  string
+		wantRetryAfter string
+	}{
+		{http.StatusOK, "1", ""},
+		{http.StatusOK, "0", ""},
+		{http.StatusTooManyRequests, "0", "1"},
+	}
+	for i, tt := range tests {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = "192.0.2.1:1234"
+		router.ServeHTTP(w, req)
+
+		if w.Code != tt.wantStatus {
+			t.Errorf("request %d: status = %d, want %d", i, w.Code, tt.wantStatus)
+		}
+		if got := w.Header().Get("X-RateLimit-Limit"); got != "2" {
+			t.Errorf("request %d: X-RateLimit-Limit = %q, want 2", i, got)
+		}
+		if got := w.Header().Get("X-RateLimit-Remaining"); got != tt.wantRemaining {
+			t.Errorf("request %d: X-RateLimit-Remaining = %q, want %q", i, got, tt.wantRemaining)
+		}
+		if got := w.Header().Get("Retry-After"); got != tt.wantRetryAfter {
+			t.Errorf("request %d: Retry-After = %q, want %q", i, got, tt.wantRetryAfter)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package migrations
+
+import (
+	"fmt"
+	"log"
+	"time"
+)
+
+// Copies figées des modèles tels qu'ils étaient au moment de la migration 0001.
+// Elles servent uniquement à mettre à niveau une base créée par l'ancienne commande migrate
+// (AutoMigrate de GORM) avant de la considérer comme étant au schéma initial : selon la version
+// qui l'a créée, il peut lui manquer des colonnes, des tables ou des index.
+// Elles ne doivent jamais suivre l'évolution des modèles : les changements de schéma passent par
+// les fichiers SQL.
+
+type baselineLink struct {
+	ID        uint   `gorm:"primaryKey"`
+	Shortcode string `gorm:"unique;index;size:32"`
+	LongURL   string `gorm:"not null"`
+	CreatedAt string
+	ExpiresAt *time.Time `gorm:"index"`
+	MaxClicks int        `gorm:"not null;default:0"`
+}
+
+func (baselineLink) TableName() string { return "links" }
+
+type baselineClick struct {
+	ID          uint         `gorm:"primaryKey"`
+	LinkID      uint         `gorm:"index"`
+	Link        baselineLink `gorm:"foreignKey:LinkID"`
+	Timestamp   time.Time
+	UserAgent   string `gorm:"size:255"`
+	IPAddress   string `gorm:"size:50"`
+	Referrer    string `gorm:"size:253;not null;default:''"`
+	Browser     string `gorm:"size:64;not null;default:''"`
+	OS          string `gorm:"size:64;not null;default:''"`
+	DeviceClass string `gorm:"size:16;not null;default:''"`
+	IsBot       bool   `gorm:"not null;default:false"`
+	Country     string `gorm:"size:2;not null;default:''"`
+	City        string `gorm:"size:128;not null;default:''"`
+}
+
+func (baselineClick) TableName() string { return "clicks" }
+
+type baselineClickDailyCount struct {
+	ID     uint      `gorm:"primaryKey"`
+	LinkID uint      `gorm:"not null;uniqueIndex:idx_click_daily_counts_link_day"`
+	Day    time.Time `gorm:"not null;uniqueIndex:idx_click_daily_counts_link_day"`
+	Clicks int       `gorm:"not null;default:0"`
+}
+
+func (baselineClickDailyCount) TableName() string { return "click_daily_counts" }
+
+type baselineVisitorSalt struct {
+	ID   uint      `gorm:"primaryKey"`
+	Day  time.Time `gorm:"not null;uniqueIndex"`
+	Salt []byte    `gorm:"not null"`
+}
+
+func (baselineVisitorSalt) TableName() string { return "visitor_salts" }
+
+type baselineVisitorSketch struct {
+	ID     uint      `gorm:"primaryKey"`
+	LinkID uint      `gorm:"not null;uniqueIndex:idx_visitor_sketches_link_day"`
+	Day    time.Time `gorm:"not null;uniqueIndex:idx_visitor_sketches_link_day"`
+	Sketch []byte    `gorm:"not null"`
+}
+
+func (baselineVisitorSketch) TableName() string { return "visitor_sketches" }
+
+// upgradeBaseline ajoute à une base existante les tables, colonnes et index du schéma initial qui lui manquent.
+// Les données existantes ne sont jamais modifiées ni supprimées.
+func (m *Migrator) upgradeBaseline() error {
+	migrator := m.db.Migrator()
+	for _, table := range []struct {
+		name  string
+		model interface{}
+	}{
+		{"links", &baselineLink{}},
+		{"clicks", &baselineClick{}},
+		{"click_daily_counts", &baselineClickDailyCount{}},
+		{"visitor_salts", &baselineVisitorSalt{}},
+		{"visitor_sketches", &baselineVisitorSketch{}},
+	} {
+		if !migrator.HasTable(table.name) {
+			log.Printf("[MIGRATE] Base existante : création de la table manquante %s.", table.name)
+		}
+		if err := migrator.AutoMigrate(table.model); err != nil {
+			return fmt.Errorf("failed to upgrade existing table %s to the initial schema: %w", table.name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,341 @@
+package migrations
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Les migrations sont des fichiers SQL numérotés, un jeu par dialecte :
+//
+//	sql/<dialecte>/<version>_<nom>.up.sql    applique la migration
+//	sql/<dialecte>/<version>_<nom>.down.sql  l'annule
+//
+// Ils sont embarqués dans le binaire. Un script contient une ou plusieurs instructions SQL,
+// chacune terminée par un ';' en fin de ligne ; les lignes commençant par '--' sont des commentaires.
+//
+//go:embed sql
+var embedded embed.FS
+
+// DefaultDir est le répertoire des migrations dans les sources, où 'migrate create' écrit les nouveaux fichiers.
+const DefaultDir = "internal/migrations/sql"
+
+// Dialects liste les dialectes pour lesquels chaque migration doit exister.
+var Dialects = []string{"sqlite", "postgres", "mysql"}
+
+// migrationFilePattern reconnaît les noms de fichiers de migration.
+var migrationFilePattern = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// migrationNamePattern valide le nom d'une nouvelle migration.
+var migrationNamePattern = regexp.MustCompile(`^[a-z0-9_]+$`)
+
+// ErrInvalidMigrationName est retournée quand le nom d'une nouvelle migration contient des caractères non autorisés.
+var ErrInvalidMigrationName = errors.New("invalid migration name: use lowercase letters, digits and '_'")
+
+// Migration est une évolution du schéma et son annulation.
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status est l'état d'une migration dans une base.
+type Status struct {
+	Migration
+	Applied   bool
+	AppliedAt time.Time
+}
+
+// schemaMigration est une ligne de la table 'schema_migrations', qui enregistre les migrations appliquées.
+type schemaMigration struct {
+	Version   int64     `gorm:"primaryKey;autoIncrement:false"`
+	Name      string    `gorm:"size:255;not null"`
+	AppliedAt time.Time `gorm:"not null"`
+}
+
+// TableName fixe le nom de la table de suivi.
+func (schemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// Migrator applique et annule les migrations du dialecte d'une base.
+type Migrator struct {
+	db         *gorm.DB
+	migrations []Migration // Triées par version croissante
+}
+
+// NewMigrator charge les migrations embarquées correspondant au dialecte de 'db'.
+func NewMigrator(db *gorm.DB) (*Migrator, error) {
+	dialect := db.Dialector.Name()
+	migrations, err := load(embedded, "sql/"+dialect)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load %s migrations: %w", dialect, err)
+	}
+	return &Migrator{db: db, migrations: migrations}, nil
+}
+
+// load lit et associe les fichiers up et down d'un répertoire de migrations.
+func load(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		match := migrationFilePattern.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+		version, _ := strconv.ParseInt(match[1], 10, 64)
+		content, err := fs.ReadFile(fsys, dir+"/"+entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		migration := byVersion[version]
+		if migration == nil {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		} else if migration.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has two names: '%s' and '%s'", version, migration.Name, match[2])
+		}
+		if match[3] == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" || migration.Down == "" {
+			return nil, fmt.Errorf("migration %d_%s must have both an up and a down file", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// ensureTable crée la table 'schema_migrations' si besoin. Elle n'est appelée que par Up et Down,
+// les seules opérations qui modifient le schéma.
+// Une base créée par l'ancienne commande migrate (AutoMigrate) a déjà les tables de la migration 1
+// mais pas de table de suivi : la migration 1 y est alors enregistrée comme appliquée sans être exécutée.
+func (m *Migrator) ensureTable() error {
+	migrator := m.db.Migrator()
+	if migrator.HasTable(&schemaMigration{}) {
+		return nil
+	}
+	baseline := migrator.HasTable("links") && len(m.migrations) > 0 && m.migrations[0].Version == 1
+	if baseline {
+		// La base a pu être créée par une version plus ancienne que le schéma initial :
+		// elle est complétée avant que la migration 0001 ne soit enregistrée comme appliquée.
+		// En cas d'échec, la table de suivi n'est pas créée et la mise à niveau sera retentée.
+		if err := m.upgradeBaseline(); err != nil {
+			return err
+		}
+	}
+	if err := migrator.CreateTable(&schemaMigration{}); err != nil {
+		return fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+
+	if baseline {
+		first := m.migrations[0]
+		if err := m.db.Create(&schemaMigration{Version: first.Version, Name: first.Name, AppliedAt: time.Now().UTC()}).Error; err != nil {
+			return err
+		}
+		log.Printf("[MIGRATE] Base existante sans suivi des migrations : migration %d_%s enregistrée comme appliquée.",
+			first.Version, first.Name)
+	}
+	return nil
+}
+
+// applied retourne les migrations appliquées, indexées par version.
+// Elle ne modifie pas la base : sans table 'schema_migrations', aucune migration n'est considérée
+// comme appliquée (une base existante y sera rattachée par le prochain Up).
+func (m *Migrator) applied() (map[int64]schemaMigration, error) {
+	if !m.db.Migrator().HasTable(&schemaMigration{}) {
+		return map[int64]schemaMigration{}, nil
+	}
+	var rows []schemaMigration
+	if err := m.db.Order("version").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	applied := make(map[int64]schemaMigration, len(rows))
+	for _, row := range rows {
+		applied[row.Version] = row
+	}
+	return applied, nil
+}
+
+// Status retourne l'état de chaque migration connue, par version croissante.
+func (m *Migrator) Status() ([]Status, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		row, ok := applied[migration.Version]
+		statuses = append(statuses, Status{Migration: migration, Applied: ok, AppliedAt: row.AppliedAt})
+	}
+	return statuses, nil
+}
+
+// Pending retourne les migrations qui restent à appliquer, par version croissante.
+func (m *Migrator) Pending() ([]Migration, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+	var pending []Migration
+	for _, migration := range m.migrations {
+		if _, ok := applied[migration.Version]; !ok {
+			pending = append(pending, migration)
+		}
+	}
+	return pending, nil
+}
+
+// Up applique toutes les migrations en attente, dans l'ordre, et retourne celles qui ont été appliquées.
+// Chaque migration est exécutée dans sa propre transaction (MySQL valide toutefois chaque instruction DDL
+// immédiatement) ; en cas d'échec, les migrations suivantes ne sont pas tentées.
+func (m *Migrator) Up() ([]Migration, error) {
+	if err := m.ensureTable(); err != nil {
+		return nil, err
+	}
+	pending, err := m.Pending()
+	if err != nil {
+		return nil, err
+	}
+	var done []Migration
+	for _, migration := range pending {
+		err := m.db.Transaction(func(tx *gorm.DB) error {
+			if err := execScript(tx, migration.Up); err != nil {
+				return err
+			}
+			return tx.Create(&schemaMigration{Version: migration.Version, Name: migration.Name, AppliedAt: time.Now().UTC()}).Error
+		})
+		if err != nil {
+			return done, fmt.Errorf("migration %d_%s failed: %w", migration.Version, migration.Name, err)
+		}
+		done = append(done, migration)
+	}
+	return done, nil
+}
+
+// Down annule les 'steps' dernières migrations appliquées, de la plus récente à la plus ancienne,
+// et retourne celles qui ont été annulées.
+func (m *Migrator) Down(steps int) ([]Migration, error) {
+	if err := m.ensureTable(); err != nil {
+		return nil, err
+	}
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+	var done []Migration
+	for i := len(m.migrations) - 1; i >= 0 && len(done) < steps; i-- {
+		migration := m.migrations[i]
+		if _, ok := applied[migration.Version]; !ok {
+			continue
+		}
+		err := m.db.Transaction(func(tx *gorm.DB) error {
+			if err := execScript(tx, migration.Down); err != nil {
+				return err
+			}
+			return tx.Delete(&schemaMigration{}, migration.Version).Error
+		})
+		if err != nil {
+			return done, fmt.Errorf("rollback of migration %d_%s failed: %w", migration.Version, migration.Name, err)
+		}
+		done = append(done, migration)
+	}
+	return done, nil
+}
+
+// execScript exécute les instructions d'un script de migration une par une,
+// tous les pilotes n'acceptant pas plusieurs instructions par requête.
+func execScript(tx *gorm.DB, script string) error {
+	for _, statement := range splitStatements(script) {
+		if err := tx.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// splitStatements découpe un script en instructions terminées par un ';' en fin de ligne,
+// en ignorant les lignes vides et les commentaires '--'.
+func splitStatements(script string) []string {
+	var statements []string
+	var current strings.Builder
+	for _, line := range strings.Split(script, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+		current.WriteString(line)
+		current.WriteString("\n")
+		if strings.HasSuffix(trimmed, ";") {
+			statements = append(statements, strings.TrimSuffix(strings.TrimSpace(current.String()), ";"))
+			current.Reset()
+		}
+	}
+	if rest := strings.TrimSpace(current.String()); rest != "" {
+		statements = append(statements, rest)
+	}
+	return statements
+}
+
+// Create écrit les fichiers up et down vides d'une nouvelle migration pour chaque dialecte dans 'dir',
+// avec le numéro de version suivant le plus grand numéro existant. Il retourne les chemins créés.
+// Les fichiers doivent ensuite être complétés puis le binaire recompilé pour être embarqués.
+func Create(dir, name string) ([]string, error) {
+	if !migrationNamePattern.MatchString(name) {
+		return nil, ErrInvalidMigrationName
+	}
+
+	var last int64
+	for _, dialect := range Dialects {
+		migrations, err := load(os.DirFS(dir), dialect)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+		for _, migration := range migrations {
+			if migration.Version > last {
+				last = migration.Version
+			}
+		}
+	}
+	version := last + 1
+
+	var created []string
+	for _, dialect := range Dialects {
+		if err := os.MkdirAll(filepath.Join(dir, dialect), 0o755); err != nil {
+			return created, err
+		}
+		for _, direction := range []string{"up", "down"} {
+			path := filepath.Join(dir, dialect, fmt.Sprintf("%04d_%s.%s.sql", version, name, direction))
+			header := fmt.Sprintf("-- Migration %04d_%s (%s, %s)\n", version, name, dialect, direction)
+			if err := os.WriteFile(path, []byte(header), 0o644); err != nil {
+				return created, err
+			}
+			created = append(created, path)
+		}
+	}
+	return created, nil
+}
@@ -0,0 +1,349 @@
+package migrations
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// openTestDB ouvre une base SQLite vide dans un répertoire temporaire.
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("failed to open test database: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db
+}
+
+// newTestMigrator retourne un migrateur limité aux 'upTo' premières migrations SQLite (toutes si upTo vaut 0).
+func newTestMigrator(t *testing.T, db *gorm.DB, upTo int) *Migrator {
+	t.Helper()
+	m, err := NewMigrator(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if upTo > 0 {
+		m.migrations = m.migrations[:upTo]
+	}
+	return m
+}
+
+func versions(migrations []Migration) []int64 {
+	var result []int64
+	for _, migration := range migrations {
+		result = append(result, migration.Version)
+	}
+	return result
+}
+
+func TestSplitStatements(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+		want   []string
+	}{
+		{"empty", "", nil},
+		{"comments only", "-- a comment\n  -- another\n\n", nil},
+		{"single statement", "DROP TABLE `links`;\n", []string{"DROP TABLE `links`"}},
+		{"multi-line statement", "CREATE TABLE `t` (\n  `id` integer\n);\n", []string{"CREATE TABLE `t` (\n  `id` integer\n)"}},
+		{"several statements", "-- header\nALTER TABLE a;\n\nALTER TABLE b;\n", []string{"ALTER TABLE a", "ALTER TABLE b"}},
+		{"semicolon inside a line", "UPDATE t SET v = 'a;b'\nWHERE id = 1;\n", []string{"UPDATE t SET v = 'a;b'\nWHERE id = 1"}},
+		{"missing final semicolon", "ALTER TABLE a;\nALTER TABLE b\n", []string{"ALTER TABLE a", "ALTER TABLE b"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := splitStatements(tt.script); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitStatements() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   fstest.MapFS
+		want    []int64
+		wantErr bool
+	}{
+		{"sorted by version", fstest.MapFS{
+			"d/0010_b.up.sql":   {Data: []byte("b")},
+			"d/0010_b.down.sql": {Data: []byte("b")},
+			"d/0002_a.up.sql":   {Data: []byte("a")},
+			"d/0002_a.down.sql": {Data: []byte("a")},
+			"d/README.md":       {Data: []byte("ignored")},
+		}, []int64{2, 10}, false},
+		{"missing down file", fstest.MapFS{
+			"d/0001_a.up.sql": {Data: []byte("a")},
+		}, nil, true},
+		{"two names for one version", fstest.MapFS{
+			"d/0001_a.up.sql":   {Data: []byte("a")},
+			"d/0001_b.down.sql": {Data: []byte("b")},
+		}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			migrations, err := load(tt.files, "d")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("load() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := versions(migrations); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("load() versions = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// Chaque migration doit exister, sous le même nom, pour tous les dialectes.
+func TestEmbeddedMigrationsMatchAcrossDialects(t *testing.T) {
+	var reference []Migration
+	for _, dialect := range Dialects {
+		migrations, err := load(embedded, "sql/"+dialect)
+		if err != nil {
+			t.Fatalf("%s: %v", dialect, err)
+		}
+		if reference == nil {
+			reference = migrations
+			continue
+		}
+		if len(migrations) != len(reference) {
+			t.Fatalf("%s has %d migrations, %s has %d", dialect, len(migrations), Dialects[0], len(reference))
+		}
+		for i, migration := range migrations {
+			if migration.Version != reference[i].Version || migration.Name != reference[i].Name {
+				t.Errorf("%s migration %d_%s, %s has %d_%s", dialect, migration.Version, migration.Name,
+					Dialects[0], reference[i].Version, reference[i].Name)
+			}
+		}
+	}
+}
+
+func TestUpDown(t *testing.T) {
+	db := openTestDB(t)
+	m := newTestMigrator(t, db, 0)
+	all := versions(m.migrations)
+
+	pending, err := m.Pending()
+	if err != nil || !reflect.DeepEqual(versions(pending), all) {
+		t.Fatalf("Pending() on an empty database = %v, %v; want %v", versions(pending), err, all)
+	}
+
+	steps := []struct {
+		name        string
+		run         func() ([]Migration, error)
+		wantDone    []int64
+		wantApplied int
+	}{
+		{"up applies everything", m.Up, all, len(all)},
+		{"up again is a no-op", m.Up, nil, len(all)},
+		{"down one step", func() ([]Migration, error) { return m.Down(1) }, all[len(all)-1:], len(all) - 1},
+		{"up reapplies the last one", m.Up, all[len(all)-1:], len(all)},
+		{"down everything", func() ([]Migration, error) { return m.Down(len(all) + 1) }, reversed(all), 0},
+		{"up from scratch", m.Up, all, len(all)},
+	}
+	for _, step := range steps {
+		done, err := step.run()
+		if err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		if got := versions(done); !reflect.DeepEqual(got, step.wantDone) {
+			t.Errorf("%s: done = %v, want %v", step.name, got, step.wantDone)
+		}
+		statuses, err := m.Status()
+		if err != nil {
+			t.Fatal(err)
+		}
+		applied := 0
+		for _, status := range statuses {
+			if status.Applied {
+				applied++
+				if status.AppliedAt.IsZero() {
+					t.Errorf("%s: migration %d applied without a date", step.name, status.Version)
+				}
+			}
+		}
+		if applied != step.wantApplied {
+			t.Errorf("%s: %d migrations applied, want %d", step.name, applied, step.wantApplied)
+		}
+		if hasLinks := db.Migrator().HasTable("links"); hasLinks != (step.wantApplied > 0) {
+			t.Errorf("%s: links table exists = %v", step.name, hasLinks)
+		}
+	}
+}
+
+func reversed(values []int64) []int64 {
+	result := make([]int64, 0, len(values))
+	for i := len(values) - 1; i >= 0; i-- {
+		result = append(result, values[i])
+	}
+	return result
+}
+
+// Une base créée par l'ancienne commande migrate n'est rattachée au suivi des migrations que par Up :
+// Status et Pending ne la modifient pas.
+func TestExistingDatabaseBaseline(t *testing.T) {
+	db := openTestDB(t)
+	// Base créée par une ancienne version : seule la table links existe, sans la colonne max_clicks.
+	if err := db.Exec("CREATE TABLE `links` (`id` integer PRIMARY KEY AUTOINCREMENT, `shortcode` text, `long_url` text NOT NULL, `created_at` text)").Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Exec("INSERT INTO `links` (`shortcode`, `long_url`, `created_at`) VALUES ('abc', 'https://example.com', '2025-06-01 14:03:27 +0000 UTC')").Error; err != nil {
+		t.Fatal(err)
+	}
+	m := newTestMigrator(t, db, 0)
+
+	if _, err := m.Status(); err != nil {
+		t.Fatal(err)
+	}
+	pending, err := m.Pending()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != len(m.migrations) {
+		t.Errorf("Pending() = %v, want every migration", versions(pending))
+	}
+	if db.Migrator().HasTable(&schemaMigration{}) || db.Migrator().HasTable("clicks") {
+		t.Fatal("Status or Pending modified the database")
+	}
+
+	done, err := m.Up()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := versions(m.migrations[1:]); !reflect.DeepEqual(versions(done), want) {
+		t.Errorf("Up() = %v, want %v (migration 1 recorded without being run)", versions(done), want)
+	}
+	for _, table := range []string{"clicks", "click_daily_counts", "visitor_salts", "visitor_sketches"} {
+		if !db.Migrator().HasTable(table) {
+			t.Errorf("missing table %s after upgrade", table)
+		}
+	}
+	var count int64
+	if err := db.Table("links").Where("shortcode = ?", "abc").Count(&count).Error; err != nil || count != 1 {
+		t.Errorf("existing link lost: count = %d, %v", count, err)
+	}
+}
+
+// La migration 0002 convertit le texte de time.Time.String() en horodatage UTC, et l'annulation le rétablit.
+func TestCreatedAtConversion(t *testing.T) {
+	tests := []struct {
+		name      string
+		createdAt *string
+		want      *time.Time
+		wantText  *string
+	}{
+		{"positive offset", ptr("2025-06-01 14:03:27.123456789 +0200 CEST m=+0.011"), ptr(time.Date(2025, 6, 1, 12, 3, 27, 0, time.UTC)), ptr("2025-06-01 12:03:27 +0000 UTC")},
+		{"negative offset", ptr("2025-06-01 22:45:00.5 -0530 -0530"), ptr(time.Date(2025, 6, 2, 4, 15, 0, 0, time.UTC)), ptr("2025-06-02 04:15:00 +0000 UTC")},
+		{"utc without fraction", ptr("2024-12-31 23:59:59 +0000 UTC"), ptr(time.Date(2024, 12, 31, 23, 59, 59, 0, time.UTC)), ptr("2024-12-31 23:59:59 +0000 UTC")},
+		{"empty", ptr(""), nil, nil},
+		{"null", nil, nil, nil},
+	}
+
+	db := openTestDB(t)
+	if _, err := newTestMigrator(t, db, 1).Up(); err != nil {
+		t.Fatal(err)
+	}
+	for i, tt := range tests {
+		if err := db.Exec("INSERT INTO `links` (`id`, `shortcode`, `long_url`, `created_at`) VALUES (?, ?, 'https://example.com', ?)",
+			i+1, tt.name, tt.createdAt).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	m := newTestMigrator(t, db, 2)
+	if _, err := m.Up(); err != nil {
+		t.Fatal(err)
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got struct{ CreatedAt *time.Time }
+			if err := db.Table("links").Select("created_at").Where("id = ?", i+1).Scan(&got).Error; err != nil {
+				t.Fatal(err)
+			}
+			switch {
+			case tt.want == nil && got.CreatedAt != nil:
+				t.Errorf("created_at = %v, want NULL", got.CreatedAt)
+			case tt.want != nil && (got.CreatedAt == nil || !got.CreatedAt.Equal(*tt.want)):
+				t.Errorf("created_at = %v, want %v", got.CreatedAt, tt.want)
+			}
+		})
+	}
+
+	if _, err := m.Down(1); err != nil {
+		t.Fatal(err)
+	}
+	for i, tt := range tests {
+		t.Run(tt.name+" rollback", func(t *testing.T) {
+			var got struct{ CreatedAt *string }
+			if err := db.Table("links").Select("created_at").Where("id = ?", i+1).Scan(&got).Error; err != nil {
+				t.Fatal(err)
+			}
+			if (got.CreatedAt == nil) != (tt.wantText == nil) || (got.CreatedAt != nil && *got.CreatedAt != *tt.wantText) {
+				t.Errorf("created_at = %v, want %v", deref(got.CreatedAt), deref(tt.wantText))
+			}
+		})
+	}
+}
+
+func ptr[T any](v T) *T { return &v }
+
+func deref(s *string) string {
+	if s == nil {
+		return "NULL"
+	}
+	return *s
+}
+
+func TestCreate(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "sqlite"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"0004_old.up.sql", "0004_old.down.sql"} {
+		if err := os.WriteFile(filepath.Join(dir, "sqlite", name), []byte("-- old\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		wantErr error
+		wantUp  string
+	}{
+		{"add_index", nil, "0005_add_index.up.sql"},
+		{"second_one", nil, "0006_second_one.up.sql"},
+		{"Bad-Name", ErrInvalidMigrationName, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			created, err := Create(dir, tt.name)
+			if err != tt.wantErr {
+				t.Fatalf("Create() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if len(created) != 2*len(Dialects) {
+				t.Fatalf("Create() = %v, want an up and a down file per dialect", created)
+			}
+			for _, dialect := range Dialects {
+				if _, err := os.Stat(filepath.Join(dir, dialect, tt.wantUp)); err != nil {
+					t.Error(err)
+				}
+			}
+		})
+	}
+}
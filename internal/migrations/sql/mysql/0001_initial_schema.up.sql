@@ -0,0 +1,53 @@
+-- Schéma initial, identique à celui créé par l'ancienne commande migrate (AutoMigrate de GORM).
+-- Seule différence : le code court a une collation binaire, pour que les codes soient sensibles à la casse.
+CREATE TABLE `links` (
+  `id` BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+  `shortcode` VARCHAR(32) CHARACTER SET ascii COLLATE ascii_bin,
+  `long_url` LONGTEXT NOT NULL,
+  `created_at` LONGTEXT,
+  `expires_at` DATETIME(3) NULL,
+  `max_clicks` BIGINT NOT NULL DEFAULT 0,
+  CONSTRAINT `uni_links_shortcode` UNIQUE (`shortcode`),
+  INDEX `idx_links_expires_at` (`expires_at`),
+  INDEX `idx_links_shortcode` (`shortcode`)
+);
+
+CREATE TABLE `clicks` (
+  `id` BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+  `link_id` BIGINT UNSIGNED,
+  `timestamp` DATETIME(3) NULL,
+  `user_agent` VARCHAR(255),
+  `ip_address` VARCHAR(50),
+  `referrer` VARCHAR(253) NOT NULL DEFAULT '',
+  `browser` VARCHAR(64) NOT NULL DEFAULT '',
+  `os` VARCHAR(64) NOT NULL DEFAULT '',
+  `device_class` VARCHAR(16) NOT NULL DEFAULT '',
+  `is_bot` BOOLEAN NOT NULL DEFAULT false,
+  `country` VARCHAR(2) NOT NULL DEFAULT '',
+  `city` VARCHAR(128) NOT NULL DEFAULT '',
+  INDEX `idx_clicks_link_id` (`link_id`),
+  CONSTRAINT `fk_clicks_link` FOREIGN KEY (`link_id`) REFERENCES `links` (`id`)
+);
+
+CREATE TABLE `click_daily_counts` (
+  `id` BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+  `link_id` BIGINT UNSIGNED NOT NULL,
+  `day` DATETIME(3) NOT NULL,
+  `clicks` BIGINT NOT NULL DEFAULT 0,
+  UNIQUE INDEX `idx_click_daily_counts_link_day` (`link_id`, `day`)
+);
+
+CREATE TABLE `visitor_salts` (
+  `id` BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+  `day` DATETIME(3) NOT NULL,
+  `salt` LONGBLOB NOT NULL,
+  UNIQUE INDEX `idx_visitor_salts_day` (`day`)
+);
+
+CREATE TABLE `visitor_sketches` (
+  `id` BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+  `link_id` BIGINT UNSIGNED NOT NULL,
+  `day` DATETIME(3) NOT NULL,
+  `sketch` LONGBLOB NOT NULL,
+  UNIQUE INDEX `idx_visitor_sketches_link_day` (`link_id`, `day`)
+);
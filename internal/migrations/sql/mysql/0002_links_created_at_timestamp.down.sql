@@ -0,0 +1,7 @@
+-- Rétablit links.created_at au format texte de time.Time.String(), en UTC.
+DROP INDEX `idx_links_created_at` ON `links`;
+ALTER TABLE `links` ADD COLUMN `created_at_text` LONGTEXT;
+UPDATE `links` SET `created_at_text` = CONCAT(DATE_FORMAT(`created_at`, '%Y-%m-%d %H:%i:%s.%f'), ' +0000 UTC')
+WHERE `created_at` IS NOT NULL;
+ALTER TABLE `links` DROP COLUMN `created_at`;
+ALTER TABLE `links` RENAME COLUMN `created_at_text` TO `created_at`;
@@ -0,0 +1,15 @@
+-- Convertit links.created_at, jusqu'ici le texte de time.Time.String()
+-- (ex: "2025-06-01 14:03:27.123456789 +0200 CEST m=+0.01"), en horodatage UTC.
+-- La partie fractionnaire des secondes n'est pas conservée.
+ALTER TABLE `links` ADD COLUMN `created_at_ts` DATETIME(3) NULL;
+
+UPDATE `links` SET `created_at_ts` = CONVERT_TZ(
+    STR_TO_DATE(LEFT(`created_at`, 19), '%Y-%m-%d %H:%i:%s'),
+    CONCAT(LEFT(SUBSTRING_INDEX(SUBSTRING_INDEX(`created_at`, ' ', 3), ' ', -1), 3), ':',
+           RIGHT(SUBSTRING_INDEX(SUBSTRING_INDEX(`created_at`, ' ', 3), ' ', -1), 2)),
+    '+00:00')
+WHERE `created_at` IS NOT NULL AND `created_at` <> '';
+
+ALTER TABLE `links` DROP COLUMN `created_at`;
+ALTER TABLE `links` RENAME COLUMN `created_at_ts` TO `created_at`;
+CREATE INDEX `idx_links_created_at` ON `links` (`created_at`);
@@ -0,0 +1,15 @@
+-- Clés d'API (seule l'empreinte SHA-256 de la clé est stockée) et propriétaire de chaque lien.
+CREATE TABLE `api_keys` (
+  `id` BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+  `name` VARCHAR(100) NOT NULL,
+  `prefix` VARCHAR(16) NOT NULL,
+  `key_hash` VARCHAR(64) NOT NULL,
+  `created_at` DATETIME(3) NOT NULL,
+  `last_used_at` DATETIME(3) NULL,
+  `revoked_at` DATETIME(3) NULL,
+  CONSTRAINT `uni_api_keys_key_hash` UNIQUE (`key_hash`)
+);
+
+-- NULL pour les liens créés par la CLI ou avant l'authentification.
+ALTER TABLE `links` ADD COLUMN `created_by` BIGINT UNSIGNED NULL;
+CREATE INDEX `idx_links_created_by` ON `links` (`created_by`);
@@ -0,0 +1,26 @@
+-- Espaces de travail, membres (clés d'API) avec leur rôle, et espace de travail de chaque lien.
+CREATE TABLE `workspaces` (
+  `id` BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+  `name` VARCHAR(100) NOT NULL,
+  `created_at` DATETIME(3) NOT NULL,
+  CONSTRAINT `uni_workspaces_name` UNIQUE (`name`)
+);
+
+CREATE TABLE `workspace_members` (
+  `id` BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+  `workspace_id` BIGINT UNSIGNED NOT NULL,
+  `api_key_id` BIGINT UNSIGNED NOT NULL,
+  `role` VARCHAR(16) NOT NULL,
+  `created_at` DATETIME(3) NOT NULL,
+  UNIQUE INDEX `idx_workspace_members_workspace_key` (`workspace_id`, `api_key_id`),
+  INDEX `idx_workspace_members_api_key_id` (`api_key_id`),
+  CONSTRAINT `fk_workspace_members_workspace` FOREIGN KEY (`workspace_id`) REFERENCES `workspaces` (`id`),
+  CONSTRAINT `fk_workspace_members_api_key` FOREIGN KEY (`api_key_id`) REFERENCES `api_keys` (`id`)
+);
+
+-- Les clés d'administration gèrent les espaces de travail.
+ALTER TABLE `api_keys` ADD COLUMN `is_admin` BOOLEAN NOT NULL DEFAULT false;
+
+-- NULL pour les liens personnels d'une clé ou créés par la CLI.
+ALTER TABLE `links` ADD COLUMN `workspace_id` BIGINT UNSIGNED NULL;
+CREATE INDEX `idx_links_workspace_id` ON `links` (`workspace_id`);
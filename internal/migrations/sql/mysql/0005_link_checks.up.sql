@@ -0,0 +1,12 @@
+-- Historique des vérifications du moniteur d'URLs.
+CREATE TABLE `link_checks` (
+  `id` BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+  `link_id` BIGINT UNSIGNED NOT NULL,
+  `checked_at` DATETIME(3) NOT NULL,
+  `up` BOOLEAN NOT NULL,
+  `status_code` BIGINT NOT NULL DEFAULT 0,
+  `latency_ms` BIGINT NOT NULL DEFAULT 0,
+  `error_class` VARCHAR(32) NOT NULL DEFAULT '',
+  INDEX `idx_link_checks_link_checked_at` (`link_id`, `checked_at`),
+  INDEX `idx_link_checks_checked_at` (`checked_at`)
+);
@@ -0,0 +1,3 @@
+ALTER TABLE `link_checks` DROP COLUMN `redirects`;
+ALTER TABLE `link_checks` DROP COLUMN `final_url`;
+ALTER TABLE `link_checks` DROP COLUMN `state_up`;
@@ -0,0 +1,5 @@
+-- URL de secours d'un lien et URL de secours par défaut d'un espace de travail, utilisées par les
+-- redirections tant que le moniteur déclare l'URL longue inaccessible, et marquage des clics redirigés vers elles.
+ALTER TABLE `links` ADD COLUMN `fallback_url` TEXT NULL;
+ALTER TABLE `workspaces` ADD COLUMN `fallback_url` TEXT NULL;
+ALTER TABLE `clicks` ADD COLUMN `fallback` BOOLEAN NOT NULL DEFAULT false;
@@ -0,0 +1 @@
+ALTER TABLE `links` MODIFY `shortcode` VARCHAR(32);
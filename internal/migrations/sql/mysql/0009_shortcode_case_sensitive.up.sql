@@ -0,0 +1,3 @@
+-- Codes courts sensibles à la casse : la collation par défaut de MySQL confondait 'abc' et 'ABC'.
+-- Les bases créées avant la correction de la migration 0001 sont mises à niveau ici.
+ALTER TABLE `links` MODIFY `shortcode` VARCHAR(32) CHARACTER SET ascii COLLATE ascii_bin;
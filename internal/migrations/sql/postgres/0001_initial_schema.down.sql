@@ -0,0 +1,5 @@
+DROP TABLE visitor_sketches;
+DROP TABLE visitor_salts;
+DROP TABLE click_daily_counts;
+DROP TABLE clicks;
+DROP TABLE links;
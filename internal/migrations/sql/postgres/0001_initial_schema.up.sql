@@ -0,0 +1,52 @@
+-- Schéma initial, identique à celui créé par l'ancienne commande migrate (AutoMigrate de GORM).
+CREATE TABLE links (
+  id BIGSERIAL PRIMARY KEY,
+  shortcode VARCHAR(32),
+  long_url TEXT NOT NULL,
+  created_at TEXT,
+  expires_at TIMESTAMPTZ,
+  max_clicks BIGINT NOT NULL DEFAULT 0,
+  CONSTRAINT uni_links_shortcode UNIQUE (shortcode)
+);
+CREATE INDEX idx_links_expires_at ON links (expires_at);
+CREATE INDEX idx_links_shortcode ON links (shortcode);
+
+CREATE TABLE clicks (
+  id BIGSERIAL PRIMARY KEY,
+  link_id BIGINT,
+  timestamp TIMESTAMPTZ,
+  user_agent VARCHAR(255),
+  ip_address VARCHAR(50),
+  referrer VARCHAR(253) NOT NULL DEFAULT '',
+  browser VARCHAR(64) NOT NULL DEFAULT '',
+  os VARCHAR(64) NOT NULL DEFAULT '',
+  device_class VARCHAR(16) NOT NULL DEFAULT '',
+  is_bot BOOLEAN NOT NULL DEFAULT false,
+  country VARCHAR(2) NOT NULL DEFAULT '',
+  city VARCHAR(128) NOT NULL DEFAULT '',
+  CONSTRAINT fk_clicks_link FOREIGN KEY (link_id) REFERENCES links (id)
+);
+CREATE INDEX idx_clicks_link_id ON clicks (link_id);
+
+CREATE TABLE click_daily_counts (
+  id BIGSERIAL PRIMARY KEY,
+  link_id BIGINT NOT NULL,
+  day TIMESTAMPTZ NOT NULL,
+  clicks BIGINT NOT NULL DEFAULT 0
+);
+CREATE UNIQUE INDEX idx_click_daily_counts_link_day ON click_daily_counts (link_id, day);
+
+CREATE TABLE visitor_salts (
+  id BIGSERIAL PRIMARY KEY,
+  day TIMESTAMPTZ NOT NULL,
+  salt BYTEA NOT NULL
+);
+CREATE UNIQUE INDEX idx_visitor_salts_day ON visitor_salts (day);
+
+CREATE TABLE visitor_sketches (
+  id BIGSERIAL PRIMARY KEY,
+  link_id BIGINT NOT NULL,
+  day TIMESTAMPTZ NOT NULL,
+  sketch BYTEA NOT NULL
+);
+CREATE UNIQUE INDEX idx_visitor_sketches_link_day ON visitor_sketches (link_id, day);
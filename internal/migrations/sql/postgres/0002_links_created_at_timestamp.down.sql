@@ -0,0 +1,5 @@
+-- Rétablit links.created_at au format texte de time.Time.String(), en UTC.
+DROP INDEX idx_links_created_at;
+ALTER TABLE links ALTER COLUMN created_at TYPE TEXT USING (
+  to_char(created_at AT TIME ZONE 'UTC', 'YYYY-MM-DD HH24:MI:SS.US') || ' +0000 UTC'
+);
@@ -0,0 +1,8 @@
+-- Convertit links.created_at, jusqu'ici le texte de time.Time.String()
+-- (ex: "2025-06-01 14:03:27.123456789 +0200 CEST m=+0.01"), en horodatage avec fuseau.
+ALTER TABLE links ALTER COLUMN created_at TYPE TIMESTAMPTZ USING (
+  CASE WHEN created_at IS NULL OR created_at = '' THEN NULL
+  ELSE (split_part(created_at, ' ', 1) || ' ' || split_part(created_at, ' ', 2) || ' ' || split_part(created_at, ' ', 3))::timestamptz
+  END
+);
+CREATE INDEX idx_links_created_at ON links (created_at);
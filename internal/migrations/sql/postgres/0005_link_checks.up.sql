@@ -0,0 +1,12 @@
+-- Historique des vérifications du moniteur d'URLs.
+CREATE TABLE link_checks (
+  id BIGSERIAL PRIMARY KEY,
+  link_id BIGINT NOT NULL,
+  checked_at TIMESTAMPTZ NOT NULL,
+  up BOOLEAN NOT NULL,
+  status_code BIGINT NOT NULL DEFAULT 0,
+  latency_ms BIGINT NOT NULL DEFAULT 0,
+  error_class VARCHAR(32) NOT NULL DEFAULT ''
+);
+CREATE INDEX idx_link_checks_link_checked_at ON link_checks (link_id, checked_at);
+CREATE INDEX idx_link_checks_checked_at ON link_checks (checked_at);
@@ -0,0 +1,6 @@
+-- État déclaré du lien après chaque vérification (amorti sur plusieurs vérifications consécutives),
+-- et redirections suivies lors de la vérification.
+ALTER TABLE link_checks ADD COLUMN state_up BOOLEAN NOT NULL DEFAULT false;
+UPDATE link_checks SET state_up = up;
+ALTER TABLE link_checks ADD COLUMN final_url TEXT;
+ALTER TABLE link_checks ADD COLUMN redirects TEXT;
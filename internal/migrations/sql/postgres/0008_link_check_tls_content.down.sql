@@ -0,0 +1,2 @@
+ALTER TABLE link_checks DROP COLUMN content_hash;
+ALTER TABLE link_checks DROP COLUMN tls_expires_at;
@@ -0,0 +1 @@
+-- Rien à annuler avec ce dialecte.
@@ -0,0 +1,52 @@
+-- Schéma initial, identique à celui créé par l'ancienne commande migrate (AutoMigrate de GORM).
+CREATE TABLE `links` (
+  `id` integer PRIMARY KEY AUTOINCREMENT,
+  `shortcode` text,
+  `long_url` text NOT NULL,
+  `created_at` text,
+  `expires_at` datetime,
+  `max_clicks` integer NOT NULL DEFAULT 0,
+  CONSTRAINT `uni_links_shortcode` UNIQUE (`shortcode`)
+);
+CREATE INDEX `idx_links_expires_at` ON `links`(`expires_at`);
+CREATE INDEX `idx_links_shortcode` ON `links`(`shortcode`);
+
+CREATE TABLE `clicks` (
+  `id` integer PRIMARY KEY AUTOINCREMENT,
+  `link_id` integer,
+  `timestamp` datetime,
+  `user_agent` text,
+  `ip_address` text,
+  `referrer` text NOT NULL DEFAULT '',
+  `browser` text NOT NULL DEFAULT '',
+  `os` text NOT NULL DEFAULT '',
+  `device_class` text NOT NULL DEFAULT '',
+  `is_bot` numeric NOT NULL DEFAULT false,
+  `country` text NOT NULL DEFAULT '',
+  `city` text NOT NULL DEFAULT '',
+  CONSTRAINT `fk_clicks_link` FOREIGN KEY (`link_id`) REFERENCES `links`(`id`)
+);
+CREATE INDEX `idx_clicks_link_id` ON `clicks`(`link_id`);
+
+CREATE TABLE `click_daily_counts` (
+  `id` integer PRIMARY KEY AUTOINCREMENT,
+  `link_id` integer NOT NULL,
+  `day` datetime NOT NULL,
+  `clicks` integer NOT NULL DEFAULT 0
+);
+CREATE UNIQUE INDEX `idx_click_daily_counts_link_day` ON `click_daily_counts`(`link_id`, `day`);
+
+CREATE TABLE `visitor_salts` (
+  `id` integer PRIMARY KEY AUTOINCREMENT,
+  `day` datetime NOT NULL,
+  `salt` blob NOT NULL
+);
+CREATE UNIQUE INDEX `idx_visitor_salts_day` ON `visitor_salts`(`day`);
+
+CREATE TABLE `visitor_sketches` (
+  `id` integer PRIMARY KEY AUTOINCREMENT,
+  `link_id` integer NOT NULL,
+  `day` datetime NOT NULL,
+  `sketch` blob NOT NULL
+);
+CREATE UNIQUE INDEX `idx_visitor_sketches_link_day` ON `visitor_sketches`(`link_id`, `day`);
@@ -0,0 +1,17 @@
+-- Convertit links.created_at, jusqu'ici le texte de time.Time.String()
+-- (ex: "2025-06-01 14:03:27.123456789 +0200 CEST m=+0.01"), en horodatage UTC.
+-- La partie fractionnaire des secondes n'est pas conservée.
+ALTER TABLE `links` ADD COLUMN `created_at_ts` datetime;
+
+UPDATE `links` SET `created_at_ts` = datetime(
+    substr(`created_at`, 1, 19),
+    (CASE substr(`created_at`, 19 + instr(substr(`created_at`, 20), ' ') + 1, 1) WHEN '-' THEN '+' ELSE '-' END)
+      || substr(`created_at`, 19 + instr(substr(`created_at`, 20), ' ') + 2, 2) || ' hours',
+    (CASE substr(`created_at`, 19 + instr(substr(`created_at`, 20), ' ') + 1, 1) WHEN '-' THEN '+' ELSE '-' END)
+      || substr(`created_at`, 19 + instr(substr(`created_at`, 20), ' ') + 4, 2) || ' minutes'
+  ) || '+00:00'
+WHERE `created_at` IS NOT NULL AND `created_at` <> '';
+
+ALTER TABLE `links` DROP COLUMN `created_at`;
+ALTER TABLE `links` RENAME COLUMN `created_at_ts` TO `created_at`;
+CREATE INDEX `idx_links_created_at` ON `links`(`created_at`);
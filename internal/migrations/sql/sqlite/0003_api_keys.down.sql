@@ -0,0 +1,3 @@
+DROP INDEX `idx_links_created_by`;
+ALTER TABLE `links` DROP COLUMN `created_by`;
+DROP TABLE `api_keys`;
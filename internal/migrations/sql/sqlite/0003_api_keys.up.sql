@@ -0,0 +1,15 @@
+-- Clés d'API (seule l'empreinte SHA-256 de la clé est stockée) et propriétaire de chaque lien.
+CREATE TABLE `api_keys` (
+  `id` integer PRIMARY KEY AUTOINCREMENT,
+  `name` text NOT NULL,
+  `prefix` text NOT NULL,
+  `key_hash` text NOT NULL,
+  `created_at` datetime NOT NULL,
+  `last_used_at` datetime,
+  `revoked_at` datetime,
+  CONSTRAINT `uni_api_keys_key_hash` UNIQUE (`key_hash`)
+);
+
+-- NULL pour les liens créés par la CLI ou avant l'authentification.
+ALTER TABLE `links` ADD COLUMN `created_by` integer;
+CREATE INDEX `idx_links_created_by` ON `links`(`created_by`);
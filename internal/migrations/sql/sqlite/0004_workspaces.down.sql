@@ -0,0 +1,5 @@
+DROP INDEX `idx_links_workspace_id`;
+ALTER TABLE `links` DROP COLUMN `workspace_id`;
+ALTER TABLE `api_keys` DROP COLUMN `is_admin`;
+DROP TABLE `workspace_members`;
+DROP TABLE `workspaces`;
@@ -0,0 +1,26 @@
+-- Espaces de travail, membres (clés d'API) avec leur rôle, et espace de travail de chaque lien.
+CREATE TABLE `workspaces` (
+  `id` integer PRIMARY KEY AUTOINCREMENT,
+  `name` text NOT NULL,
+  `created_at` datetime NOT NULL,
+  CONSTRAINT `uni_workspaces_name` UNIQUE (`name`)
+);
+
+CREATE TABLE `workspace_members` (
+  `id` integer PRIMARY KEY AUTOINCREMENT,
+  `workspace_id` integer NOT NULL,
+  `api_key_id` integer NOT NULL,
+  `role` text NOT NULL,
+  `created_at` datetime NOT NULL,
+  CONSTRAINT `fk_workspace_members_workspace` FOREIGN KEY (`workspace_id`) REFERENCES `workspaces`(`id`),
+  CONSTRAINT `fk_workspace_members_api_key` FOREIGN KEY (`api_key_id`) REFERENCES `api_keys`(`id`)
+);
+CREATE UNIQUE INDEX `idx_workspace_members_workspace_key` ON `workspace_members`(`workspace_id`, `api_key_id`);
+CREATE INDEX `idx_workspace_members_api_key_id` ON `workspace_members`(`api_key_id`);
+
+-- Les clés d'administration gèrent les espaces de travail.
+ALTER TABLE `api_keys` ADD COLUMN `is_admin` numeric NOT NULL DEFAULT false;
+
+-- NULL pour les liens personnels d'une clé ou créés par la CLI.
+ALTER TABLE `links` ADD COLUMN `workspace_id` integer;
+CREATE INDEX `idx_links_workspace_id` ON `links`(`workspace_id`);
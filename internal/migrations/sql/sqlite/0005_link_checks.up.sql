@@ -0,0 +1,12 @@
+-- Historique des vérifications du moniteur d'URLs.
+CREATE TABLE `link_checks` (
+  `id` integer PRIMARY KEY AUTOINCREMENT,
+  `link_id` integer NOT NULL,
+  `checked_at` datetime NOT NULL,
+  `up` numeric NOT NULL,
+  `status_code` integer NOT NULL DEFAULT 0,
+  `latency_ms` integer NOT NULL DEFAULT 0,
+  `error_class` text NOT NULL DEFAULT ''
+);
+CREATE INDEX `idx_link_checks_link_checked_at` ON `link_checks`(`link_id`, `checked_at`);
+CREATE INDEX `idx_link_checks_checked_at` ON `link_checks`(`checked_at`);
@@ -0,0 +1,3 @@
+ALTER TABLE `clicks` DROP COLUMN `fallback`;
+ALTER TABLE `workspaces` DROP COLUMN `fallback_url`;
+ALTER TABLE `links` DROP COLUMN `fallback_url`;
@@ -0,0 +1,3 @@
+-- Date d'expiration du certificat TLS de l'URL longue et empreinte de son contenu, relevées à chaque vérification.
+ALTER TABLE `link_checks` ADD COLUMN `tls_expires_at` datetime;
+ALTER TABLE `link_checks` ADD COLUMN `content_hash` text;
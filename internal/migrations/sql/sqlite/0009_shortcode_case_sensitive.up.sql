@@ -0,0 +1 @@
+-- Codes courts sensibles à la casse : rien à faire, la colonne 'shortcode' l'est déjà avec ce dialecte.
@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// APIKey représente une clé d'accès à l'API.
+// La clé elle-même n'est jamais stockée : seule son empreinte SHA-256 l'est, avec un préfixe
+// non secret qui permet de la reconnaître dans les listes.
+// Une clé révoquée est conservée pour que les liens qu'elle a créés gardent leur propriétaire.
+// Une clé d'administration gère les espaces de travail et a accès à tous leurs liens.
+type APIKey struct {
+	ID         uint       `gorm:"primaryKey"`
+	Name       string     `gorm:"size:100;not null"`
+	Prefix     string     `gorm:"size:16;not null"`
+	KeyHash    string     `gorm:"size:64;not null;unique"`
+	IsAdmin    bool       `gorm:"not null;default:false"`
+	CreatedAt  time.Time  `gorm:"not null"`
+	LastUsedAt *time.Time // nil si la clé n'a jamais été utilisée
+	RevokedAt  *time.Time // nil tant que la clé est active
+}
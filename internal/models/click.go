@@ -9,16 +9,59 @@ type Click struct {
 	LinkID    uint      `gorm:"index"`             // Clé étrangère vers la table 'links', indexée pour des requêtes efficaces
 	Link      Link      `gorm:"foreignKey:LinkID"` // Relation GORM: indique que LinkID est une FK vers le champ ID de Link
 	Timestamp time.Time // Horodatage précis du clic
-	UserAgent string    `gorm:"size:255"` // User-Agent de l'utilisateur qui a cliqué (informations sur le navigateur/OS)
-	IPAddress string    `gorm:"size:50"`  // Adresse IP de l'utilisateur
+	UserAgent string    `gorm:"size:255"`                     // User-Agent de l'utilisateur qui a cliqué (informations sur le navigateur/OS)
+	IPAddress string    `gorm:"size:50"`                      // Adresse IP de l'utilisateur
+	Referrer  string    `gorm:"size:253;not null;default:''"` // Domaine du site d'origine (en-tête Referer normalisé), vide pour un accès direct
+	// Champs déduits du User-Agent par les workers, vides si la famille n'est pas reconnue
+	Browser     string `gorm:"size:64;not null;default:''"` // Famille de navigateur (Chrome, Firefox...)
+	OS          string `gorm:"size:64;not null;default:''"` // Famille de système d'exploitation (Windows, iOS...)
+	DeviceClass string `gorm:"size:16;not null;default:''"` // desktop, mobile ou tablet
+	IsBot       bool   `gorm:"not null;default:false"`      // Clic d'un robot (crawler, aperçu de lien, script...)
+	// Champs déduits de l'adresse IP par les workers quand une base GeoIP est configurée
+	Country string `gorm:"size:2;not null;default:''"`   // Code pays ISO 3166-1 alpha-2
+	City    string `gorm:"size:128;not null;default:''"` // Nom anglais de la ville
+	// Clic redirigé vers l'URL de secours du lien parce que le moniteur déclarait son URL longue inaccessible
+	Fallback bool `gorm:"not null;default:false"`
+	// Empreinte du visiteur calculée par les workers pour le comptage des visiteurs uniques.
+	// Elle n'est jamais persistée avec le clic.
+	VisitorHash uint64 `gorm:"-"`
+	// Adresse IP déjà anonymisée avant la persistance (clic rejoué depuis le spool).
+	IPAnonymized bool `gorm:"-"`
 }
 
 // ClickEvent représente un événement de clic brut, destiné à être passé via un channel
 // Ce n'est pas un modèle GORM direct.
 // Un Click event a un LinkID(uint), un Timestamp (Time.Time), un UserAgent (string) et un IP (stringà
 type ClickEvent struct {
-	LinkID    uint
-	Timestamp time.Time
-	UserAgent string
-	IPAddress string
+	LinkID       uint
+	Timestamp    time.Time
+	UserAgent    string
+	IPAddress    string
+	Referrer     string // Domaine du site d'origine, déjà normalisé
+	Fallback     bool   // Redirigé vers l'URL de secours du lien
+	IPAnonymized bool   // Adresse IP anonymisée avant l'écriture de l'événement dans le spool sur disque
+}
+
+// ClickDailyCount conserve le nombre de clics d'un lien pour une journée (UTC) après la suppression
+// des clics détaillés par la politique de rétention en mode agrégation.
+// GORM utilisera ces tags pour créer la table 'click_daily_counts'.
+type ClickDailyCount struct {
+	ID     uint      `gorm:"primaryKey"`
+	LinkID uint      `gorm:"not null;uniqueIndex:idx_click_daily_counts_link_day"`
+	Day    time.Time `gorm:"not null;uniqueIndex:idx_click_daily_counts_link_day"` // Minuit UTC du jour agrégé
+	Clicks int       `gorm:"not null;default:0"`
+}
+
+// ClickBucket est le nombre de clics d'un lien sur un intervalle de temps commençant à Start.
+// Ce n'est pas un modèle GORM : il est produit par les requêtes d'agrégation sur les clics.
+type ClickBucket struct {
+	Start time.Time
+	Count int
+}
+
+// DimensionCount est le nombre de clics pour une valeur d'une dimension (domaine référent, navigateur...).
+// Ce n'est pas un modèle GORM : il est produit par les requêtes d'agrégation sur les clics.
+type DimensionCount struct {
+	Value string
+	Count int
 }
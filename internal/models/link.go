@@ -1,14 +1,27 @@
 package models
 
+import "time"
+
 // Link représente un lien raccourci dans la base de données.
 // Les tags `gorm:"..."` définissent comment GORM doit mapper cette structure à une table SQL.
 // ID qui est une primaryKey
-// Shortcode : doit être unique, indexé pour des recherches rapide (voir doc), taille max 10 caractères
+// Shortcode : doit être unique, indexé pour des recherches rapide (voir doc), taille max 32 caractères pour accueillir les alias personnalisés
 // LongURL : doit pas être null
-// CreateAt : Horodatage de la créatino du lien
+// CreateAt : Horodatage de la créatino du lien, en UTC
+// ExpiresAt : date d'expiration optionnelle, nil si le lien n'expire jamais
+// MaxClicks : nombre maximal de clics avant expiration, 0 pour illimité
+// CreatedBy : identifiant de la clé d'API qui a créé le lien, nil pour un lien créé par la CLI
+// WorkspaceID : espace de travail propriétaire du lien, nil pour un lien personnel ou créé par la CLI
+// FallbackURL : URL de secours optionnelle, utilisée par les redirections tant que le moniteur déclare LongURL inaccessible
+// Le schéma de la table est défini par les migrations de internal/migrations, pas par ces tags.
 type Link struct {
-	ID        uint   `gorm:"primaryKey"`
-	Shortcode string `gorm:"unique;index;size:10"`
-	LongURL   string `gorm:"not null"`
-	CreatedAt string
+	ID          uint       `gorm:"primaryKey"`
+	Shortcode   string     `gorm:"unique;index;size:32"`
+	LongURL     string     `gorm:"not null"`
+	CreatedAt   time.Time  `gorm:"index"`
+	ExpiresAt   *time.Time `gorm:"index"`
+	MaxClicks   int        `gorm:"not null;default:0"`
+	CreatedBy   *uint      `gorm:"index"`
+	WorkspaceID *uint      `gorm:"index"`
+	FallbackURL string     `gorm:"type:text"`
 }
@@ -0,0 +1,82 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Classes d'erreur d'une vérification, pour regrouper les causes d'indisponibilité sans stocker le message complet.
+const (
+	CheckErrorNone       = ""           // Réponse reçue avec un code de statut sain
+	CheckErrorStatus     = "status"     // Réponse reçue avec un code de statut non sain
+	CheckErrorTimeout    = "timeout"    // Délai de la vérification dépassé
+	CheckErrorDNS        = "dns"        // Nom d'hôte non résolu
+	CheckErrorConnection = "connection" // Connexion refusée ou interrompue
+	CheckErrorTLS        = "tls"        // Certificat ou négociation TLS invalide
+	CheckErrorInvalidURL = "invalid_url"
+	CheckErrorRedirects  = "too_many_redirects"
+	CheckErrorOther      = "other"
+)
+
+// LinkCheck est le résultat d'une vérification de l'URL longue d'un lien par le moniteur.
+// Le schéma de la table est défini par les migrations de internal/migrations, pas par ces tags.
+type LinkCheck struct {
+	ID         uint          `gorm:"primaryKey"`
+	LinkID     uint          `gorm:"not null;index:idx_link_checks_link_checked_at"`
+	CheckedAt  time.Time     `gorm:"not null;index:idx_link_checks_link_checked_at;index"`
+	Up         bool          `gorm:"not null"`           // Résultat de cette vérification
+	StateUp    bool          `gorm:"not null"`           // État déclaré du lien après cette vérification
+	StatusCode int           `gorm:"not null;default:0"` // 0 si aucune réponse n'a été reçue
+	LatencyMs  int64         `gorm:"not null;default:0"`
+	ErrorClass string        `gorm:"size:32;not null;default:''"` // Une des constantes CheckError*
+	FinalURL   string        `gorm:"type:text"`                   // URL atteinte après les redirections, vide sans redirection
+	Redirects  RedirectChain `gorm:"type:text"`                   // Redirections suivies, dans l'ordre
+	// Première date d'expiration des certificats TLS présentés, nil sans connexion HTTPS
+	TLSExpiresAt *time.Time
+	// Empreinte SHA-256 hexadécimale du contenu, vide si elle n'est pas calculée ou si l'URL est inaccessible
+	ContentHash string `gorm:"size:64"`
+}
+
+// RedirectHop est une redirection suivie lors d'une vérification : l'URL demandée et le code 3xx reçu.
+type RedirectHop struct {
+	URL        string `json:"url"`
+	StatusCode int    `json:"status_code"`
+}
+
+// RedirectChain est la suite des redirections d'une vérification, stockée en JSON.
+type RedirectChain []RedirectHop
+
+// Value implémente driver.Valuer : une chaîne vide est stockée à NULL.
+func (c RedirectChain) Value() (driver.Value, error) {
+	if len(c) == 0 {
+		return nil, nil
+	}
+	data, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// Scan implémente sql.Scanner.
+func (c *RedirectChain) Scan(value any) error {
+	var data []byte
+	switch v := value.(type) {
+	case nil:
+		*c = nil
+		return nil
+	case string:
+		data = []byte(v)
+	case []byte:
+		data = v
+	default:
+		return fmt.Errorf("cannot scan %T into RedirectChain", value)
+	}
+	if len(data) == 0 {
+		*c = nil
+		return nil
+	}
+	return json.Unmarshal(data, c)
+}
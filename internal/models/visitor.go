@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// VisitorSalt est le sel aléatoire d'une journée (UTC) utilisé pour calculer les empreintes des visiteurs.
+// Un nouveau sel est tiré chaque jour et les anciens sont supprimés : une empreinte ne permet pas
+// de suivre un visiteur d'un jour à l'autre ni de retrouver son adresse IP.
+// GORM utilisera ces tags pour créer la table 'visitor_salts'.
+type VisitorSalt struct {
+	ID   uint      `gorm:"primaryKey"`
+	Day  time.Time `gorm:"not null;uniqueIndex"` // Minuit UTC du jour
+	Salt []byte    `gorm:"not null"`
+}
+
+// VisitorSketch contient le sketch HyperLogLog des visiteurs d'un lien pour une journée (UTC).
+// GORM utilisera ces tags pour créer la table 'visitor_sketches'.
+type VisitorSketch struct {
+	ID     uint      `gorm:"primaryKey"`
+	LinkID uint      `gorm:"not null;uniqueIndex:idx_visitor_sketches_link_day"`
+	Day    time.Time `gorm:"not null;uniqueIndex:idx_visitor_sketches_link_day"` // Minuit UTC du jour
+	Sketch []byte    `gorm:"not null"`                                           // Registres du sketch sérialisés
+}
@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// Rôles d'un membre dans un espace de travail, du plus au moins privilégié.
+const (
+	RoleOwner  = "owner"  // Gère les liens et les membres de l'espace
+	RoleEditor = "editor" // Crée, modifie et supprime les liens de l'espace
+	RoleViewer = "viewer" // Consulte les liens et leurs statistiques
+)
+
+// Workspace représente un espace de travail partagé par une équipe : il possède des liens
+// et des membres (clés d'API) qui y ont chacun un rôle.
+type Workspace struct {
+	ID        uint      `gorm:"primaryKey"`
+	Name      string    `gorm:"size:100;not null;unique"`
+	CreatedAt time.Time `gorm:"not null"`
+	// FallbackURL est l'URL de secours des liens de l'espace qui n'en définissent pas, vide si aucune.
+	FallbackURL string `gorm:"type:text"`
+}
+
+// WorkspaceMember associe une clé d'API à un espace de travail avec un rôle.
+type WorkspaceMember struct {
+	ID          uint      `gorm:"primaryKey"`
+	WorkspaceID uint      `gorm:"not null;uniqueIndex:idx_workspace_members_workspace_key"`
+	APIKeyID    uint      `gorm:"not null;uniqueIndex:idx_workspace_members_workspace_key;index"`
+	Role        string    `gorm:"size:16;not null"` // RoleOwner, RoleEditor ou RoleViewer
+	CreatedAt   time.Time `gorm:"not null"`
+}
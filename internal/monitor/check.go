@@ -0,0 +1,245 @@
+package monitor
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+)
+
+// maxRedirects est le nombre maximal de redirections suivies lors d'une vérification.
+const maxRedirects = 10
+
+// errTooManyRedirects interrompt une vérification qui dépasse maxRedirects.
+var errTooManyRedirects = errors.New("too many redirects")
+
+// checkResult est le résultat d'une vérification d'URL.
+type checkResult struct {
+	Up         bool
+	StatusCode int // 0 si aucune réponse n'a été reçue
+	Latency    time.Duration
+	ErrorClass string // Une des constantes models.CheckError*
+	FinalURL   string // URL atteinte après les redirections, vide sans redirection
+	Redirects  models.RedirectChain
+	// TLSExpiresAt est la première date d'expiration des certificats présentés par les serveurs HTTPS
+	// rencontrés, redirections comprises. nil sans connexion HTTPS.
+	TLSExpiresAt *time.Time
+	// ContentHash est l'empreinte du contenu selon cfg.ContentMode, vide si elle n'est pas calculée
+	// ou si l'URL est inaccessible.
+	ContentHash string
+}
+
+// StatusSet est un ensemble de codes de statut HTTP, décrit par des intervalles.
+type StatusSet struct {
+	ranges [][2]int
+}
+
+// DefaultHealthyStatuses sont les codes de statut considérés comme sains par défaut : 2xx et 3xx.
+var DefaultHealthyStatuses = StatusSet{ranges: [][2]int{{200, 399}}}
+
+// ParseStatusSet construit un StatusSet à partir d'éléments de la forme "2xx" (classe), "200-299"
+// (intervalle) ou "404" (code seul). Une liste vide retourne DefaultHealthyStatuses.
+func ParseStatusSet(specs []string) (StatusSet, error) {
+	if len(specs) == 0 {
+		return DefaultHealthyStatuses, nil
+	}
+	var set StatusSet
+	for _, spec := range specs {
+		spec = strings.ToLower(strings.TrimSpace(spec))
+		var low, high int
+		var err error
+		switch {
+		case len(spec) == 3 && strings.HasSuffix(spec, "xx"):
+			low, err = strconv.Atoi(spec[:1])
+			low *= 100
+			high = low + 99
+		case strings.Contains(spec, "-"):
+			bounds := strings.SplitN(spec, "-", 2)
+			if low, err = strconv.Atoi(bounds[0]); err == nil {
+				high, err = strconv.Atoi(bounds[1])
+			}
+		default:
+			low, err = strconv.Atoi(spec)
+			high = low
+		}
+		if err != nil || low < 100 || high > 599 || low > high {
+			return StatusSet{}, fmt.Errorf("invalid HTTP status set element '%s' (use 2xx, 200-299 or 200)", spec)
+		}
+		set.ranges = append(set.ranges, [2]int{low, high})
+	}
+	return set, nil
+}
+
+// Contains indique si 'code' appartient à l'ensemble.
+func (s StatusSet) Contains(code int) bool {
+	for _, r := range s.ranges {
+		if code >= r[0] && code <= r[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// newCheckClient crée le client HTTP partagé par les vérifications. Il suit au plus maxRedirects redirections.
+func newCheckClient(cfg Config) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConnsPerHost = cfg.PerHostConcurrency
+	return &http.Client{
+		Timeout:   cfg.Timeout,
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxRedirects {
+				return errTooManyRedirects
+			}
+			return nil
+		},
+	}
+}
+
+// checkURL vérifie l'accessibilité d'une URL par une requête HEAD. Si le serveur refuse la méthode HEAD
+// (405 ou 501), la vérification est refaite par une requête GET limitée au premier octet.
+// Quand l'empreinte du contenu est demandée (cfg.ContentMode), la vérification se fait directement
+// par une requête GET complète. La requête est annulée si ctx l'est.
+func (m *UrlMonitor) checkURL(ctx context.Context, url string) checkResult {
+	if m.cfg.ContentMode != ContentNone {
+		return m.request(ctx, http.MethodGet, url)
+	}
+	result := m.request(ctx, http.MethodHead, url)
+	if result.StatusCode == http.StatusMethodNotAllowed || result.StatusCode == http.StatusNotImplemented {
+		log.Printf("[MONITOR] Requête HEAD refusée par '%s' (code %d), nouvelle tentative en GET", url, result.StatusCode)
+		result = m.request(ctx, http.MethodGet, url)
+	}
+	return result
+}
+
+// request envoie une requête de vérification et en déduit l'état de l'URL d'après cfg.HealthyStatuses.
+// Une requête GET ne lit que le premier octet de la page, sauf si l'empreinte du contenu est demandée.
+func (m *UrlMonitor) request(ctx context.Context, method, url string) checkResult {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		log.Printf("[MONITOR] Erreur d'accès à l'URL '%s': %v", url, err)
+		return checkResult{ErrorClass: models.CheckErrorInvalidURL}
+	}
+	ranged := method == http.MethodGet && m.cfg.ContentMode == ContentNone
+	if ranged {
+		// Seul le code de statut compte : inutile de télécharger la page.
+		req.Header.Set("Range", "bytes=0-0")
+	}
+
+	start := time.Now()
+	resp, err := m.client.Do(req)
+	latency := time.Since(start)
+	if err != nil {
+		log.Printf("[MONITOR] Erreur d'accès à l'URL '%s': %v", url, err)
+		// Si une erreur se produit, on considère l'URL comme inaccessible
+		return checkResult{Latency: latency, ErrorClass: classifyError(err)}
+	}
+	defer resp.Body.Close() // Assurez-vous de fermer le corps de la réponse pour libérer les ressources
+
+	log.Printf("[MONITOR] Requête %s pour l'URL '%s' a renvoyé le code de statut %d", method, url, resp.StatusCode)
+	statusCode := resp.StatusCode
+	if ranged {
+		statusCode = unrangedStatus(statusCode)
+	}
+	result := checkResult{
+		Up:           m.cfg.HealthyStatuses.Contains(statusCode),
+		StatusCode:   statusCode,
+		Latency:      latency,
+		Redirects:    redirectChain(resp),
+		TLSExpiresAt: certificateExpiry(resp),
+	}
+	if len(result.Redirects) > 0 {
+		result.FinalURL = resp.Request.URL.String()
+	}
+	if !result.Up {
+		result.ErrorClass = models.CheckErrorStatus
+	}
+
+	// L'empreinte d'une page d'erreur ne dit rien du contenu attendu : elle n'est calculée que pour une URL accessible.
+	if method == http.MethodGet && m.cfg.ContentMode != ContentNone && result.Up {
+		hash, err := hashContent(m.cfg.ContentMode, resp.Body, m.cfg.ContentMaxBytes)
+		if err != nil {
+			log.Printf("[MONITOR] Erreur de lecture du contenu de l'URL '%s': %v", url, err)
+		}
+		result.ContentHash = hash
+	}
+	// Un serveur qui ignore l'en-tête Range envoie toute la page : seul le début est lu.
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+	return result
+}
+
+// unrangedStatus retourne le code de statut qu'aurait renvoyé une requête GET sans en-tête Range.
+// Une réponse partielle (206) et une plage non satisfaisable (416, page vide) prouvent que la page
+// existe et est accessible : elles valent 200. Les autres codes sont inchangés.
+func unrangedStatus(statusCode int) int {
+	switch statusCode {
+	case http.StatusPartialContent, http.StatusRequestedRangeNotSatisfiable:
+		return http.StatusOK
+	default:
+		return statusCode
+	}
+}
+
+// certificateExpiry retourne la première date d'expiration des certificats présentés lors des connexions HTTPS
+// qui ont mené à 'resp', redirections comprises, ou nil si aucune connexion n'était en HTTPS.
+// Seul le certificat de chaque serveur est considéré, pas ceux des autorités intermédiaires.
+func certificateExpiry(resp *http.Response) *time.Time {
+	var earliest *time.Time
+	for r := resp; r != nil; r = r.Request.Response {
+		if r.TLS == nil || len(r.TLS.PeerCertificates) == 0 {
+			continue
+		}
+		notAfter := r.TLS.PeerCertificates[0].NotAfter.UTC()
+		if earliest == nil || notAfter.Before(*earliest) {
+			earliest = &notAfter
+		}
+	}
+	return earliest
+}
+
+// redirectChain reconstitue les redirections qui ont mené à 'resp', de la première à la dernière.
+func redirectChain(resp *http.Response) models.RedirectChain {
+	var chain models.RedirectChain
+	for req := resp.Request; req.Response != nil; req = req.Response.Request {
+		chain = append(chain, models.RedirectHop{
+			URL:        req.Response.Request.URL.String(),
+			StatusCode: req.Response.StatusCode,
+		})
+	}
+	for i, j := 0, len(chain)-1; i < j; i, j = i+1, j-1 {
+		chain[i], chain[j] = chain[j], chain[i]
+	}
+	return chain
+}
+
+// classifyError range l'erreur d'une requête de vérification dans l'une des classes models.CheckError*.
+func classifyError(err error) string {
+	var dnsErr *net.DNSError
+	var netErr net.Error
+	var certErr *tls.CertificateVerificationError
+	var recordErr tls.RecordHeaderError
+	var opErr *net.OpError
+	switch {
+	case errors.Is(err, errTooManyRedirects):
+		return models.CheckErrorRedirects
+	case errors.As(err, &dnsErr):
+		return models.CheckErrorDNS
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return models.CheckErrorTimeout
+	case errors.As(err, &certErr), errors.As(err, &recordErr):
+		return models.CheckErrorTLS
+	case errors.As(err, &opErr):
+		return models.CheckErrorConnection
+	default:
+		return models.CheckErrorOther
+	}
+}
@@ -0,0 +1,92 @@
+package monitor
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// ContentMode indique comment le moniteur calcule l'empreinte du contenu d'une URL longue.
+type ContentMode string
+
+// Modes de calcul de l'empreinte du contenu.
+const (
+	ContentNone ContentMode = ""     // Pas d'empreinte : la vérification se fait par une requête HEAD
+	ContentBody ContentMode = "body" // Empreinte du corps de la réponse, octet par octet
+	ContentText ContentMode = "text" // Empreinte du texte visible de la page, sans balises, scripts ni styles
+)
+
+// DefaultContentMaxBytes est la taille maximale du corps lu pour calculer l'empreinte, par défaut.
+const DefaultContentMaxBytes = 512 * 1024
+
+// ParseContentMode valide un mode de calcul de l'empreinte du contenu ("", "none", "body" ou "text").
+func ParseContentMode(mode string) (ContentMode, error) {
+	switch ContentMode(strings.ToLower(strings.TrimSpace(mode))) {
+	case ContentNone, "none":
+		return ContentNone, nil
+	case ContentBody:
+		return ContentBody, nil
+	case ContentText:
+		return ContentText, nil
+	default:
+		return ContentNone, fmt.Errorf("invalid content check mode '%s' (use none, body or text)", mode)
+	}
+}
+
+// hashContent calcule l'empreinte SHA-256 hexadécimale du contenu lu dans 'body' selon 'mode'.
+// Seuls les 'maxBytes' premiers octets sont pris en compte.
+func hashContent(mode ContentMode, body io.Reader, maxBytes int64) (string, error) {
+	content, err := io.ReadAll(io.LimitReader(body, maxBytes))
+	if err != nil {
+		return "", err
+	}
+	if mode == ContentText {
+		content = []byte(extractText(content))
+	}
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// extractText retourne le texte visible d'une page HTML, les mots séparés par une seule espace.
+// Le contenu des éléments script, style, noscript et template est ignoré, comme les attributs :
+// l'empreinte ne change pas quand seuls le balisage, les scripts ou les espaces changent.
+// Un contenu qui n'est pas du HTML est traité comme du texte.
+func extractText(content []byte) string {
+	tokenizer := html.NewTokenizer(strings.NewReader(string(content)))
+	var words []string
+	skipped := 0 // Profondeur dans les éléments dont le texte est ignoré
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			return strings.Join(words, " ")
+		case html.StartTagToken:
+			if isHiddenElement(tokenizer) {
+				skipped++
+			}
+		case html.EndTagToken:
+			if skipped > 0 && isHiddenElement(tokenizer) {
+				skipped--
+			}
+		case html.TextToken:
+			if skipped == 0 {
+				words = append(words, strings.Fields(string(tokenizer.Text()))...)
+			}
+		}
+	}
+}
+
+// isHiddenElement indique si la balise courante du tokenizer est celle d'un élément dont le texte n'est pas affiché.
+func isHiddenElement(tokenizer *html.Tokenizer) bool {
+	name, _ := tokenizer.TagName()
+	switch atom.Lookup(name) {
+	case atom.Script, atom.Style, atom.Noscript, atom.Template:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,75 @@
+package monitor
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+)
+
+// hostLimiter borne le nombre de vérifications simultanées vers un même hôte.
+// Un limiteur est créé pour chaque cycle de vérification.
+type hostLimiter struct {
+	limit int
+	mu    sync.Mutex
+	slots map[string]chan struct{} // Hôte -> sémaphore de capacité 'limit'
+}
+
+// newHostLimiter crée un limiteur autorisant 'limit' vérifications simultanées par hôte.
+func newHostLimiter(limit int) *hostLimiter {
+	return &hostLimiter{limit: limit, slots: make(map[string]chan struct{})}
+}
+
+// acquire attend une place libre pour 'host' et retourne la fonction qui la libère.
+// Elle retourne false si ctx est annulé pendant l'attente.
+func (l *hostLimiter) acquire(ctx context.Context, host string) (release func(), ok bool) {
+	l.mu.Lock()
+	slot, exists := l.slots[host]
+	if !exists {
+		slot = make(chan struct{}, l.limit)
+		l.slots[host] = slot
+	}
+	l.mu.Unlock()
+
+	select {
+	case slot <- struct{}{}:
+		return func() { <-slot }, true
+	case <-ctx.Done():
+		return nil, false
+	}
+}
+
+// hostOf retourne l'hôte d'une URL en minuscules, ou une chaîne vide si l'URL est invalide.
+func hostOf(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(parsed.Hostname())
+}
+
+// interleaveByHost réordonne les liens en alternant les hôtes : le premier lien de chaque hôte,
+// puis le deuxième de chaque hôte, etc. L'ordre relatif des liens d'un même hôte est conservé.
+func interleaveByHost(links []models.Link) []models.Link {
+	var hosts []string
+	byHost := make(map[string][]models.Link)
+	for _, link := range links {
+		host := hostOf(link.LongURL)
+		if _, ok := byHost[host]; !ok {
+			hosts = append(hosts, host)
+		}
+		byHost[host] = append(byHost[host], link)
+	}
+
+	result := make([]models.Link, 0, len(links))
+	for round := 0; len(result) < len(links); round++ {
+		for _, host := range hosts {
+			if round < len(byHost[host]) {
+				result = append(result, byHost[host][round])
+			}
+		}
+	}
+	return result
+}
@@ -1,113 +1,420 @@
 package monitor
 
 import (
+	"context"
 	"log"
 	"net/http"
 	"sync" // Pour protéger l'accès concurrentiel à knownStates
+	"sync/atomic"
 	"time"
 
-	_ "github.com/axellelanca/urlshortener/internal/models"   // Importe les modèles de liens
+	"github.com/axellelanca/urlshortener/internal/metrics"
+	"github.com/axellelanca/urlshortener/internal/models" // Importe les modèles de liens
+	"github.com/axellelanca/urlshortener/internal/notify"
 	"github.com/axellelanca/urlshortener/internal/repository" // Importe le repository de liens
 )
 
+// Config regroupe les paramètres du moniteur d'URLs.
+type Config struct {
+	Interval           time.Duration // Intervalle entre le début de deux cycles de vérification (ex: 5 minutes)
+	Concurrency        int           // Nombre maximal de vérifications simultanées
+	PerHostConcurrency int           // Nombre maximal de vérifications simultanées vers un même hôte
+	Timeout            time.Duration // Délai maximal d'une vérification
+	HistoryRetention   time.Duration // Durée de conservation des vérifications enregistrées (0: sans limite)
+	HealthyStatuses    StatusSet     // Codes de statut qui indiquent une URL accessible
+	FailureThreshold   int           // Échecs consécutifs avant de déclarer une URL accessible INACCESSIBLE
+	RecoveryThreshold  int           // Succès consécutifs avant de déclarer une URL inaccessible ACCESSIBLE
+	TLSWarning         time.Duration // Délai avant l'expiration d'un certificat TLS à partir duquel elle est signalée (0: jamais)
+	ContentMode        ContentMode   // Calcul de l'empreinte du contenu, pour signaler ses changements
+	ContentMaxBytes    int64         // Taille maximale du contenu lu pour calculer son empreinte
+}
+
 // UrlMonitor gère la surveillance périodique des URLs longues.
 type UrlMonitor struct {
-	linkRepo    repository.LinkRepository // Pour récupérer les URLs à surveiller
-	interval    time.Duration             // Intervalle entre chaque vérification (ex: 5 minutes)
-	knownStates map[uint]bool             // État connu de chaque URL: map[LinkID]estAccessible (true/false)
-	mu          sync.Mutex                // Mutex pour protéger l'accès concurrentiel à knownStates
+	linkRepo    repository.LinkRepository      // Pour récupérer les URLs à surveiller
+	checkRepo   repository.LinkCheckRepository // Pour enregistrer le résultat des vérifications
+	notifier    notify.Notifier                // Pour signaler les changements constatés, nil pour les journaliser seulement
+	cfg         Config
+	client      *http.Client         // Partagé par toutes les vérifications pour réutiliser les connexions
+	knownStates map[uint]bool        // État déclaré de chaque URL: map[LinkID]estAccessible (true/false)
+	streaks     map[uint]int         // Vérifications consécutives contraires à l'état déclaré, par lien
+	reported    map[string]struct{}  // Codes courts dont l'état est exposé dans metrics.MonitorLinkUp
+	tlsWarned   map[uint]time.Time   // Date d'expiration du certificat déjà signalée, par lien
+	contents    map[uint]contentHash // Dernière empreinte du contenu, par lien
+	mu          sync.Mutex           // Mutex pour protéger l'accès concurrentiel aux maps ci-dessus
+	running     atomic.Bool          // Vrai pendant un cycle de vérification
+	cycles      sync.WaitGroup       // Cycle en cours, attendu à l'arrêt du moniteur
 }
 
 // NewUrlMonitor crée et retourne une nouvelle instance de UrlMonitor.
+// Les limites de concurrence et les seuils inférieurs à 1 sont ramenés à 1, un délai nul à 5 secondes,
+// un ensemble de codes de statut vide à DefaultHealthyStatuses, une taille de contenu nulle à DefaultContentMaxBytes.
 // Attention: retourne un pointeur
-func NewUrlMonitor(linkRepo repository.LinkRepository, interval time.Duration) *UrlMonitor {
+func NewUrlMonitor(linkRepo repository.LinkRepository, checkRepo repository.LinkCheckRepository, notifier notify.Notifier, cfg Config) *UrlMonitor {
+	if cfg.Concurrency < 1 {
+		cfg.Concurrency = 1
+	}
+	if cfg.PerHostConcurrency < 1 {
+		cfg.PerHostConcurrency = 1
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 5 * time.Second
+	}
+	if len(cfg.HealthyStatuses.ranges) == 0 {
+		cfg.HealthyStatuses = DefaultHealthyStatuses
+	}
+	cfg.FailureThreshold = max(cfg.FailureThreshold, 1)
+	cfg.RecoveryThreshold = max(cfg.RecoveryThreshold, 1)
+	if cfg.ContentMaxBytes <= 0 {
+		cfg.ContentMaxBytes = DefaultContentMaxBytes
+	}
+
 	return &UrlMonitor{
-		linkRepo:    linkRepo,            // Injecte le repository de liens pour récupérer les URLs à surveiller
-		interval:    interval,            // Définit l'intervalle de vérification
+		linkRepo:    linkRepo, // Injecte le repository de liens pour récupérer les URLs à surveiller
+		checkRepo:   checkRepo,
+		notifier:    notifier,
+		cfg:         cfg,
+		client:      newCheckClient(cfg),
 		knownStates: make(map[uint]bool), // Initialise la map pour stocker les états connus des URLs
-		mu:          sync.Mutex{},        // Initialise le mutex pour protéger l'accès concurrentiel
+		streaks:     make(map[uint]int),
+		reported:    make(map[string]struct{}),
+		tlsWarned:   make(map[uint]time.Time),
+		contents:    make(map[uint]contentHash),
 	}
 }
 
 // Start lance la boucle de surveillance périodique des URLs.
 // Cette fonction est conçue pour être lancée dans une goroutine séparée.
-func (m *UrlMonitor) Start() {
-	log.Printf("[MONITOR] Démarrage du moniteur d'URLs avec un intervalle de %v...", m.interval)
-	ticker := time.NewTicker(m.interval) // Crée un ticker qui envoie un signal à chaque intervalle
-	defer ticker.Stop()                  // S'assure que le ticker est arrêté quand Start se termine
+// Elle se termine quand ctx est annulé, après la fin du cycle en cours.
+func (m *UrlMonitor) Start(ctx context.Context) {
+	log.Printf("[MONITOR] Démarrage du moniteur d'URLs avec un intervalle de %v (%d vérification(s) simultanée(s), %d par hôte)...",
+		m.cfg.Interval, m.cfg.Concurrency, m.cfg.PerHostConcurrency)
+	ticker := time.NewTicker(m.cfg.Interval) // Crée un ticker qui envoie un signal à chaque intervalle
+	defer ticker.Stop()                      // S'assure que le ticker est arrêté quand Start se termine
+	defer m.cycles.Wait()                    // Attend la fin d'un cycle interrompu par l'arrêt
+
+	// Reprend l'état enregistré lors de l'exécution précédente, pour signaler un changement
+	// dès la première vérification après un redémarrage.
+	m.loadKnownStates()
 
 	// Exécute une première vérification immédiatement au démarrage
-	m.checkUrls()
+	m.startCycle(ctx)
 
 	// Boucle principale du moniteur, déclenchée par le ticker
-	for range ticker.C {
-		m.checkUrls()
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("[MONITOR] Arrêt du moniteur d'URLs.")
+			return
+		case <-ticker.C:
+			m.startCycle(ctx)
+		}
 	}
 }
 
+// startCycle lance un cycle de vérification en arrière-plan, sauf si le précédent n'est pas terminé :
+// le tick est alors ignoré pour que les cycles ne se chevauchent pas.
+func (m *UrlMonitor) startCycle(ctx context.Context) {
+	if !m.running.CompareAndSwap(false, true) {
+		metrics.MonitorCyclesSkipped.Inc()
+		log.Println("[MONITOR] Le cycle précédent est toujours en cours, vérification ignorée.")
+		return
+	}
+	m.cycles.Add(1)
+	go func() {
+		defer m.cycles.Done()
+		defer m.running.Store(false)
+		m.checkUrls(ctx)
+	}()
+}
+
 // checkUrls effectue une vérification de l'état de toutes les URLs longues enregistrées.
-func (m *UrlMonitor) checkUrls() {
+// Les vérifications sont réparties entre cfg.Concurrency goroutines, sans dépasser cfg.PerHostConcurrency
+// requêtes simultanées vers un même hôte. Elle s'interrompt si ctx est annulé.
+func (m *UrlMonitor) checkUrls(ctx context.Context) {
 	log.Println("[MONITOR] Lancement de la vérification de l'état des URLs...")
+	start := time.Now()
 
 	// Gérer l'erreur si la récupération échoue.
 	// Si erreur : log.Printf("[MONITOR] ERREUR lors de la récupération des liens pour la surveillance : %v", err)
-	links, err := m.linkRepo.GetAllLinks()
+	// Le moniteur surveille les liens de tous les espaces de travail.
+	links, err := m.linkRepo.GetAllLinks(repository.AllLinks)
 	if err != nil {
 		log.Printf("[MONITOR] ERREUR lors de la récupération des liens pour la surveillance : %v", err)
 		return // Sort de la fonction si une erreur se produit
 	}
 
-	for _, link := range links {
-		currentState := m.isUrlAccessible(link.LongURL)
-		if currentState {
-			log.Printf("[MONITOR] L'URL %s (%s) est ACCESSIBLE",
-				link.Shortcode, link.LongURL)
-		} else {
-			log.Printf("[MONITOR] L'URL %s (%s) est INACCESSIBLE",
-				link.Shortcode, link.LongURL)
+	hosts := newHostLimiter(m.cfg.PerHostConcurrency)
+	jobs := make(chan models.Link)
+	var wg sync.WaitGroup
+	for i := 0; i < m.cfg.Concurrency && i < len(links); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for link := range jobs {
+				m.checkLink(ctx, hosts, link)
+			}
+		}()
+	}
+
+	// Les liens sont distribués en alternant les hôtes, pour que les workers ne restent pas
+	// tous bloqués sur la limite d'un hôte qui a beaucoup de liens.
+	seen := make(map[string]struct{}, len(links))
+dispatch:
+	for _, link := range interleaveByHost(links) {
+		select {
+		case jobs <- link:
+			seen[link.Shortcode] = struct{}{}
+		case <-ctx.Done():
+			break dispatch
 		}
+	}
+	close(jobs)
+	wg.Wait()
 
-		// Protéger l'accès à la map 'knownStates' car 'checkUrls' peut être exécuté concurremment
-		m.mu.Lock()
-		previousState, exists := m.knownStates[link.ID] // Récupère l'état précédent
-		m.knownStates[link.ID] = currentState           // Met à jour l'état actuel
-		m.mu.Unlock()
-
-		// Si c'est la première vérification pour ce lien, on initialise l'état sans notifier.
-		if !exists {
-			log.Printf("[MONITOR] État initial pour le lien %s (%s) : %s",
-				link.Shortcode, link.LongURL, formatState(currentState))
-			continue
+	duration := time.Since(start)
+	metrics.MonitorCycleDuration.Set(duration.Seconds())
+	if ctx.Err() != nil {
+		log.Printf("[MONITOR] Vérification interrompue par l'arrêt du moniteur après %v.", duration.Round(time.Millisecond))
+		return
+	}
+	m.forgetRemovedLinks(seen)
+	m.purgeHistory()
+	log.Printf("[MONITOR] Vérification de l'état de %d URL(s) terminée en %v.", len(links), duration.Round(time.Millisecond))
+}
+
+// checkLink vérifie l'état d'un lien, dans la limite de concurrence de son hôte, puis enregistre le résultat
+// et signale un changement d'état, l'expiration prochaine du certificat TLS ou un changement de contenu.
+func (m *UrlMonitor) checkLink(ctx context.Context, hosts *hostLimiter, link models.Link) {
+	release, ok := hosts.acquire(ctx, hostOf(link.LongURL))
+	if !ok {
+		return
+	}
+	checkedAt := time.Now().UTC()
+	result := m.checkURL(ctx, link.LongURL)
+	release()
+	// Une requête annulée par l'arrêt ne dit rien de l'état de l'URL.
+	if ctx.Err() != nil {
+		return
+	}
+
+	// Protéger l'accès aux maps car les liens sont vérifiés en parallèle.
+	// L'état déclaré ne change qu'après cfg.FailureThreshold échecs ou cfg.RecoveryThreshold succès
+	// consécutifs, pour qu'une erreur passagère ne soit pas signalée.
+	m.mu.Lock()
+	previousState, exists := m.knownStates[link.ID] // Récupère l'état précédent
+	currentState := previousState
+	streak := 0
+	switch {
+	case !exists || result.Up == previousState:
+		currentState = result.Up
+		delete(m.streaks, link.ID)
+	default:
+		streak = m.streaks[link.ID] + 1
+		if streak >= m.threshold(result.Up) {
+			currentState = result.Up
+			streak = 0
+			delete(m.streaks, link.ID)
+		} else {
+			m.streaks[link.ID] = streak
 		}
+	}
+	m.knownStates[link.ID] = currentState // Met à jour l'état actuel
+	m.reported[link.Shortcode] = struct{}{}
+
+	// Un certificat n'est signalé qu'une fois, sauf s'il est remplacé par un autre qui expire aussi bientôt.
+	warnTLS := result.TLSExpiresAt != nil && m.cfg.TLSWarning > 0 &&
+		result.TLSExpiresAt.Sub(checkedAt) <= m.cfg.TLSWarning && !m.tlsWarned[link.ID].Equal(*result.TLSExpiresAt)
+	if warnTLS {
+		m.tlsWarned[link.ID] = *result.TLSExpiresAt
+	}
+	// Une vérification sans empreinte (URL inaccessible) ne remplace pas la dernière empreinte connue.
+	previousContent := m.contents[link.ID]
+	if result.ContentHash != "" {
+		m.contents[link.ID] = contentHash{url: link.LongURL, hash: result.ContentHash}
+	}
+	m.mu.Unlock()
 
-		// Si l'état a changé, générer une fausse notification dans les logs.
-		// log.Printf("[NOTIFICATION] Le lien %s (%s) est passé de %s à %s !"
-		if currentState != previousState {
-			log.Printf("[NOTIFICATION] Le lien %s (%s) est passé de %s à %s !",
-				link.Shortcode, link.LongURL,
-				formatState(previousState), formatState(currentState))
+	err := m.checkRepo.CreateLinkCheck(&models.LinkCheck{
+		LinkID:       link.ID,
+		CheckedAt:    checkedAt,
+		Up:           result.Up,
+		StateUp:      currentState,
+		StatusCode:   result.StatusCode,
+		LatencyMs:    result.Latency.Milliseconds(),
+		ErrorClass:   result.ErrorClass,
+		FinalURL:     result.FinalURL,
+		Redirects:    result.Redirects,
+		TLSExpiresAt: result.TLSExpiresAt,
+		ContentHash:  result.ContentHash,
+	})
+	if err != nil {
+		log.Printf("[MONITOR] ERREUR lors de l'enregistrement de la vérification de %s : %v", link.Shortcode, err)
+	}
+
+	recordState(link.Shortcode, currentState, result.Up)
+	if result.Up {
+		log.Printf("[MONITOR] L'URL %s (%s) est ACCESSIBLE",
+			link.Shortcode, link.LongURL)
+	} else {
+		log.Printf("[MONITOR] L'URL %s (%s) est INACCESSIBLE",
+			link.Shortcode, link.LongURL)
+	}
+	if result.FinalURL != "" {
+		log.Printf("[MONITOR] L'URL %s a été redirigée %d fois vers %s",
+			link.Shortcode, len(result.Redirects), result.FinalURL)
+	}
+	if streak > 0 {
+		log.Printf("[MONITOR] Vérification %d/%d avant de déclarer le lien %s %s",
+			streak, m.threshold(result.Up), link.Shortcode, formatState(result.Up))
+	}
+
+	if warnTLS {
+		log.Printf("[NOTIFICATION] Le certificat TLS du lien %s (%s) expire le %s !",
+			link.Shortcode, link.LongURL, result.TLSExpiresAt.Format(time.RFC3339))
+		m.notify(ctx, link, notify.NewTLSExpiringEvent(link, *result.TLSExpiresAt, result.StatusCode, checkedAt))
+	}
+	if previousContent.changedTo(link.LongURL, result.ContentHash) {
+		log.Printf("[NOTIFICATION] Le contenu du lien %s (%s) a changé !", link.Shortcode, link.LongURL)
+		m.notify(ctx, link, notify.NewContentChangedEvent(link, previousContent.hash, result.ContentHash, result.StatusCode, checkedAt))
+	}
+
+	// Si c'est la première vérification pour ce lien, on initialise l'état sans notifier.
+	if !exists {
+		log.Printf("[MONITOR] État initial pour le lien %s (%s) : %s",
+			link.Shortcode, link.LongURL, formatState(currentState))
+		return
+	}
+
+	// Si l'état a changé, le signaler dans les logs et sur les canaux de notification.
+	if currentState != previousState {
+		log.Printf("[NOTIFICATION] Le lien %s (%s) est passé de %s à %s !",
+			link.Shortcode, link.LongURL,
+			formatState(previousState), formatState(currentState))
+		m.notify(ctx, link, notify.NewStateChangeEvent(link, currentState, result.StatusCode, result.ErrorClass, checkedAt))
+	}
+}
+
+// contentHash est la dernière empreinte du contenu d'un lien et l'URL longue dont elle provient.
+// L'URL est vide pour une empreinte chargée depuis l'historique.
+type contentHash struct {
+	url  string
+	hash string
+}
+
+// changedTo indique si 'hash', l'empreinte du contenu de 'url', diffère de l'empreinte précédente.
+// Un changement d'URL longue n'est pas un changement de contenu.
+func (c contentHash) changedTo(url, hash string) bool {
+	if c.hash == "" || hash == "" || (c.url != "" && c.url != url) {
+		return false
+	}
+	return hash != c.hash
+}
+
+// notify transmet un événement aux canaux de notification, s'il y en a.
+func (m *UrlMonitor) notify(ctx context.Context, link models.Link, event notify.Event) {
+	if m.notifier == nil {
+		return
+	}
+	if err := m.notifier.Notify(ctx, event); err != nil {
+		log.Printf("[MONITOR] ERREUR lors de l'envoi de la notification pour %s : %v", link.Shortcode, err)
+	}
+}
+
+// IsDown indique si l'URL longue d'un lien est actuellement déclarée INACCESSIBLE.
+// Un lien qui n'a pas encore été vérifié n'est pas considéré comme inaccessible.
+func (m *UrlMonitor) IsDown(linkID uint) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	up, known := m.knownStates[linkID]
+	return known && !up
+}
+
+// threshold retourne le nombre de vérifications consécutives nécessaires pour déclarer l'état 'up'.
+func (m *UrlMonitor) threshold(up bool) int {
+	if up {
+		return m.cfg.RecoveryThreshold
+	}
+	return m.cfg.FailureThreshold
+}
+
+// recordState publie dans les métriques l'état déclaré d'un lien et le résultat de sa dernière vérification.
+func recordState(shortCode string, state, checkUp bool) {
+	if state {
+		metrics.MonitorLinkUp.WithLabelValues(shortCode).Set(1)
+	} else {
+		metrics.MonitorLinkUp.WithLabelValues(shortCode).Set(0)
+	}
+	if checkUp {
+		metrics.MonitorChecksTotal.WithLabelValues("up").Inc()
+	} else {
+		metrics.MonitorChecksTotal.WithLabelValues("down").Inc()
+	}
+}
+
+// forgetRemovedLinks retire des métriques l'état des liens qui n'ont pas été vérifiés
+// lors d'un cycle complet, c'est-à-dire les liens supprimés depuis.
+func (m *UrlMonitor) forgetRemovedLinks(seen map[string]struct{}) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for shortCode := range m.reported {
+		if _, ok := seen[shortCode]; !ok {
+			metrics.MonitorLinkUp.DeleteLabelValues(shortCode)
+			delete(m.reported, shortCode)
 		}
 	}
-	log.Println("[MONITOR] Vérification de l'état des URLs terminée.")
 }
 
-// isUrlAccessible effectue une requête HTTP HEAD pour vérifier l'accessibilité d'une URL.
-func (m *UrlMonitor) isUrlAccessible(url string) bool {
-	client := &http.Client{
-		Timeout: 5 * time.Second, // Timeout de 5 secondes pour la requête HTTP
+// loadKnownStates initialise knownStates avec l'état déclaré lors de la dernière vérification de chaque lien,
+// streaks avec les vérifications consécutives qui le contredisent déjà, contents avec la dernière empreinte
+// du contenu et tlsWarned avec les certificats qui expiraient déjà bientôt, donc déjà signalés.
+// En cas d'erreur, le moniteur démarre sans état connu, comme lors de sa première exécution.
+func (m *UrlMonitor) loadKnownStates() {
+	checks, err := m.checkRepo.GetLatestChecks()
+	if err != nil {
+		log.Printf("[MONITOR] ERREUR lors du chargement des derniers états connus : %v", err)
+		return
+	}
+	streaks, err := m.checkRepo.GetPendingStreaks()
+	if err != nil {
+		log.Printf("[MONITOR] ERREUR lors du chargement des vérifications en cours : %v", err)
+		streaks = nil
 	}
-	// Un code de statut 2xx ou 3xx indique que l'URL est accessible.
-	// Si err : log.Printf("[MONITOR] Erreur d'accès à l'URL '%s': %v", url, err)
-	resp, err := client.Head(url)
+	contents, err := m.checkRepo.GetLatestContentHashes()
 	if err != nil {
-		log.Printf("[MONITOR] Erreur d'accès à l'URL '%s': %v", url, err)
-		return false // Si une erreur se produit, on considère l'URL comme inaccessible
+		log.Printf("[MONITOR] ERREUR lors du chargement des empreintes de contenu : %v", err)
+		contents = nil
 	}
+	m.mu.Lock()
+	for _, check := range checks {
+		m.knownStates[check.LinkID] = check.StateUp
+		if check.TLSExpiresAt != nil && m.cfg.TLSWarning > 0 && check.TLSExpiresAt.Sub(check.CheckedAt) <= m.cfg.TLSWarning {
+			m.tlsWarned[check.LinkID] = *check.TLSExpiresAt
+		}
+	}
+	for linkID, streak := range streaks {
+		m.streaks[linkID] = streak
+	}
+	for linkID, hash := range contents {
+		m.contents[linkID] = contentHash{hash: hash}
+	}
+	m.mu.Unlock()
+	log.Printf("[MONITOR] État connu de %d lien(s) chargé depuis l'historique.", len(checks))
+}
 
-	defer resp.Body.Close() // Assurez-vous de fermer le corps de la réponse pour libérer les ressources
-	log.Printf("[MONITOR] Requête HEAD pour l'URL '%s' a renvoyé le code de statut %d", url, resp.StatusCode)
-	// Déterminer l'accessibilité basée sur le code de statut HTTP.
-	return resp.StatusCode >= 200 && resp.StatusCode < 400 // Codes 2xx ou 3xx
+// purgeHistory supprime les vérifications plus anciennes que cfg.HistoryRetention.
+func (m *UrlMonitor) purgeHistory() {
+	if m.cfg.HistoryRetention <= 0 {
+		return
+	}
+	deleted, err := m.checkRepo.DeleteChecksBefore(time.Now().UTC().Add(-m.cfg.HistoryRetention))
+	if err != nil {
+		log.Printf("[MONITOR] ERREUR lors de la purge de l'historique des vérifications : %v", err)
+		return
+	}
+	if deleted > 0 {
+		log.Printf("[MONITOR] %d vérification(s) ancienne(s) supprimée(s) de l'historique.", deleted)
+	}
 }
 
 // formatState est une fonction utilitaire pour rendre l'état plus lisible dans les logs.
@@ -0,0 +1,94 @@
+package notify
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/config"
+)
+
+// Types de canaux de notification configurables.
+const (
+	ChannelWebhook = "webhook"
+	ChannelSlack   = "slack"
+	ChannelEmail   = "email"
+)
+
+// NewDispatcherFromConfig crée le Dispatcher des canaux de la section 'notifications' de la configuration.
+// Il renvoie une erreur si un canal est incomplet ou d'un type inconnu.
+func NewDispatcherFromConfig(cfg *config.Config) (*Dispatcher, error) {
+	timeout := time.Duration(cfg.Notifications.TimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	retry := retryPolicy{
+		MaxAttempts: max(cfg.Notifications.MaxAttempts, 1),
+		Delay:       time.Duration(cfg.Notifications.RetryDelaySeconds) * time.Second,
+	}
+	client := &http.Client{Timeout: timeout}
+
+	names := make(map[string]struct{}, len(cfg.Notifications.Channels))
+	subscriptions := make([]Subscription, 0, len(cfg.Notifications.Channels))
+	for i, channel := range cfg.Notifications.Channels {
+		name := channel.Name
+		if name == "" {
+			name = fmt.Sprintf("%s-%d", channel.Type, i+1)
+		}
+		if _, taken := names[name]; taken {
+			return nil, fmt.Errorf("notification channel '%s' is defined twice", name)
+		}
+		names[name] = struct{}{}
+
+		var notifier Notifier
+		switch channel.Type {
+		case ChannelWebhook, ChannelSlack:
+			if err := validateHTTPURL(channel.URL); err != nil {
+				return nil, fmt.Errorf("notification channel '%s': %w", name, err)
+			}
+			if channel.Type == ChannelWebhook {
+				notifier = NewWebhookNotifier(channel.URL, channel.Secret, client, retry)
+			} else {
+				notifier = NewSlackNotifier(channel.URL, client, retry)
+			}
+		case ChannelEmail:
+			if channel.From == "" || len(channel.To) == 0 {
+				return nil, fmt.Errorf("notification channel '%s': 'from' and 'to' are required", name)
+			}
+			emailNotifier, err := NewEmailNotifier(channel.SMTPAddr, channel.Username, channel.Password,
+				channel.From, channel.To, timeout, retry)
+			if err != nil {
+				return nil, fmt.Errorf("notification channel '%s': %w", name, err)
+			}
+			notifier = emailNotifier
+		default:
+			return nil, fmt.Errorf("notification channel '%s': unknown type '%s' (use %s, %s or %s)",
+				name, channel.Type, ChannelWebhook, ChannelSlack, ChannelEmail)
+		}
+
+		subscription := Subscription{
+			Name:       name,
+			Notifier:   notifier,
+			ShortCodes: make(map[string]struct{}, len(channel.Links)),
+			Workspaces: make(map[uint]struct{}, len(channel.Workspaces)),
+		}
+		for _, shortCode := range channel.Links {
+			subscription.ShortCodes[shortCode] = struct{}{}
+		}
+		for _, workspaceID := range channel.Workspaces {
+			subscription.Workspaces[workspaceID] = struct{}{}
+		}
+		subscriptions = append(subscriptions, subscription)
+	}
+	return NewDispatcher(subscriptions), nil
+}
+
+// validateHTTPURL vérifie qu'une URL de canal est une URL absolue http ou https.
+func validateHTTPURL(raw string) error {
+	parsed, err := url.Parse(raw)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("'url' must be an absolute http or https URL (got '%s')", raw)
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+// EmailNotifier envoie les événements par e-mail via un serveur SMTP.
+// La connexion passe en TLS si le serveur propose STARTTLS ; l'authentification n'est utilisée
+// que si un identifiant est configuré. Un serveur SMTP local de test (MailHog, Mailpit...)
+// fonctionne donc sans autre réglage.
+type EmailNotifier struct {
+	addr    string // hôte:port du serveur SMTP
+	host    string
+	auth    smtp.Auth
+	from    string
+	to      []string
+	timeout time.Duration // Délai maximal d'une tentative d'envoi
+	retry   retryPolicy
+}
+
+// NewEmailNotifier crée un EmailNotifier pour le serveur SMTP 'addr' (hôte:port).
+func NewEmailNotifier(addr, username, password, from string, to []string, timeout time.Duration, retry retryPolicy) (*EmailNotifier, error) {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SMTP address '%s': %w", addr, err)
+	}
+	n := &EmailNotifier{addr: addr, host: host, from: from, to: to, timeout: timeout, retry: retry}
+	if username != "" {
+		n.auth = smtp.PlainAuth("", username, password, host)
+	}
+	return n, nil
+}
+
+// Notify envoie le résumé de l'événement à tous les destinataires. Un refus définitif du serveur
+// (code SMTP 5xx) n'est pas retenté.
+func (n *EmailNotifier) Notify(ctx context.Context, event Event) error {
+	state := "INACCESSIBLE"
+	switch {
+	case event.Type == EventTLSExpiring:
+		state = "certificat TLS bientôt expiré"
+	case event.Type == EventContentChanged:
+		state = "contenu modifié"
+	case event.Up:
+		state = "ACCESSIBLE"
+	}
+	subject := fmt.Sprintf("[url-shortener] Lien %s %s", event.ShortCode, state)
+
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", n.from)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(n.to, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&msg, "Date: %s\r\n", event.OccurredAt.Format(time.RFC1123Z))
+	fmt.Fprintf(&msg, "Message-ID: <%s@urlshortener>\r\n", event.ID)
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	msg.WriteString("Content-Transfer-Encoding: 8bit\r\n\r\n")
+	fmt.Fprintf(&msg, "%s\r\n\r\nDate de la vérification : %s\r\n",
+		event.Summary(), event.OccurredAt.Format(time.RFC3339))
+
+	return n.retry.do(ctx, func(ctx context.Context) error {
+		err := n.send(ctx, msg.Bytes())
+		var protoErr *textproto.Error
+		if errors.As(err, &protoErr) && protoErr.Code >= 500 {
+			return permanentError{err}
+		}
+		return err
+	})
+}
+
+// send transmet un message au serveur SMTP, en une seule connexion limitée à n.timeout.
+func (n *EmailNotifier) send(ctx context.Context, msg []byte) error {
+	dialer := net.Dialer{Timeout: n.timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", n.addr)
+	if err != nil {
+		return err
+	}
+	if err := conn.SetDeadline(time.Now().Add(n.timeout)); err != nil {
+		conn.Close()
+		return err
+	}
+	client, err := smtp.NewClient(conn, n.host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: n.host}); err != nil {
+			return err
+		}
+	}
+	if n.auth != nil {
+		if err := client.Auth(n.auth); err != nil {
+			return err
+		}
+	}
+	if err := client.Mail(n.from); err != nil {
+		return err
+	}
+	for _, to := range n.to {
+		if err := client.Rcpt(to); err != nil {
+			return err
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
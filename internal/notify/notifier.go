@@ -0,0 +1,224 @@
+package notify
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/metrics"
+	"github.com/axellelanca/urlshortener/internal/models"
+)
+
+// Types des événements envoyés par le moniteur d'URLs.
+const (
+	EventLinkDown       = "link.down"            // L'URL longue d'un lien est devenue inaccessible
+	EventLinkUp         = "link.up"              // L'URL longue d'un lien est de nouveau accessible
+	EventTLSExpiring    = "link.tls_expiring"    // Le certificat TLS de l'URL longue d'un lien expire bientôt
+	EventContentChanged = "link.content_changed" // Le contenu de l'URL longue d'un lien a changé
+)
+
+// Event décrit un changement constaté par le moniteur sur l'URL longue d'un lien.
+type Event struct {
+	ID          string // Identifiant aléatoire, identique pour toutes les tentatives d'envoi
+	Type        string // Une des constantes Event*
+	OccurredAt  time.Time
+	LinkID      uint
+	ShortCode   string
+	LongURL     string
+	WorkspaceID *uint
+	Up          bool   // Nouvel état (EventLinkDown, EventLinkUp)
+	StatusCode  int    // Code de statut de la vérification, 0 si aucune réponse n'a été reçue
+	ErrorClass  string // Classe d'erreur de la vérification (models.CheckError*)
+	// TLSExpiresAt est la date d'expiration du certificat (EventTLSExpiring).
+	TLSExpiresAt *time.Time
+	// ContentHash et PreviousContentHash sont la nouvelle et l'ancienne empreinte du contenu (EventContentChanged).
+	ContentHash         string
+	PreviousContentHash string
+}
+
+// newEvent construit un événement de type 'eventType' pour 'link', avec un identifiant aléatoire.
+func newEvent(eventType string, link models.Link, at time.Time) Event {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		// Sans identifiant aléatoire, les destinataires peuvent toujours dédoublonner sur le lien et la date.
+		log.Printf("WARNING: could not generate notification ID: %v", err)
+	}
+	return Event{
+		ID:          hex.EncodeToString(id),
+		Type:        eventType,
+		OccurredAt:  at,
+		LinkID:      link.ID,
+		ShortCode:   link.Shortcode,
+		LongURL:     link.LongURL,
+		WorkspaceID: link.WorkspaceID,
+	}
+}
+
+// NewStateChangeEvent construit l'événement du passage de l'URL longue de 'link' à l'état 'up'.
+func NewStateChangeEvent(link models.Link, up bool, statusCode int, errorClass string, at time.Time) Event {
+	eventType := EventLinkDown
+	if up {
+		eventType = EventLinkUp
+	}
+	event := newEvent(eventType, link, at)
+	event.Up = up
+	event.StatusCode = statusCode
+	event.ErrorClass = errorClass
+	return event
+}
+
+// NewTLSExpiringEvent construit l'événement de l'expiration prochaine, à 'expiresAt', du certificat TLS
+// de l'URL longue de 'link'.
+func NewTLSExpiringEvent(link models.Link, expiresAt time.Time, statusCode int, at time.Time) Event {
+	event := newEvent(EventTLSExpiring, link, at)
+	event.Up = true
+	event.StatusCode = statusCode
+	event.TLSExpiresAt = &expiresAt
+	return event
+}
+
+// NewContentChangedEvent construit l'événement du changement de l'empreinte du contenu de l'URL longue de 'link'.
+func NewContentChangedEvent(link models.Link, previousHash, hash string, statusCode int, at time.Time) Event {
+	event := newEvent(EventContentChanged, link, at)
+	event.Up = true
+	event.StatusCode = statusCode
+	event.ContentHash = hash
+	event.PreviousContentHash = previousHash
+	return event
+}
+
+// Summary décrit l'événement en une phrase, pour les canaux destinés à être lus (Slack, e-mail).
+func (e Event) Summary() string {
+	switch e.Type {
+	case EventTLSExpiring:
+		days := int(e.TLSExpiresAt.Sub(e.OccurredAt).Hours() / 24)
+		return fmt.Sprintf("Le certificat TLS du lien %s (%s) expire le %s (dans %d jour(s)).",
+			e.ShortCode, e.LongURL, e.TLSExpiresAt.Format(time.DateOnly), days)
+	case EventContentChanged:
+		return fmt.Sprintf("Le contenu du lien %s (%s) a changé (empreinte %.12s, précédente %.12s).",
+			e.ShortCode, e.LongURL, e.ContentHash, e.PreviousContentHash)
+	}
+	if e.Up {
+		return fmt.Sprintf("Le lien %s (%s) est passé de INACCESSIBLE à ACCESSIBLE.", e.ShortCode, e.LongURL)
+	}
+	cause := e.ErrorClass
+	if e.StatusCode != 0 {
+		cause = fmt.Sprintf("code de statut %d", e.StatusCode)
+	}
+	return fmt.Sprintf("Le lien %s (%s) est passé de ACCESSIBLE à INACCESSIBLE (%s).", e.ShortCode, e.LongURL, cause)
+}
+
+// Notifier envoie un événement sur un canal de notification.
+type Notifier interface {
+	Notify(ctx context.Context, event Event) error
+}
+
+// Subscription associe un canal de notification aux liens dont il reçoit les événements.
+// Sans code court ni espace de travail, le canal reçoit les événements de tous les liens.
+type Subscription struct {
+	Name       string // Nom du canal, utilisé dans les logs et les métriques
+	Notifier   Notifier
+	ShortCodes map[string]struct{} // Liens suivis individuellement
+	Workspaces map[uint]struct{}   // Espaces de travail dont tous les liens sont suivis
+}
+
+// matches indique si le canal est abonné au lien de l'événement.
+func (s Subscription) matches(event Event) bool {
+	if len(s.ShortCodes) == 0 && len(s.Workspaces) == 0 {
+		return true
+	}
+	if _, ok := s.ShortCodes[event.ShortCode]; ok {
+		return true
+	}
+	if event.WorkspaceID != nil {
+		if _, ok := s.Workspaces[*event.WorkspaceID]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Dispatcher est le Notifier du moniteur : il transmet chaque événement aux canaux abonnés.
+// Les envois, qui peuvent être retentés pendant plusieurs secondes, se font en arrière-plan
+// pour ne pas ralentir les vérifications.
+type Dispatcher struct {
+	subscriptions []Subscription
+	pending       sync.WaitGroup
+}
+
+// NewDispatcher crée un Dispatcher pour les abonnements donnés.
+func NewDispatcher(subscriptions []Subscription) *Dispatcher {
+	return &Dispatcher{subscriptions: subscriptions}
+}
+
+// Len retourne le nombre de canaux configurés.
+func (d *Dispatcher) Len() int {
+	return len(d.subscriptions)
+}
+
+// Notify lance l'envoi de l'événement à chaque canal abonné et retourne sans attendre.
+// Les envois ne sont pas interrompus par l'annulation de ctx : l'arrêt du moniteur ne doit pas
+// faire perdre une notification déjà décidée. Wait attend leur fin.
+func (d *Dispatcher) Notify(ctx context.Context, event Event) error {
+	ctx = context.WithoutCancel(ctx)
+	for _, subscription := range d.subscriptions {
+		if !subscription.matches(event) {
+			continue
+		}
+		d.pending.Add(1)
+		go func() {
+			defer d.pending.Done()
+			if err := subscription.Notifier.Notify(ctx, event); err != nil {
+				metrics.NotificationsTotal.WithLabelValues(subscription.Name, "failed").Inc()
+				log.Printf("ERROR: Failed to send notification %s for %s on channel '%s': %v",
+					event.Type, event.ShortCode, subscription.Name, err)
+				return
+			}
+			metrics.NotificationsTotal.WithLabelValues(subscription.Name, "sent").Inc()
+		}()
+	}
+	return nil
+}
+
+// Wait attend la fin des envois en cours.
+func (d *Dispatcher) Wait() {
+	d.pending.Wait()
+}
+
+// retryPolicy décrit les nouvelles tentatives d'un envoi en échec.
+type retryPolicy struct {
+	MaxAttempts int           // Nombre total de tentatives, au moins 1
+	Delay       time.Duration // Attente avant la deuxième tentative, doublée à chaque tentative suivante
+}
+
+// permanentError signale un échec qu'une nouvelle tentative ne corrigerait pas (requête refusée par le destinataire).
+type permanentError struct {
+	err error
+}
+
+func (e permanentError) Error() string { return e.err.Error() }
+func (e permanentError) Unwrap() error { return e.err }
+
+// do appelle 'attempt' jusqu'à ce qu'il réussisse, retourne une permanentError ou que les tentatives soient épuisées.
+func (p retryPolicy) do(ctx context.Context, attempt func(ctx context.Context) error) error {
+	delay := p.Delay
+	for i := 1; ; i++ {
+		err := attempt(ctx)
+		if err == nil {
+			return nil
+		}
+		if _, permanent := err.(permanentError); permanent || i >= p.MaxAttempts {
+			return fmt.Errorf("giving up after %d attempt(s): %w", i, err)
+		}
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		delay *= 2
+	}
+}
@@ -0,0 +1,38 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+)
+
+// SlackNotifier envoie les événements à un webhook entrant Slack, ou à tout service qui accepte
+// le même format (Mattermost, Rocket.Chat...).
+type SlackNotifier struct {
+	url    string
+	client *http.Client
+	retry  retryPolicy
+}
+
+// NewSlackNotifier crée un SlackNotifier pour l'URL d'un webhook entrant.
+func NewSlackNotifier(url string, client *http.Client, retry retryPolicy) *SlackNotifier {
+	return &SlackNotifier{url: url, client: client, retry: retry}
+}
+
+// Notify publie le résumé de l'événement, en retentant après une erreur réseau, une réponse 429 ou 5xx.
+func (n *SlackNotifier) Notify(ctx context.Context, event Event) error {
+	icon := ":red_circle:"
+	switch {
+	case event.Type == EventTLSExpiring, event.Type == EventContentChanged:
+		icon = ":warning:"
+	case event.Up:
+		icon = ":large_green_circle:"
+	}
+	body, err := json.Marshal(map[string]string{"text": icon + " " + event.Summary()})
+	if err != nil {
+		return err
+	}
+	return n.retry.do(ctx, func(ctx context.Context) error {
+		return postJSON(ctx, n.client, n.url, body, nil)
+	})
+}
@@ -0,0 +1,140 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// En-têtes des requêtes du WebhookNotifier.
+const (
+	HeaderEvent     = "X-Webhook-Event"     // Type de l'événement
+	HeaderID        = "X-Webhook-ID"        // Identifiant de l'événement, pour ignorer les doublons après une nouvelle tentative
+	HeaderTimestamp = "X-Webhook-Timestamp" // Date d'envoi de la tentative, en secondes Unix
+	HeaderSignature = "X-Webhook-Signature" // "sha256=" suivi du HMAC-SHA256 hexadécimal de "<timestamp>.<corps>"
+)
+
+// webhookPayload est le corps JSON envoyé par le WebhookNotifier.
+type webhookPayload struct {
+	ID            string      `json:"id"`
+	Type          string      `json:"type"`
+	OccurredAt    time.Time   `json:"occurred_at"`
+	Link          webhookLink `json:"link"`
+	State         string      `json:"state"`
+	PreviousState string      `json:"previous_state,omitempty"` // Seulement pour link.down et link.up
+	StatusCode    int         `json:"status_code"`
+	ErrorClass    string      `json:"error_class"`
+	// Champs propres à link.tls_expiring et link.content_changed
+	TLSExpiresAt        *time.Time `json:"tls_expires_at,omitempty"`
+	ContentHash         string     `json:"content_hash,omitempty"`
+	PreviousContentHash string     `json:"previous_content_hash,omitempty"`
+}
+
+type webhookLink struct {
+	ID          uint   `json:"id"`
+	ShortCode   string `json:"short_code"`
+	LongURL     string `json:"long_url"`
+	WorkspaceID *uint  `json:"workspace_id"`
+}
+
+// WebhookNotifier envoie les événements en JSON par une requête POST vers une URL.
+// Si un secret est configuré, chaque requête est signée (HeaderSignature) pour que le destinataire
+// puisse vérifier son origine ; il doit aussi refuser les horodatages trop anciens.
+type WebhookNotifier struct {
+	url    string
+	secret []byte
+	client *http.Client
+	retry  retryPolicy
+}
+
+// NewWebhookNotifier crée un WebhookNotifier. Un secret vide désactive la signature.
+func NewWebhookNotifier(url, secret string, client *http.Client, retry retryPolicy) *WebhookNotifier {
+	return &WebhookNotifier{url: url, secret: []byte(secret), client: client, retry: retry}
+}
+
+// Notify envoie l'événement, en retentant après une erreur réseau, une réponse 429 ou 5xx.
+func (n *WebhookNotifier) Notify(ctx context.Context, event Event) error {
+	state, previous := "down", "up"
+	if event.Up {
+		state, previous = "up", "down"
+	}
+	if event.Type != EventLinkDown && event.Type != EventLinkUp {
+		previous = ""
+	}
+	body, err := json.Marshal(webhookPayload{
+		ID:                  event.ID,
+		Type:                event.Type,
+		OccurredAt:          event.OccurredAt,
+		Link:                webhookLink{ID: event.LinkID, ShortCode: event.ShortCode, LongURL: event.LongURL, WorkspaceID: event.WorkspaceID},
+		State:               state,
+		PreviousState:       previous,
+		StatusCode:          event.StatusCode,
+		ErrorClass:          event.ErrorClass,
+		TLSExpiresAt:        event.TLSExpiresAt,
+		ContentHash:         event.ContentHash,
+		PreviousContentHash: event.PreviousContentHash,
+	})
+	if err != nil {
+		return err
+	}
+
+	return n.retry.do(ctx, func(ctx context.Context) error {
+		return postJSON(ctx, n.client, n.url, body, func(req *http.Request) {
+			req.Header.Set(HeaderEvent, event.Type)
+			req.Header.Set(HeaderID, event.ID)
+			if len(n.secret) > 0 {
+				timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+				req.Header.Set(HeaderTimestamp, timestamp)
+				req.Header.Set(HeaderSignature, "sha256="+Sign(n.secret, timestamp, body))
+			}
+		})
+	})
+}
+
+// Sign calcule la signature d'un corps de webhook : le HMAC-SHA256 hexadécimal de "<timestamp>.<corps>".
+func Sign(secret []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// postJSON envoie 'body' en POST vers 'url'. 'prepare' complète les en-têtes de la requête.
+// Une réponse 4xx autre que 429 est une permanentError : la requête elle-même est refusée.
+func postJSON(ctx context.Context, client *http.Client, url string, body []byte, prepare func(req *http.Request)) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return permanentError{err}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "urlshortener-monitor")
+	if prepare != nil {
+		prepare(req)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	// Lit (une partie de) la réponse pour que la connexion puisse être réutilisée.
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	err = fmt.Errorf("unexpected response status %d", resp.StatusCode)
+	if resp.StatusCode >= 400 && resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests {
+		return permanentError{err}
+	}
+	return err
+}
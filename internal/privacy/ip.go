@@ -0,0 +1,103 @@
+package privacy
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+)
+
+// Modes de traitement des adresses IP avant leur persistance.
+const (
+	IPModeNone     = "none"     // Adresse conservée telle quelle
+	IPModeTruncate = "truncate" // Adresse ramenée à son réseau : /24 en IPv4, /48 en IPv6
+	IPModeHash     = "hash"     // Adresse remplacée par un HMAC-SHA256 à clé secrète
+)
+
+// hashHexLength est le nombre de caractères hexadécimaux conservés du HMAC (128 bits),
+// pour tenir dans la colonne 'ip_address'.
+const hashHexLength = 32
+
+// ErrInvalidIPMode est retournée quand le mode de traitement des adresses IP n'est pas reconnu.
+var ErrInvalidIPMode = errors.New("invalid IP mode: use none, truncate or hash")
+
+// ErrMissingHMACSecret est retournée quand le mode hash est demandé sans clé secrète.
+var ErrMissingHMACSecret = errors.New("IP mode 'hash' requires a non-empty HMAC secret")
+
+// IPAnonymizer transforme les adresses IP des clics selon le mode configuré.
+type IPAnonymizer struct {
+	mode   string
+	secret []byte
+}
+
+// NewIPAnonymizer crée un IPAnonymizer pour le mode donné. Un mode vide équivaut à IPModeNone.
+// La clé 'secret' n'est utilisée qu'en mode hash : la changer rend les anciennes empreintes
+// impossibles à rapprocher des nouvelles.
+func NewIPAnonymizer(mode, secret string) (*IPAnonymizer, error) {
+	switch mode {
+	case "":
+		mode = IPModeNone
+	case IPModeNone, IPModeTruncate:
+	case IPModeHash:
+		if secret == "" {
+			return nil, ErrMissingHMACSecret
+		}
+	default:
+		return nil, fmt.Errorf("%w (got '%s')", ErrInvalidIPMode, mode)
+	}
+	return &IPAnonymizer{mode: mode, secret: []byte(secret)}, nil
+}
+
+// Mode retourne le mode de traitement des adresses IP.
+func (a *IPAnonymizer) Mode() string {
+	return a.mode
+}
+
+// Enabled indique si les adresses IP sont transformées avant leur persistance.
+func (a *IPAnonymizer) Enabled() bool {
+	return a.mode != IPModeNone
+}
+
+// Anonymize retourne la forme persistée de l'adresse IP 'ip'.
+// En mode truncate, une valeur qui n'est pas une adresse IP est remplacée par une chaîne vide
+// plutôt que d'être conservée en clair.
+func (a *IPAnonymizer) Anonymize(ip string) string {
+	switch a.mode {
+	case IPModeTruncate:
+		return truncateIP(ip)
+	case IPModeHash:
+		if ip == "" {
+			return ""
+		}
+		mac := hmac.New(sha256.New, a.secret)
+		mac.Write([]byte(ip))
+		return hex.EncodeToString(mac.Sum(nil))[:hashHexLength]
+	default:
+		return ip
+	}
+}
+
+// StoredForms retourne les valeurs sous lesquelles l'adresse 'ip' a pu être enregistrée :
+// en clair (clics antérieurs à l'activation du mode privé) et, en mode hash, sous forme d'empreinte.
+// En mode truncate, l'adresse tronquée est partagée par tout un réseau et n'est pas retournée.
+func (a *IPAnonymizer) StoredForms(ip string) []string {
+	forms := []string{ip}
+	if a.mode == IPModeHash {
+		forms = append(forms, a.Anonymize(ip))
+	}
+	return forms
+}
+
+// truncateIP met à zéro la partie hôte d'une adresse IP.
+func truncateIP(ip string) string {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return ""
+	}
+	if v4 := parsed.To4(); v4 != nil {
+		return v4.Mask(net.CIDRMask(24, 32)).String()
+	}
+	return parsed.Mask(net.CIDRMask(48, 128)).String()
+}
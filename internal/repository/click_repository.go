@@ -1,6 +1,9 @@
 package repository
 
 import (
+	"errors"
+	"time"
+
 	"github.com/axellelanca/urlshortener/internal/models"
 	"gorm.io/gorm"
 )
@@ -10,18 +13,88 @@ import (
 // de rester indépendante de l'implémentation spécifique de la base de données.
 type ClickRepository interface {
 	CreateClick(click *models.Click) error
+	CreateClicks(clicks []models.Click) error     // Insertion groupée utilisée par les workers
 	CountClicksByLinkID(linkID uint) (int, error) // Utilisé par LinkService pour les stats
+	CountClicks(filter ClickFilter) (int, error)
+	CountClicksByTimeSlot(filter ClickFilter, slot time.Duration) ([]models.ClickBucket, error)
+	CountClicksByDimension(filter ClickFilter, dimension ClickDimension, limit int) ([]models.DimensionCount, error)
+	CountClicksWithoutDimension(filter ClickFilter, dimension ClickDimension) (int, error)
+	DeleteClicksBefore(cutoff time.Time) (int64, error)    // Rétention : suppression des clics anciens
+	AggregateClicksBefore(cutoff time.Time) (int64, error) // Rétention : remplacement des clics anciens par des totaux journaliers
+	DeleteClicksByIP(ipAddresses []string) (int64, error)  // Droit à l'effacement
+	ExistingLinkIDs(linkIDs []uint) (map[uint]bool, error) // Rejeu du spool : liens qui existent encore
+}
+
+// ClickDimension est une colonne de la table 'clicks' selon laquelle les clics peuvent être regroupés.
+// Seules les constantes ci-dessous sont acceptées, la valeur étant injectée dans la requête SQL.
+type ClickDimension string
+
+// Dimensions de regroupement des clics.
+const (
+	DimensionReferrer ClickDimension = "referrer"
+	DimensionBrowser  ClickDimension = "browser"
+	DimensionOS       ClickDimension = "os"
+	DimensionDevice   ClickDimension = "device_class"
+	DimensionCountry  ClickDimension = "country"
+	DimensionCity     ClickDimension = "city"
+)
+
+// validDimensions liste les dimensions acceptées par les requêtes de regroupement.
+var validDimensions = map[ClickDimension]bool{
+	DimensionReferrer: true,
+	DimensionBrowser:  true,
+	DimensionOS:       true,
+	DimensionDevice:   true,
+	DimensionCountry:  true,
+	DimensionCity:     true,
+}
+
+// ErrInvalidDimension est retournée quand une dimension de regroupement inconnue est demandée.
+var ErrInvalidDimension = errors.New("invalid click dimension")
+
+// ClickFilter restreint les requêtes d'agrégation aux clics d'un lien sur une période.
+// Les bornes laissées à leur valeur zéro ne filtrent pas.
+type ClickFilter struct {
+	LinkID      uint
+	From        time.Time // Borne incluse
+	To          time.Time // Borne exclue
+	ExcludeBots bool      // Ignore les clics identifiés comme provenant de robots
+	Fallback    bool      // Ne compte que les clics redirigés vers l'URL de secours du lien
+}
+
+// clickEpochExprs convertit la colonne 'timestamp' en secondes Unix, selon le dialecte SQL.
+// SQLite stocke les horodatages sous forme de texte avec leur décalage horaire :
+// la conversion permet de comparer et de regrouper des clics enregistrés dans des fuseaux différents.
+// MySQL interprète les DATETIME dans le fuseau de la session, qui doit être UTC (loc=UTC dans le DSN).
+var clickEpochExprs = map[string]string{
+	"sqlite":   "CAST(strftime('%s', timestamp) AS INTEGER)",
+	"postgres": "CAST(EXTRACT(EPOCH FROM timestamp) AS BIGINT)",
+	"mysql":    "UNIX_TIMESTAMP(timestamp)",
+}
+
+// intDivOps est l'opérateur de division entière de chaque dialecte SQL ('/' produit un décimal en MySQL).
+var intDivOps = map[string]string{
+	"sqlite":   "/",
+	"postgres": "/",
+	"mysql":    "DIV",
 }
 
 // GormClickRepository est l'implémentation de l'interface ClickRepository utilisant GORM.
 type GormClickRepository struct {
-	db *gorm.DB // Référence à l'instance de la base de données GORM
+	db        *gorm.DB // Référence à l'instance de la base de données GORM
+	epochExpr string   // Expression SQL de 'timestamp' en secondes Unix
+	intDiv    string   // Opérateur SQL de division entière
 }
 
 // NewClickRepository crée et retourne une nouvelle instance de GormClickRepository.
 // C'est la méthode recommandée pour obtenir un dépôt, garantissant que la connexion à la base de données est injectée.
+// Les expressions SQL dépendantes du dialecte sont choisies d'après le pilote de 'db' (SQLite par défaut).
 func NewClickRepository(db *gorm.DB) *GormClickRepository {
-	return &GormClickRepository{db: db}
+	dialect := db.Dialector.Name()
+	if _, ok := clickEpochExprs[dialect]; !ok {
+		dialect = "sqlite"
+	}
+	return &GormClickRepository{db: db, epochExpr: clickEpochExprs[dialect], intDiv: intDivOps[dialect]}
 }
 
 // CreateClick insère un nouvel enregistrement de clic dans la base de données.
@@ -30,14 +103,213 @@ func (r *GormClickRepository) CreateClick(click *models.Click) error {
 	return r.db.Create(click).Error
 }
 
-// CountClicksByLinkID compte le nombre total de clics pour un ID de lien donné.
+// clickInsertBatchSize borne le nombre de lignes par requête INSERT, SQLite limitant
+// le nombre de paramètres liés par requête.
+const clickInsertBatchSize = 100
+
+// CreateClicks insère plusieurs clics en une seule transaction.
+// Un lot entier est donc soit persisté, soit rejeté, ce qui réduit fortement
+// la contention sur le verrou d'écriture de SQLite par rapport à une insertion par clic.
+func (r *GormClickRepository) CreateClicks(clicks []models.Click) error {
+	if len(clicks) == 0 {
+		return nil
+	}
+	return r.db.CreateInBatches(clicks, clickInsertBatchSize).Error
+}
+
+// CountClicksByLinkID compte le nombre total de clics pour un ID de lien donné,
+// y compris les clics agrégés par la politique de rétention.
 // Cette méthode est utilisée pour fournir des statistiques pour une URL courte.
 func (r *GormClickRepository) CountClicksByLinkID(linkID uint) (int, error) {
+	return countAllClicks(r.db, linkID)
+}
+
+// countAllClicks compte les clics détaillés d'un lien et y ajoute ses totaux journaliers agrégés.
+func countAllClicks(db *gorm.DB, linkID uint) (int, error) {
 	var count int64 // GORM retourne un int64 pour les décomptes
-	// où 'LinkID' correspond à l'ID de lien fourni.
-	// Assurez-vous de gérer les erreurs appropriées.
-	if err := r.db.Model(&models.Click{}).Where("link_id = ?", linkID).Count(&count).Error; err != nil {
+	if err := db.Model(&models.Click{}).Where("link_id = ?", linkID).Count(&count).Error; err != nil {
+		return 0, err
+	}
+	var aggregated int64
+	if err := db.Model(&models.ClickDailyCount{}).Where("link_id = ?", linkID).
+		Select("COALESCE(SUM(clicks), 0)").Scan(&aggregated).Error; err != nil {
+		return 0, err
+	}
+	return int(count + aggregated), nil
+}
+
+// CountClicks compte les clics correspondant au filtre.
+func (r *GormClickRepository) CountClicks(filter ClickFilter) (int, error) {
+	var count int64
+	if err := r.filtered(filter).Count(&count).Error; err != nil {
+		return 0, err
+	}
+	return int(count), nil
+}
+
+// CountClicksByTimeSlot compte les clics correspondant au filtre, regroupés par tranches de durée 'slot'
+// alignées sur l'époque Unix. Seules les tranches contenant au moins un clic sont retournées,
+// dans l'ordre chronologique. L'agrégation est faite par la base : le nombre de lignes lues
+// dépend du nombre de tranches et non du nombre de clics.
+func (r *GormClickRepository) CountClicksByTimeSlot(filter ClickFilter, slot time.Duration) ([]models.ClickBucket, error) {
+	slotSeconds := int64(slot / time.Second)
+	if slotSeconds < 1 {
+		slotSeconds = 1
+	}
+
+	query := r.filtered(filter).
+		Select("("+r.epochExpr+" "+r.intDiv+" ?) AS slot, COUNT(*) AS total", slotSeconds)
+
+	var rows []struct {
+		Slot  int64
+		Total int
+	}
+	if err := query.Group("slot").Order("slot").Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	buckets := make([]models.ClickBucket, 0, len(rows))
+	for _, row := range rows {
+		buckets = append(buckets, models.ClickBucket{
+			Start: time.Unix(row.Slot*slotSeconds, 0).UTC(),
+			Count: row.Total,
+		})
+	}
+	return buckets, nil
+}
+
+// CountClicksByDimension compte les clics correspondant au filtre pour chaque valeur non vide
+// de la dimension, par nombre de clics décroissant. 'limit' borne le nombre de valeurs retournées (0 pour toutes).
+func (r *GormClickRepository) CountClicksByDimension(filter ClickFilter, dimension ClickDimension, limit int) ([]models.DimensionCount, error) {
+	if !validDimensions[dimension] {
+		return nil, ErrInvalidDimension
+	}
+	column := string(dimension)
+
+	query := r.filtered(filter).
+		Select(column + " AS value, COUNT(*) AS count").
+		Where(column + " <> ''").
+		Group(column).
+		Order("count DESC, value")
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+
+	var counts []models.DimensionCount
+	if err := query.Scan(&counts).Error; err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
+
+// CountClicksWithoutDimension compte les clics correspondant au filtre pour lesquels la dimension est vide
+// (accès direct pour le référent, valeur inconnue pour les autres dimensions).
+func (r *GormClickRepository) CountClicksWithoutDimension(filter ClickFilter, dimension ClickDimension) (int, error) {
+	if !validDimensions[dimension] {
+		return 0, ErrInvalidDimension
+	}
+
+	var count int64
+	if err := r.filtered(filter).Where(string(dimension) + " = ''").Count(&count).Error; err != nil {
 		return 0, err
 	}
-	return int(count), nil // Convert the int64 count to an int
+	return int(count), nil
+}
+
+// filtered retourne une requête sur la table 'clicks' restreinte par le filtre.
+func (r *GormClickRepository) filtered(filter ClickFilter) *gorm.DB {
+	query := r.db.Model(&models.Click{}).Where("link_id = ?", filter.LinkID)
+	if !filter.From.IsZero() {
+		query = query.Where(r.epochExpr+" >= ?", filter.From.Unix())
+	}
+	if !filter.To.IsZero() {
+		query = query.Where(r.epochExpr+" < ?", filter.To.Unix())
+	}
+	if filter.ExcludeBots {
+		query = query.Where("is_bot = ?", false)
+	}
+	if filter.Fallback {
+		query = query.Where("fallback = ?", true)
+	}
+	return query
+}
+
+// DeleteClicksBefore supprime les clics enregistrés avant 'cutoff' et retourne le nombre de lignes supprimées.
+func (r *GormClickRepository) DeleteClicksBefore(cutoff time.Time) (int64, error) {
+	result := r.db.Where(r.epochExpr+" < ?", cutoff.Unix()).Delete(&models.Click{})
+	return result.RowsAffected, result.Error
+}
+
+// AggregateClicksBefore remplace les clics enregistrés avant 'cutoff' par leur nombre par lien et par jour (UTC),
+// ajouté aux totaux de la table 'click_daily_counts', puis les supprime. Le tout est fait dans une même
+// transaction : un clic n'est jamais compté deux fois ni perdu. Les clics agrégés restent inclus dans
+// CountClicksByLinkID mais plus dans les séries temporelles ni les répartitions.
+// Retourne le nombre de clics supprimés.
+func (r *GormClickRepository) AggregateClicksBefore(cutoff time.Time) (int64, error) {
+	var deleted int64
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var rows []struct {
+			LinkID uint
+			Day    int64
+			Total  int
+		}
+		err := tx.Model(&models.Click{}).
+			Select("link_id, ("+r.epochExpr+" "+r.intDiv+" 86400) AS day, COUNT(*) AS total").
+			Where(r.epochExpr+" < ?", cutoff.Unix()).
+			Group("link_id, day").
+			Scan(&rows).Error
+		if err != nil {
+			return err
+		}
+
+		for _, row := range rows {
+			day := time.Unix(row.Day*86400, 0).UTC()
+			var daily models.ClickDailyCount
+			err := tx.Where("link_id = ? AND day = ?", row.LinkID, day).First(&daily).Error
+			switch {
+			case errors.Is(err, gorm.ErrRecordNotFound):
+				daily = models.ClickDailyCount{LinkID: row.LinkID, Day: day, Clicks: row.Total}
+				err = tx.Create(&daily).Error
+			case err == nil:
+				err = tx.Model(&daily).Update("clicks", gorm.Expr("clicks + ?", row.Total)).Error
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		result := tx.Where(r.epochExpr+" < ?", cutoff.Unix()).Delete(&models.Click{})
+		deleted = result.RowsAffected
+		return result.Error
+	})
+	if err != nil {
+		return 0, err
+	}
+	return deleted, nil
+}
+
+// DeleteClicksByIP supprime tous les clics dont l'adresse IP enregistrée fait partie de 'ipAddresses'
+// et retourne le nombre de lignes supprimées.
+func (r *GormClickRepository) DeleteClicksByIP(ipAddresses []string) (int64, error) {
+	if len(ipAddresses) == 0 {
+		return 0, nil
+	}
+	result := r.db.Where("ip_address IN ?", ipAddresses).Delete(&models.Click{})
+	return result.RowsAffected, result.Error
+}
+
+// ExistingLinkIDs retourne, parmi 'linkIDs', les identifiants des liens encore présents en base.
+func (r *GormClickRepository) ExistingLinkIDs(linkIDs []uint) (map[uint]bool, error) {
+	existing := make(map[uint]bool, len(linkIDs))
+	if len(linkIDs) == 0 {
+		return existing, nil
+	}
+	var ids []uint
+	if err := r.db.Model(&models.Link{}).Where("id IN ?", linkIDs).Pluck("id", &ids).Error; err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		existing[id] = true
+	}
+	return existing, nil
 }
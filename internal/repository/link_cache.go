@@ -0,0 +1,247 @@
+package repository
+
+import (
+	"container/list"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+	"gorm.io/gorm"
+)
+
+// CachedLinkRepository est un LinkRepository qui garde en mémoire les derniers liens lus par code court.
+// Le cache est de taille bornée (éviction du moins récemment utilisé) et chaque entrée expire après un TTL.
+// Les codes inconnus sont aussi mis en cache, avec un TTL plus court, pour qu'une rafale de requêtes
+// sur un code inexistant n'atteigne pas la base à chaque fois.
+//
+// Seul GetLinkByShortCode est servi par le cache ; les autres méthodes sont déléguées au repository sous-jacent.
+// Les entrées d'un lien sont invalidées quand il est créé, modifié ou supprimé par ce processus.
+// Les modifications faites par un autre processus (autre réplica, commande CLI) ne sont visibles
+// qu'à l'expiration de l'entrée.
+type CachedLinkRepository struct {
+	LinkRepository
+
+	capacity    int
+	ttl         time.Duration
+	negativeTTL time.Duration
+
+	mu      sync.Mutex               // Protège entries, lru et loads
+	entries map[string]*list.Element // Code court -> élément de lru
+	lru     *list.List               // Entrées de la plus récemment à la moins récemment utilisée
+	loads   map[string]*loadState    // Lectures en base en cours, par code court
+
+	hits      atomic.Uint64
+	misses    atomic.Uint64
+	evictions atomic.Uint64
+}
+
+// cacheEntry est une entrée du cache : un lien, ou nil pour un code court inconnu.
+type cacheEntry struct {
+	shortCode string
+	link      *models.Link
+	expiresAt time.Time
+}
+
+// loadState suit les lectures en base en cours pour un code court.
+// Invalidate incrémente sa génération : un lien lu avant l'invalidation n'est pas mis en cache,
+// sans quoi une lecture concurrente d'une modification pourrait y replacer l'ancienne version.
+type loadState struct {
+	generation uint64
+	readers    int
+}
+
+// CacheStats est un instantané des compteurs du cache.
+type CacheStats struct {
+	Hits      uint64 // Lectures servies par le cache, y compris les codes inconnus
+	Misses    uint64 // Lectures transmises à la base
+	Evictions uint64 // Entrées retirées pour faire de la place
+	Entries   int    // Nombre d'entrées actuellement en cache
+	Capacity  int
+}
+
+// NewCachedLinkRepository enveloppe 'inner' dans un cache d'au plus 'capacity' entrées.
+// Un 'negativeTTL' nul désactive la mise en cache des codes inconnus.
+func NewCachedLinkRepository(inner LinkRepository, capacity int, ttl, negativeTTL time.Duration) *CachedLinkRepository {
+	if capacity < 1 {
+		capacity = 1
+	}
+	return &CachedLinkRepository{
+		LinkRepository: inner,
+		capacity:       capacity,
+		ttl:            ttl,
+		negativeTTL:    negativeTTL,
+		entries:        make(map[string]*list.Element),
+		lru:            list.New(),
+		loads:          make(map[string]*loadState),
+	}
+}
+
+// GetLinkByShortCode retourne le lien depuis le cache s'il y est encore valide, sinon le lit dans la base
+// et le met en cache. Le lien retourné est une copie : l'appelant peut le modifier sans altérer le cache.
+func (r *CachedLinkRepository) GetLinkByShortCode(shortCode string) (*models.Link, error) {
+	if link, found := r.lookup(shortCode); found {
+		r.hits.Add(1)
+		if link == nil {
+			return nil, gorm.ErrRecordNotFound
+		}
+		return link, nil
+	}
+	r.misses.Add(1)
+
+	generation := r.beginLoad(shortCode)
+	link, err := r.LinkRepository.GetLinkByShortCode(shortCode)
+	switch {
+	case err == nil:
+		r.endLoad(shortCode, generation, link, r.ttl)
+		return cloneLink(link), nil
+	case errors.Is(err, gorm.ErrRecordNotFound) && r.negativeTTL > 0:
+		r.endLoad(shortCode, generation, nil, r.negativeTTL)
+	default:
+		r.endLoad(shortCode, generation, nil, 0)
+	}
+	return nil, err
+}
+
+// CreateLink crée le lien puis retire du cache une éventuelle entrée négative pour son code court.
+func (r *CachedLinkRepository) CreateLink(link *models.Link) error {
+	err := r.LinkRepository.CreateLink(link)
+	r.Invalidate(link.Shortcode)
+	return err
+}
+
+// UpdateLink met à jour le lien puis retire son entrée du cache.
+func (r *CachedLinkRepository) UpdateLink(link *models.Link) error {
+	err := r.LinkRepository.UpdateLink(link)
+	r.Invalidate(link.Shortcode)
+	return err
+}
+
+// DeleteLink supprime le lien puis retire son entrée du cache.
+func (r *CachedLinkRepository) DeleteLink(link *models.Link) error {
+	err := r.LinkRepository.DeleteLink(link)
+	r.Invalidate(link.Shortcode)
+	return err
+}
+
+// Invalidate retire l'entrée d'un code court du cache. Les lectures en base en cours
+// pour ce code ne mettront pas leur résultat en cache.
+func (r *CachedLinkRepository) Invalidate(shortCode string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if element, ok := r.entries[shortCode]; ok {
+		r.lru.Remove(element)
+		delete(r.entries, shortCode)
+	}
+	if state, ok := r.loads[shortCode]; ok {
+		state.generation++
+	}
+}
+
+// Stats retourne les compteurs du cache.
+func (r *CachedLinkRepository) Stats() CacheStats {
+	r.mu.Lock()
+	entries := r.lru.Len()
+	r.mu.Unlock()
+	return CacheStats{
+		Hits:      r.hits.Load(),
+		Misses:    r.misses.Load(),
+		Evictions: r.evictions.Load(),
+		Entries:   entries,
+		Capacity:  r.capacity,
+	}
+}
+
+// lookup retourne une copie du lien en cache pour 'shortCode' (nil pour un code inconnu)
+// et true si une entrée valide existe. Une entrée expirée est retirée.
+func (r *CachedLinkRepository) lookup(shortCode string) (*models.Link, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	element, ok := r.entries[shortCode]
+	if !ok {
+		return nil, false
+	}
+	entry := element.Value.(*cacheEntry)
+	if time.Now().After(entry.expiresAt) {
+		r.lru.Remove(element)
+		delete(r.entries, shortCode)
+		return nil, false
+	}
+	r.lru.MoveToFront(element)
+	if entry.link == nil {
+		return nil, true
+	}
+	return cloneLink(entry.link), true
+}
+
+// beginLoad enregistre le début d'une lecture en base pour 'shortCode' et retourne la génération courante du code.
+func (r *CachedLinkRepository) beginLoad(shortCode string) uint64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	state, ok := r.loads[shortCode]
+	if !ok {
+		state = &loadState{}
+		r.loads[shortCode] = state
+	}
+	state.readers++
+	return state.generation
+}
+
+// endLoad termine une lecture en base commencée à la génération 'generation' et met en cache
+// son résultat pour 'ttl' si le code n'a pas été invalidé entre-temps. Un 'ttl' nul ne met rien en cache.
+func (r *CachedLinkRepository) endLoad(shortCode string, generation uint64, link *models.Link, ttl time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	state := r.loads[shortCode]
+	state.readers--
+	if state.readers == 0 {
+		delete(r.loads, shortCode)
+	}
+	if ttl > 0 && state.generation == generation {
+		r.store(shortCode, link, ttl)
+	}
+}
+
+// store met en cache une copie de 'link' (nil pour un code inconnu) et évince
+// l'entrée la moins récemment utilisée si le cache est plein. L'appelant doit détenir le verrou.
+func (r *CachedLinkRepository) store(shortCode string, link *models.Link, ttl time.Duration) {
+	entry := &cacheEntry{shortCode: shortCode, expiresAt: time.Now().Add(ttl)}
+	if link != nil {
+		entry.link = cloneLink(link)
+	}
+
+	if element, ok := r.entries[shortCode]; ok {
+		element.Value = entry
+		r.lru.MoveToFront(element)
+		return
+	}
+	r.entries[shortCode] = r.lru.PushFront(entry)
+	for r.lru.Len() > r.capacity {
+		oldest := r.lru.Back()
+		r.lru.Remove(oldest)
+		delete(r.entries, oldest.Value.(*cacheEntry).shortCode)
+		r.evictions.Add(1)
+	}
+}
+
+// cloneLink retourne une copie profonde de 'link' : les champs pointeurs (expiration, créateur,
+// espace de travail) ne sont pas partagés entre le cache et ses appelants.
+func cloneLink(link *models.Link) *models.Link {
+	copied := *link
+	if link.ExpiresAt != nil {
+		expiresAt := *link.ExpiresAt
+		copied.ExpiresAt = &expiresAt
+	}
+	if link.CreatedBy != nil {
+		createdBy := *link.CreatedBy
+		copied.CreatedBy = &createdBy
+	}
+	if link.WorkspaceID != nil {
+		workspaceID := *link.WorkspaceID
+		copied.WorkspaceID = &workspaceID
+	}
+	return &copied
+}
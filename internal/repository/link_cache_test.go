@@ -0,0 +1,176 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+	"gorm.io/gorm"
+)
+
+// fakeLinkRepository sert des liens depuis une map et compte les lectures.
+// Seules les méthodes utilisées par le cache sont implémentées.
+type fakeLinkRepository struct {
+	LinkRepository
+
+	links  map[string]*models.Link
+	reads  int
+	onRead func(shortCode string) // Appelé après la lecture du lien, avant de le retourner
+}
+
+func newFakeLinkRepository(codes ...string) *fakeLinkRepository {
+	f := &fakeLinkRepository{links: make(map[string]*models.Link)}
+	for i, code := range codes {
+		f.links[code] = &models.Link{ID: uint(i + 1), Shortcode: code, LongURL: "https://example.com/" + code}
+	}
+	return f
+}
+
+func (f *fakeLinkRepository) GetLinkByShortCode(shortCode string) (*models.Link, error) {
+	f.reads++
+	link, ok := f.links[shortCode]
+	if f.onRead != nil {
+		f.onRead(shortCode)
+	}
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	copied := *link
+	return &copied, nil
+}
+
+func (f *fakeLinkRepository) UpdateLink(link *models.Link) error {
+	copied := *link
+	f.links[link.Shortcode] = &copied
+	return nil
+}
+
+func TestCachedLinkRepositoryReads(t *testing.T) {
+	tests := []struct {
+		name        string
+		capacity    int
+		ttl         time.Duration
+		negativeTTL time.Duration
+		reads       []string // Codes lus, dans l'ordre
+		wantReads   int      // Lectures transmises au repository sous-jacent
+		wantEvicted uint64
+	}{
+		{"repeated hits", 10, time.Minute, time.Minute, []string{"a", "a", "a"}, 1, 0},
+		{"negative cache", 10, time.Minute, time.Minute, []string{"missing", "missing"}, 1, 0},
+		{"negative cache disabled", 10, time.Minute, 0, []string{"missing", "missing"}, 2, 0},
+		{"expired entry", 10, time.Nanosecond, time.Minute, []string{"a", "a"}, 2, 0},
+		{"lru eviction", 2, time.Minute, time.Minute, []string{"a", "b", "c", "a"}, 4, 2},
+		{"recently used entry kept", 2, time.Minute, time.Minute, []string{"a", "b", "a", "c", "a"}, 3, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inner := newFakeLinkRepository("a", "b", "c")
+			cache := NewCachedLinkRepository(inner, tt.capacity, tt.ttl, tt.negativeTTL)
+			for _, code := range tt.reads {
+				if tt.ttl == time.Nanosecond {
+					time.Sleep(time.Millisecond)
+				}
+				link, err := cache.GetLinkByShortCode(code)
+				if _, known := inner.links[code]; known {
+					if err != nil || link.Shortcode != code {
+						t.Fatalf("GetLinkByShortCode(%q) = %+v, %v", code, link, err)
+					}
+				} else if err != gorm.ErrRecordNotFound {
+					t.Fatalf("GetLinkByShortCode(%q) error = %v, want ErrRecordNotFound", code, err)
+				}
+			}
+			if inner.reads != tt.wantReads {
+				t.Errorf("inner reads = %d, want %d", inner.reads, tt.wantReads)
+			}
+			stats := cache.Stats()
+			if stats.Evictions != tt.wantEvicted {
+				t.Errorf("evictions = %d, want %d", stats.Evictions, tt.wantEvicted)
+			}
+			if stats.Hits+stats.Misses != uint64(len(tt.reads)) || stats.Misses != uint64(tt.wantReads) {
+				t.Errorf("stats = %+v, want %d misses over %d reads", stats, tt.wantReads, len(tt.reads))
+			}
+			if stats.Entries > tt.capacity {
+				t.Errorf("entries = %d, above capacity %d", stats.Entries, tt.capacity)
+			}
+		})
+	}
+}
+
+func TestCachedLinkRepositoryInvalidation(t *testing.T) {
+	inner := newFakeLinkRepository("a")
+	cache := NewCachedLinkRepository(inner, 10, time.Minute, time.Minute)
+
+	if _, err := cache.GetLinkByShortCode("a"); err != nil {
+		t.Fatal(err)
+	}
+	if err := cache.UpdateLink(&models.Link{ID: 1, Shortcode: "a", LongURL: "https://example.com/new"}); err != nil {
+		t.Fatal(err)
+	}
+	link, err := cache.GetLinkByShortCode("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if link.LongURL != "https://example.com/new" {
+		t.Errorf("LongURL = %q, want the updated URL", link.LongURL)
+	}
+	if inner.reads != 2 {
+		t.Errorf("inner reads = %d, want 2", inner.reads)
+	}
+}
+
+// Une invalidation pendant une lecture en base ne doit pas laisser l'ancienne version en cache.
+func TestCachedLinkRepositoryInvalidateDuringLoad(t *testing.T) {
+	inner := newFakeLinkRepository("a")
+	cache := NewCachedLinkRepository(inner, 10, time.Minute, time.Minute)
+
+	inner.onRead = func(shortCode string) {
+		// La lecture a déjà vu l'ancienne version quand la modification arrive.
+		inner.onRead = nil
+		if err := cache.UpdateLink(&models.Link{ID: 1, Shortcode: "a", LongURL: "https://example.com/new"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	link, err := cache.GetLinkByShortCode("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if link.LongURL != "https://example.com/a" {
+		t.Fatalf("first read = %q, want the version read before the update", link.LongURL)
+	}
+
+	link, err = cache.GetLinkByShortCode("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if link.LongURL != "https://example.com/new" {
+		t.Errorf("second read = %q, stale link kept in cache", link.LongURL)
+	}
+	if inner.reads != 2 {
+		t.Errorf("inner reads = %d, want 2", inner.reads)
+	}
+}
+
+func TestCachedLinkRepositoryReturnsCopies(t *testing.T) {
+	expiresAt := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	createdBy, workspaceID := uint(7), uint(9)
+	inner := newFakeLinkRepository()
+	inner.links["a"] = &models.Link{ID: 1, Shortcode: "a", ExpiresAt: &expiresAt, CreatedBy: &createdBy, WorkspaceID: &workspaceID}
+	cache := NewCachedLinkRepository(inner, 10, time.Minute, time.Minute)
+
+	for i := 0; i < 2; i++ {
+		link, err := cache.GetLinkByShortCode("a")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !link.ExpiresAt.Equal(expiresAt) || *link.CreatedBy != createdBy || *link.WorkspaceID != workspaceID {
+			t.Fatalf("read %d = %+v, cached link was altered by a caller", i, link)
+		}
+		link.LongURL = "https://attacker.example"
+		*link.ExpiresAt = time.Time{}
+		*link.CreatedBy = 0
+		*link.WorkspaceID = 0
+	}
+	if inner.reads != 1 {
+		t.Errorf("inner reads = %d, want 1", inner.reads)
+	}
+}
@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+	"gorm.io/gorm"
+)
+
+// LinkCheckRepository définit l'accès à l'historique des vérifications du moniteur d'URLs.
+type LinkCheckRepository interface {
+	CreateLinkCheck(check *models.LinkCheck) error
+	GetLatestChecks() ([]models.LinkCheck, error)
+	GetPendingStreaks() (map[uint]int, error)
+	GetLatestContentHashes() (map[uint]string, error)
+	GetLastCheck(linkID uint, before time.Time) (*models.LinkCheck, error)
+	GetChecks(linkID uint, from, to time.Time) ([]models.LinkCheck, error)
+	DeleteChecksBefore(cutoff time.Time) (int64, error)
+}
+
+// GormLinkCheckRepository est l'implémentation de l'interface LinkCheckRepository utilisant GORM.
+type GormLinkCheckRepository struct {
+	db *gorm.DB
+}
+
+// NewLinkCheckRepository crée et retourne une nouvelle instance de GormLinkCheckRepository.
+func NewLinkCheckRepository(db *gorm.DB) *GormLinkCheckRepository {
+	return &GormLinkCheckRepository{db: db}
+}
+
+// CreateLinkCheck enregistre le résultat d'une vérification.
+func (r *GormLinkCheckRepository) CreateLinkCheck(check *models.LinkCheck) error {
+	return r.db.Create(check).Error
+}
+
+// GetLatestChecks retourne la dernière vérification enregistrée de chaque lien.
+// Les vérifications d'un lien sont insérées dans l'ordre chronologique : la plus récente a le plus grand ID.
+func (r *GormLinkCheckRepository) GetLatestChecks() ([]models.LinkCheck, error) {
+	var checks []models.LinkCheck
+	latest := r.db.Model(&models.LinkCheck{}).Select("MAX(id)").Group("link_id")
+	if err := r.db.Where("id IN (?)", latest).Find(&checks).Error; err != nil {
+		return nil, err
+	}
+	return checks, nil
+}
+
+// GetPendingStreaks retourne, pour chaque lien dont les dernières vérifications contredisent l'état déclaré,
+// le nombre de ces vérifications consécutives : celles qui suivent la dernière vérification conforme à l'état déclaré.
+func (r *GormLinkCheckRepository) GetPendingStreaks() (map[uint]int, error) {
+	var rows []struct {
+		LinkID uint
+		Streak int
+	}
+	err := r.db.Model(&models.LinkCheck{}).
+		Select("link_id, COUNT(*) AS streak").
+		Where("up <> state_up").
+		Where("id > COALESCE((SELECT MAX(c2.id) FROM link_checks c2 WHERE c2.link_id = link_checks.link_id AND c2.up = c2.state_up), 0)").
+		Group("link_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	streaks := make(map[uint]int, len(rows))
+	for _, row := range rows {
+		streaks[row.LinkID] = row.Streak
+	}
+	return streaks, nil
+}
+
+// GetLatestContentHashes retourne, pour chaque lien, la dernière empreinte de contenu enregistrée.
+// Les vérifications sans empreinte (URL inaccessible ou empreinte non demandée) sont ignorées.
+func (r *GormLinkCheckRepository) GetLatestContentHashes() (map[uint]string, error) {
+	var checks []models.LinkCheck
+	latest := r.db.Model(&models.LinkCheck{}).Select("MAX(id)").Where("content_hash <> ''").Group("link_id")
+	if err := r.db.Select("link_id, content_hash").Where("id IN (?)", latest).Find(&checks).Error; err != nil {
+		return nil, err
+	}
+	hashes := make(map[uint]string, len(checks))
+	for _, check := range checks {
+		hashes[check.LinkID] = check.ContentHash
+	}
+	return hashes, nil
+}
+
+// GetLastCheck retourne la dernière vérification d'un lien antérieure à 'before' (sans limite si 'before' est zéro).
+// Il renvoie gorm.ErrRecordNotFound si le lien n'a jamais été vérifié avant cette date.
+func (r *GormLinkCheckRepository) GetLastCheck(linkID uint, before time.Time) (*models.LinkCheck, error) {
+	var check models.LinkCheck
+	query := r.db.Where("link_id = ?", linkID)
+	if !before.IsZero() {
+		query = query.Where("checked_at < ?", before)
+	}
+	if err := query.Order("checked_at DESC, id DESC").First(&check).Error; err != nil {
+		return nil, err
+	}
+	return &check, nil
+}
+
+// GetChecks retourne les vérifications d'un lien effectuées dans [from, to), par ordre chronologique.
+// Les bornes laissées à leur valeur zéro ne filtrent pas.
+func (r *GormLinkCheckRepository) GetChecks(linkID uint, from, to time.Time) ([]models.LinkCheck, error) {
+	var checks []models.LinkCheck
+	query := r.db.Where("link_id = ?", linkID)
+	if !from.IsZero() {
+		query = query.Where("checked_at >= ?", from)
+	}
+	if !to.IsZero() {
+		query = query.Where("checked_at < ?", to)
+	}
+	if err := query.Order("checked_at, id").Find(&checks).Error; err != nil {
+		return nil, err
+	}
+	return checks, nil
+}
+
+// DeleteChecksBefore supprime les vérifications antérieures à 'cutoff' et retourne le nombre de lignes supprimées.
+func (r *GormLinkCheckRepository) DeleteChecksBefore(cutoff time.Time) (int64, error) {
+	result := r.db.Where("checked_at < ?", cutoff).Delete(&models.LinkCheck{})
+	return result.RowsAffected, result.Error
+}
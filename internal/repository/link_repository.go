@@ -1,6 +1,8 @@
 package repository
 
 import (
+	"time"
+
 	"github.com/axellelanca/urlshortener/internal/models"
 	"gorm.io/gorm"
 )
@@ -10,10 +12,66 @@ import (
 type LinkRepository interface {
 	CreateLink(link *models.Link) error
 	GetLinkByShortCode(shortCode string) (*models.Link, error)
-	GetAllLinks() ([]models.Link, error)
+	GetAllLinks(scope LinkScope) ([]models.Link, error)
+	ListLinks(filter LinkFilter) ([]models.Link, int64, error)
+	UpdateLink(link *models.Link) error
+	DeleteLink(link *models.Link) error
 	CountClicksByLinkID(linkID uint) (int, error)
 }
 
+// LinkScope restreint les liens auxquels s'applique une lecture de plusieurs liens :
+// les liens d'un espace de travail, les liens personnels d'une clé d'API, ou tous les liens (AllLinks)
+// pour les traitements internes. La valeur zéro ne correspond à aucun lien.
+type LinkScope struct {
+	all         bool
+	unowned     bool // Liens hors espace de travail sans créateur (API sans authentification)
+	WorkspaceID uint // Liens de cet espace de travail
+	APIKeyID    uint // Si WorkspaceID est nul : liens hors espace de travail créés par cette clé
+}
+
+// AllLinks est le périmètre sans restriction, réservé au moniteur, à la CLI et aux clés d'administration.
+var AllLinks = LinkScope{all: true}
+
+// UnownedScope est le périmètre des liens créés sans clé d'API, hors espace de travail.
+var UnownedScope = LinkScope{unowned: true}
+
+// WorkspaceScope retourne le périmètre des liens d'un espace de travail.
+func WorkspaceScope(workspaceID uint) LinkScope {
+	return LinkScope{WorkspaceID: workspaceID}
+}
+
+// PersonalScope retourne le périmètre des liens personnels d'une clé d'API.
+func PersonalScope(apiKeyID uint) LinkScope {
+	return LinkScope{APIKeyID: apiKeyID}
+}
+
+// apply ajoute à 'query' la condition correspondant au périmètre.
+func (s LinkScope) apply(query *gorm.DB) *gorm.DB {
+	switch {
+	case s.all:
+		return query
+	case s.unowned:
+		return query.Where("workspace_id IS NULL AND created_by IS NULL")
+	case s.WorkspaceID != 0:
+		return query.Where("workspace_id = ?", s.WorkspaceID)
+	default:
+		return query.Where("workspace_id IS NULL AND created_by = ?", s.APIKeyID)
+	}
+}
+
+// LinkFilter décrit les critères de filtrage, de tri et de pagination de ListLinks.
+// Les champs laissés à leur valeur zéro ne filtrent pas.
+type LinkFilter struct {
+	URLContains   string    // Sous-chaîne recherchée dans l'URL longue
+	CreatedAfter  time.Time // Liens créés à partir de cette date (incluse)
+	CreatedBefore time.Time // Liens créés avant cette date (exclue)
+	Scope         LinkScope // Périmètre des liens, obligatoire
+	SortBy        string    // Colonne de tri, doit être validée par l'appelant
+	SortDesc      bool      // Tri décroissant
+	Limit         int       // Nombre maximal de liens retournés, 0 pour tous
+	Offset        int       // Nombre de liens à ignorer
+}
+
 type GormLinkRepository struct {
 	db *gorm.DB
 }
@@ -40,22 +98,82 @@ func (r *GormLinkRepository) GetLinkByShortCode(shortCode string) (*models.Link,
 	return &link, nil
 }
 
-// GetAllLinks récupère tous les liens de la base de données.
-// Cette méthode est utilisée par le moniteur d'URLs.
-func (r *GormLinkRepository) GetAllLinks() ([]models.Link, error) {
+// GetAllLinks récupère tous les liens du périmètre 'scope'.
+// Cette méthode est utilisée par le moniteur d'URLs, avec AllLinks.
+func (r *GormLinkRepository) GetAllLinks(scope LinkScope) ([]models.Link, error) {
 	var links []models.Link
-	if err := r.db.Find(&links).Error; err != nil {
+	if err := scope.apply(r.db).Find(&links).Error; err != nil {
 		return nil, err
 	}
 	return links, nil
 }
 
-// CountClicksByLinkID compte le nombre total de clics pour un ID de lien donné.
-func (r *GormLinkRepository) CountClicksByLinkID(linkID uint) (int, error) {
-	var count int64 // GORM retourne un int64 pour les comptes
-	// où 'LinkID' correspond à l'ID du lien donné.
-	if err := r.db.Model(&models.Click{}).Where("link_id = ?", linkID).Count(&count).Error; err != nil {
-		return 0, err
+// ListLinks récupère une page de liens correspondant au filtre, ainsi que le nombre total
+// de liens correspondants (sans pagination) pour permettre au client de naviguer.
+func (r *GormLinkRepository) ListLinks(filter LinkFilter) ([]models.Link, int64, error) {
+	query := filter.Scope.apply(r.db.Model(&models.Link{}))
+	if filter.URLContains != "" {
+		query = query.Where("long_url LIKE ?", "%"+filter.URLContains+"%")
+	}
+	// 'created_at' est enregistré en UTC : SQLite le stockant sous forme de texte,
+	// les bornes doivent être dans le même fuseau pour être comparées correctement.
+	if !filter.CreatedAfter.IsZero() {
+		query = query.Where("created_at >= ?", filter.CreatedAfter.UTC())
 	}
-	return int(count), nil
+	if !filter.CreatedBefore.IsZero() {
+		query = query.Where("created_at < ?", filter.CreatedBefore.UTC())
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if filter.SortBy != "" {
+		direction := "ASC"
+		if filter.SortDesc {
+			direction = "DESC"
+		}
+		query = query.Order(filter.SortBy + " " + direction)
+	}
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit).Offset(filter.Offset)
+	}
+
+	var links []models.Link
+	if err := query.Find(&links).Error; err != nil {
+		return nil, 0, err
+	}
+	return links, total, nil
+}
+
+// UpdateLink enregistre toutes les colonnes d'un lien existant.
+func (r *GormLinkRepository) UpdateLink(link *models.Link) error {
+	return r.db.Save(link).Error
+}
+
+// DeleteLink supprime un lien ainsi que les clics, totaux journaliers, sketches de visiteurs et vérifications
+// qui lui sont rattachés, dans une même transaction.
+func (r *GormLinkRepository) DeleteLink(link *models.Link) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("link_id = ?", link.ID).Delete(&models.Click{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("link_id = ?", link.ID).Delete(&models.ClickDailyCount{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("link_id = ?", link.ID).Delete(&models.VisitorSketch{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("link_id = ?", link.ID).Delete(&models.LinkCheck{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(link).Error
+	})
+}
+
+// CountClicksByLinkID compte le nombre total de clics pour un ID de lien donné,
+// y compris les clics agrégés par la politique de rétention.
+func (r *GormLinkRepository) CountClicksByLinkID(linkID uint) (int, error) {
+	return countAllClicks(r.db, linkID)
 }
@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"crypto/rand"
+	"errors"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// saltSize est la taille en octets des sels quotidiens des empreintes de visiteurs.
+const saltSize = 32
+
+// VisitorRepository définit l'accès aux sels quotidiens et aux sketches de visiteurs uniques.
+type VisitorRepository interface {
+	GetOrCreateSalt(day time.Time) ([]byte, error)
+	DeleteSaltsBefore(day time.Time) error
+	DeleteSketchesBefore(day time.Time) (int64, error) // Rétention : suppression des sketches anciens
+	UpdateSketch(linkID uint, day time.Time, update func(current []byte) ([]byte, error)) error
+	GetSketches(linkID uint, from, to time.Time) ([]models.VisitorSketch, error)
+}
+
+// GormVisitorRepository est l'implémentation de l'interface VisitorRepository utilisant GORM.
+type GormVisitorRepository struct {
+	db *gorm.DB
+}
+
+// NewVisitorRepository crée et retourne une nouvelle instance de GormVisitorRepository.
+func NewVisitorRepository(db *gorm.DB) *GormVisitorRepository {
+	return &GormVisitorRepository{db: db}
+}
+
+// GetOrCreateSalt retourne le sel du jour 'day' (minuit UTC), en le tirant au hasard s'il n'existe pas encore.
+// Si deux processus créent le sel en même temps, le premier enregistré est retourné aux deux.
+func (r *GormVisitorRepository) GetOrCreateSalt(day time.Time) ([]byte, error) {
+	var salt models.VisitorSalt
+	err := r.db.Where("day = ?", day).First(&salt).Error
+	if err == nil {
+		return salt.Salt, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	value := make([]byte, saltSize)
+	if _, err := rand.Read(value); err != nil {
+		return nil, err
+	}
+	if err := r.db.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&models.VisitorSalt{Day: day, Salt: value}).Error; err != nil {
+		return nil, err
+	}
+	if err := r.db.Where("day = ?", day).First(&salt).Error; err != nil {
+		return nil, err
+	}
+	return salt.Salt, nil
+}
+
+// DeleteSaltsBefore supprime les sels des jours antérieurs à 'day'.
+func (r *GormVisitorRepository) DeleteSaltsBefore(day time.Time) error {
+	return r.db.Where("day < ?", day).Delete(&models.VisitorSalt{}).Error
+}
+
+// DeleteSketchesBefore supprime les sketches des jours antérieurs à 'day' et retourne le nombre de lignes supprimées.
+func (r *GormVisitorRepository) DeleteSketchesBefore(day time.Time) (int64, error) {
+	result := r.db.Where("day < ?", day).Delete(&models.VisitorSketch{})
+	return result.RowsAffected, result.Error
+}
+
+// UpdateSketch remplace le sketch d'un lien pour le jour 'day' par le résultat de 'update',
+// qui reçoit le sketch actuel (vide s'il n'existe pas encore). La ligne est verrouillée pendant
+// la transaction pour qu'une mise à jour concurrente ne soit pas perdue.
+func (r *GormVisitorRepository) UpdateSketch(linkID uint, day time.Time, update func(current []byte) ([]byte, error)) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		// La transaction commence par une écriture : SQLite prend alors le verrou d'écriture dès le début,
+		// au lieu d'échouer en tentant de promouvoir un verrou de lecture pendant qu'un autre worker écrit.
+		err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&models.VisitorSketch{LinkID: linkID, Day: day, Sketch: []byte{}}).Error
+		if err != nil {
+			return err
+		}
+
+		var sketch models.VisitorSketch
+		err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("link_id = ? AND day = ?", linkID, day).First(&sketch).Error
+		if err != nil {
+			return err
+		}
+
+		data, err := update(sketch.Sketch)
+		if err != nil {
+			return err
+		}
+		return tx.Model(&sketch).Update("sketch", data).Error
+	})
+}
+
+// GetSketches retourne les sketches d'un lien pour les jours compris entre 'from' (inclus) et 'to' (exclu),
+// par ordre chronologique. Les bornes laissées à leur valeur zéro ne filtrent pas.
+func (r *GormVisitorRepository) GetSketches(linkID uint, from, to time.Time) ([]models.VisitorSketch, error) {
+	query := r.db.Where("link_id = ?", linkID)
+	if !from.IsZero() {
+		query = query.Where("day >= ?", from)
+	}
+	if !to.IsZero() {
+		query = query.Where("day < ?", to)
+	}
+
+	var sketches []models.VisitorSketch
+	if err := query.Order("day").Find(&sketches).Error; err != nil {
+		return nil, err
+	}
+	return sketches, nil
+}
@@ -0,0 +1,174 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrWorkspaceNotEmpty est retournée par DeleteWorkspace quand l'espace de travail possède encore des liens.
+var ErrWorkspaceNotEmpty = errors.New("workspace still owns links")
+
+// WorkspaceRepository définit les méthodes d'accès aux données des espaces de travail et de leurs membres.
+type WorkspaceRepository interface {
+	CreateWorkspace(workspace *models.Workspace) error
+	GetWorkspaceByID(id uint) (*models.Workspace, error)
+	GetWorkspaceByName(name string) (*models.Workspace, error)
+	ListWorkspaces() ([]models.Workspace, error)
+	ListWorkspacesForAPIKey(apiKeyID uint) ([]WorkspaceWithRole, error)
+	UpdateWorkspace(workspace *models.Workspace) error
+	DeleteWorkspace(id uint) error
+	GetMember(workspaceID, apiKeyID uint) (*models.WorkspaceMember, error)
+	ListMembers(workspaceID uint) ([]models.WorkspaceMember, error)
+	SaveMember(member *models.WorkspaceMember) error
+	DeleteMember(workspaceID, apiKeyID uint) error
+	CountOwners(workspaceID uint) (int64, error)
+}
+
+// WorkspaceWithRole est un espace de travail accompagné du rôle qu'y tient une clé d'API.
+type WorkspaceWithRole struct {
+	models.Workspace
+	Role string
+}
+
+// GormWorkspaceRepository implémente WorkspaceRepository avec GORM.
+type GormWorkspaceRepository struct {
+	db *gorm.DB
+}
+
+// NewWorkspaceRepository crée et retourne une nouvelle instance de GormWorkspaceRepository.
+func NewWorkspaceRepository(db *gorm.DB) *GormWorkspaceRepository {
+	return &GormWorkspaceRepository{db: db}
+}
+
+// CreateWorkspace insère un nouvel espace de travail.
+func (r *GormWorkspaceRepository) CreateWorkspace(workspace *models.Workspace) error {
+	return r.db.Create(workspace).Error
+}
+
+// GetWorkspaceByID récupère un espace de travail par son identifiant.
+// Il renvoie gorm.ErrRecordNotFound si aucun espace de travail ne correspond.
+func (r *GormWorkspaceRepository) GetWorkspaceByID(id uint) (*models.Workspace, error) {
+	var workspace models.Workspace
+	if err := r.db.First(&workspace, id).Error; err != nil {
+		return nil, err
+	}
+	return &workspace, nil
+}
+
+// GetWorkspaceByName récupère un espace de travail par son nom.
+// Il renvoie gorm.ErrRecordNotFound si aucun espace de travail ne correspond.
+func (r *GormWorkspaceRepository) GetWorkspaceByName(name string) (*models.Workspace, error) {
+	var workspace models.Workspace
+	if err := r.db.Where("name = ?", name).First(&workspace).Error; err != nil {
+		return nil, err
+	}
+	return &workspace, nil
+}
+
+// ListWorkspaces retourne tous les espaces de travail, par ordre de création.
+func (r *GormWorkspaceRepository) ListWorkspaces() ([]models.Workspace, error) {
+	var workspaces []models.Workspace
+	if err := r.db.Order("id").Find(&workspaces).Error; err != nil {
+		return nil, err
+	}
+	return workspaces, nil
+}
+
+// ListWorkspacesForAPIKey retourne les espaces de travail dont une clé d'API est membre, avec son rôle.
+func (r *GormWorkspaceRepository) ListWorkspacesForAPIKey(apiKeyID uint) ([]WorkspaceWithRole, error) {
+	var workspaces []WorkspaceWithRole
+	err := r.db.Model(&models.Workspace{}).
+		Select("workspaces.*, workspace_members.role").
+		Joins("JOIN workspace_members ON workspace_members.workspace_id = workspaces.id").
+		Where("workspace_members.api_key_id = ?", apiKeyID).
+		Order("workspaces.id").
+		Scan(&workspaces).Error
+	if err != nil {
+		return nil, err
+	}
+	return workspaces, nil
+}
+
+// UpdateWorkspace enregistre les modifications d'un espace de travail existant.
+func (r *GormWorkspaceRepository) UpdateWorkspace(workspace *models.Workspace) error {
+	return r.db.Save(workspace).Error
+}
+
+// DeleteWorkspace supprime un espace de travail et ses membres, dans une même transaction.
+// Il renvoie ErrWorkspaceNotEmpty si des liens appartiennent encore à l'espace,
+// et gorm.ErrRecordNotFound si l'espace n'existe pas.
+func (r *GormWorkspaceRepository) DeleteWorkspace(id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var links int64
+		if err := tx.Model(&models.Link{}).Where("workspace_id = ?", id).Count(&links).Error; err != nil {
+			return err
+		}
+		if links > 0 {
+			return ErrWorkspaceNotEmpty
+		}
+		if err := tx.Where("workspace_id = ?", id).Delete(&models.WorkspaceMember{}).Error; err != nil {
+			return err
+		}
+		result := tx.Delete(&models.Workspace{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+}
+
+// GetMember récupère l'appartenance d'une clé d'API à un espace de travail.
+// Il renvoie gorm.ErrRecordNotFound si la clé n'en est pas membre.
+func (r *GormWorkspaceRepository) GetMember(workspaceID, apiKeyID uint) (*models.WorkspaceMember, error) {
+	var member models.WorkspaceMember
+	err := r.db.Where("workspace_id = ? AND api_key_id = ?", workspaceID, apiKeyID).First(&member).Error
+	if err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
+
+// ListMembers retourne les membres d'un espace de travail, par ordre d'ajout.
+func (r *GormWorkspaceRepository) ListMembers(workspaceID uint) ([]models.WorkspaceMember, error) {
+	var members []models.WorkspaceMember
+	if err := r.db.Where("workspace_id = ?", workspaceID).Order("id").Find(&members).Error; err != nil {
+		return nil, err
+	}
+	return members, nil
+}
+
+// SaveMember ajoute une clé d'API à un espace de travail, ou change son rôle si elle en est déjà membre.
+func (r *GormWorkspaceRepository) SaveMember(member *models.WorkspaceMember) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "workspace_id"}, {Name: "api_key_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"role"}),
+	}).Create(member).Error
+}
+
+// DeleteMember retire une clé d'API d'un espace de travail.
+// Il renvoie gorm.ErrRecordNotFound si la clé n'en était pas membre.
+func (r *GormWorkspaceRepository) DeleteMember(workspaceID, apiKeyID uint) error {
+	result := r.db.Where("workspace_id = ? AND api_key_id = ?", workspaceID, apiKeyID).Delete(&models.WorkspaceMember{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// CountOwners compte les propriétaires d'un espace de travail.
+func (r *GormWorkspaceRepository) CountOwners(workspaceID uint) (int64, error) {
+	var owners int64
+	err := r.db.Model(&models.WorkspaceMember{}).
+		Where("workspace_id = ? AND role = ?", workspaceID, models.RoleOwner).
+		Count(&owners).Error
+	return owners, err
+}
@@ -0,0 +1,36 @@
+package services
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Bornes de longueur d'un alias personnalisé.
+// La borne haute correspond à la taille de la colonne 'shortcode' de models.Link.
+const (
+	minAliasLength = 3
+	maxAliasLength = 32
+)
+
+// aliasPattern limite les alias aux caractères sûrs dans un chemin d'URL.
+var aliasPattern = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+// reservedAliases contient les codes qui entreraient en conflit avec les routes du service.
+// La comparaison est insensible à la casse.
+var reservedAliases = map[string]struct{}{
+	"health":  {},
+	"api":     {},
+	"metrics": {},
+}
+
+// ValidateAlias vérifie qu'un alias personnalisé peut être utilisé comme code court :
+// longueur, jeu de caractères et mots réservés.
+func ValidateAlias(alias string) error {
+	if len(alias) < minAliasLength || len(alias) > maxAliasLength || !aliasPattern.MatchString(alias) {
+		return ErrInvalidAlias
+	}
+	if _, reserved := reservedAliases[strings.ToLower(alias)]; reserved {
+		return ErrReservedAlias
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package services
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateAlias(t *testing.T) {
+	tests := []struct {
+		name    string
+		alias   string
+		wantErr error
+	}{
+		{"letters and digits", "Promo2025", nil},
+		{"dash and underscore", "my_promo-link", nil},
+		{"minimum length", "abc", nil},
+		{"maximum length", strings.Repeat("a", maxAliasLength), nil},
+		{"empty", "", ErrInvalidAlias},
+		{"too short", "ab", ErrInvalidAlias},
+		{"too long", strings.Repeat("a", maxAliasLength+1), ErrInvalidAlias},
+		{"slash", "a/b/c", ErrInvalidAlias},
+		{"space", "my link", ErrInvalidAlias},
+		{"dot", "file.txt", ErrInvalidAlias},
+		{"non-ascii letter", "café", ErrInvalidAlias},
+		{"reserved route", "health", ErrReservedAlias},
+		{"reserved route, other case", "API", ErrReservedAlias},
+		{"reserved prefix only", "metrics2", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateAlias(tt.alias); err != tt.wantErr {
+				t.Errorf("ValidateAlias(%q) = %v, want %v", tt.alias, err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/repository"
+)
+
+// Format des clés d'API : le préfixe fixe permet de les repérer (par exemple dans un dépôt de code),
+// suivi de 32 caractères aléatoires en base64 URL.
+const (
+	apiKeyPrefix        = "usk_"
+	apiKeyRandomBytes   = 24
+	apiKeyDisplayLength = len(apiKeyPrefix) + 8 // Partie non secrète conservée pour identifier la clé
+	maxAPIKeyNameLength = 100
+)
+
+// lastUsedResolution évite d'écrire en base à chaque requête : la date de dernière utilisation
+// n'est mise à jour que si la précédente date de plus longtemps.
+const lastUsedResolution = time.Minute
+
+// APIKeyService gère la création, la révocation et la vérification des clés d'API.
+type APIKeyService struct {
+	apiKeyRepo repository.APIKeyRepository
+}
+
+// NewAPIKeyService crée et retourne une nouvelle instance de APIKeyService.
+func NewAPIKeyService(apiKeyRepo repository.APIKeyRepository) *APIKeyService {
+	return &APIKeyService{apiKeyRepo: apiKeyRepo}
+}
+
+// CreateAPIKey génère une nouvelle clé d'API nommée 'name' et l'enregistre.
+// Une clé d'administration ('admin') gère les espaces de travail.
+// Il retourne la clé enregistrée et sa valeur en clair, qui ne pourra plus être retrouvée ensuite.
+func (s *APIKeyService) CreateAPIKey(name string, admin bool) (*models.APIKey, string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > maxAPIKeyNameLength {
+		return nil, "", ErrInvalidAPIKeyName
+	}
+
+	b := make([]byte, apiKeyRandomBytes)
+	if _, err := rand.Read(b); err != nil {
+		return nil, "", fmt.Errorf("failed to generate API key: %w", err)
+	}
+	plaintext := apiKeyPrefix + base64.RawURLEncoding.EncodeToString(b)
+
+	key := &models.APIKey{
+		Name:      name,
+		Prefix:    plaintext[:apiKeyDisplayLength],
+		KeyHash:   HashAPIKey(plaintext),
+		IsAdmin:   admin,
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := s.apiKeyRepo.CreateAPIKey(key); err != nil {
+		return nil, "", fmt.Errorf("failed to save API key: %w", err)
+	}
+	return key, plaintext, nil
+}
+
+// ListAPIKeys retourne toutes les clés d'API, y compris les clés révoquées.
+func (s *APIKeyService) ListAPIKeys() ([]models.APIKey, error) {
+	return s.apiKeyRepo.ListAPIKeys()
+}
+
+// RevokeAPIKey révoque une clé d'API : elle ne permet plus de s'authentifier.
+// Il renvoie gorm.ErrRecordNotFound si aucune clé active n'a cet identifiant.
+func (s *APIKeyService) RevokeAPIKey(id uint) error {
+	return s.apiKeyRepo.RevokeAPIKey(id, time.Now().UTC())
+}
+
+// Authenticate retourne la clé active correspondant à la valeur en clair 'plaintext',
+// ou ErrInvalidAPIKey si elle est inconnue ou révoquée.
+func (s *APIKeyService) Authenticate(plaintext string) (*models.APIKey, error) {
+	if !strings.HasPrefix(plaintext, apiKeyPrefix) {
+		return nil, ErrInvalidAPIKey
+	}
+	key, err := s.apiKeyRepo.GetAPIKeyByHash(HashAPIKey(plaintext))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidAPIKey
+		}
+		return nil, err
+	}
+	if key.RevokedAt != nil {
+		return nil, ErrInvalidAPIKey
+	}
+
+	now := time.Now().UTC()
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= lastUsedResolution {
+		if err := s.apiKeyRepo.TouchAPIKey(key.ID, now); err != nil {
+			log.Printf("WARNING: Failed to record last use of API key %d: %v", key.ID, err)
+		} else {
+			key.LastUsedAt = &now
+		}
+	}
+	return key, nil
+}
+
+// HashAPIKey retourne l'empreinte SHA-256 (hexadécimale) d'une clé d'API, telle qu'elle est stockée.
+// Les clés étant aléatoires et longues, un hachage lent n'est pas nécessaire.
+func HashAPIKey(plaintext string) string {
+	sum := sha256.Sum256([]byte(plaintext))
+	return hex.EncodeToString(sum[:])
+}
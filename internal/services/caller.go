@@ -0,0 +1,83 @@
+package services
+
+import (
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/repository"
+)
+
+// Caller identifie l'auteur d'une opération sur les liens faite par l'API, et le périmètre dans lequel il agit :
+// un espace de travail (choisi par l'en-tête X-Workspace-ID) où il a un rôle, ou ses liens personnels.
+// Un Caller nil n'est soumis à aucune restriction : il est réservé à la CLI.
+// Quand l'authentification est désactivée, l'API agit avec un Caller anonyme (APIKeyID nul),
+// limité aux liens créés sans clé d'API.
+type Caller struct {
+	APIKeyID      uint   // Clé d'API authentifiée, 0 pour un appel anonyme
+	Admin         bool   // Clé d'administration : hors espace de travail, accès à tous les liens
+	WorkspaceID   uint   // Espace de travail de la requête, 0 pour les liens personnels de la clé
+	Role          string // Rôle dans WorkspaceID (models.RoleOwner pour une clé d'administration)
+	HasWorkspaces bool   // La clé est membre d'au moins un espace de travail
+}
+
+// WorkspaceRequired indique si le caller doit choisir un espace de travail pour accéder aux liens :
+// une clé membre d'un espace de travail n'a pas de liens personnels, ses listes et statistiques
+// sont toujours limitées à un espace. Une clé d'administration n'y est pas soumise.
+func (c *Caller) WorkspaceRequired() bool {
+	return c != nil && !c.Admin && c.WorkspaceID == 0 && c.HasWorkspaces
+}
+
+// canRead indique si le caller peut consulter 'link' : un lien de son espace de travail,
+// ou, hors espace de travail, un lien personnel qu'il a créé (tous les liens pour une clé d'administration).
+func (c *Caller) canRead(link *models.Link) bool {
+	if c == nil {
+		return true
+	}
+	if c.WorkspaceID != 0 {
+		return link.WorkspaceID != nil && *link.WorkspaceID == c.WorkspaceID
+	}
+	if c.Admin {
+		return true
+	}
+	if c.APIKeyID == 0 {
+		return link.WorkspaceID == nil && link.CreatedBy == nil
+	}
+	return link.WorkspaceID == nil && link.CreatedBy != nil && *link.CreatedBy == c.APIKeyID
+}
+
+// canWrite indique si le caller peut créer, modifier ou supprimer des liens dans son périmètre.
+// Dans un espace de travail, le rôle viewer ne le permet pas ; une clé d'administration y est propriétaire.
+func (c *Caller) canWrite() bool {
+	if c == nil || c.Admin || c.WorkspaceID == 0 {
+		return true
+	}
+	return c.Role == models.RoleOwner || c.Role == models.RoleEditor
+}
+
+// scope retourne le périmètre des liens visibles par le caller.
+func (c *Caller) scope() repository.LinkScope {
+	switch {
+	case c == nil:
+		return repository.AllLinks
+	case c.WorkspaceID != 0:
+		return repository.WorkspaceScope(c.WorkspaceID)
+	case c.Admin:
+		return repository.AllLinks
+	case c.APIKeyID == 0:
+		return repository.UnownedScope
+	default:
+		return repository.PersonalScope(c.APIKeyID)
+	}
+}
+
+// assignOwner enregistre le caller comme créateur de 'link' et, s'il agit dans un espace de travail,
+// rattache le lien à cet espace.
+func (c *Caller) assignOwner(link *models.Link) {
+	if c == nil || c.APIKeyID == 0 {
+		return
+	}
+	apiKeyID := c.APIKeyID
+	link.CreatedBy = &apiKeyID
+	if c.WorkspaceID != 0 {
+		workspaceID := c.WorkspaceID
+		link.WorkspaceID = &workspaceID
+	}
+}
@@ -2,8 +2,11 @@ package services
 
 import (
 	"errors"
+	"net"
+	"time"
 
 	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/privacy"
 	"github.com/axellelanca/urlshortener/internal/repository" // Importe le package repository
 )
 
@@ -44,3 +47,243 @@ func (s *ClickService) GetClicksCountByLinkID(linkID uint) (int, error) {
 	}
 	return count, nil // Retourne le nombre de clics
 }
+
+// Intervalles d'agrégation acceptés par GetClickTimeSeries.
+const (
+	IntervalHour = "hour"
+	IntervalDay  = "day"
+	IntervalWeek = "week"
+)
+
+// MaxTimeSeriesBuckets borne le nombre d'intervalles d'une série temporelle.
+const MaxTimeSeriesBuckets = 1000
+
+// timeSlot est la granularité de l'agrégation faite par la base de données.
+// Tous les fuseaux horaires ont un décalage multiple de 15 minutes : des tranches de 15 minutes
+// peuvent donc être regroupées exactement en heures, jours ou semaines locales.
+const timeSlot = 15 * time.Minute
+
+// defaultTimeSeriesSpans est la période couverte par défaut quand 'from' n'est pas fourni.
+var defaultTimeSeriesSpans = map[string]time.Duration{
+	IntervalHour: 24 * time.Hour,
+	IntervalDay:  30 * 24 * time.Hour,
+	IntervalWeek: 12 * 7 * 24 * time.Hour,
+}
+
+// TimeSeriesOptions regroupe les paramètres d'une série temporelle de clics.
+type TimeSeriesOptions struct {
+	Interval string         // hour, day ou week
+	From     time.Time      // Début de la période (inclus), par défaut To moins une période dépendant de l'intervalle
+	To       time.Time      // Fin de la période (exclue), par défaut maintenant
+	Location *time.Location // Fuseau horaire dans lequel les intervalles sont découpés, UTC par défaut
+}
+
+// TimeSeries est le nombre de clics d'un lien par intervalle de temps.
+// Buckets contient un élément par intervalle de la période, y compris ceux sans clic.
+type TimeSeries struct {
+	Interval string
+	From     time.Time
+	To       time.Time
+	Location *time.Location
+	Buckets  []models.ClickBucket
+	Total    int
+}
+
+// GetClickTimeSeries retourne le nombre de clics d'un lien par heure, jour ou semaine.
+// Les intervalles sont découpés dans le fuseau horaire demandé (les semaines commencent le lundi)
+// et les intervalles sans clic sont présents avec un compte de zéro.
+func (s *ClickService) GetClickTimeSeries(linkID uint, opts TimeSeriesOptions) (*TimeSeries, error) {
+	span, ok := defaultTimeSeriesSpans[opts.Interval]
+	if !ok {
+		return nil, ErrInvalidInterval
+	}
+	if opts.Location == nil {
+		opts.Location = time.UTC
+	}
+	if opts.To.IsZero() {
+		opts.To = time.Now()
+	}
+	if opts.From.IsZero() {
+		opts.From = opts.To.Add(-span)
+	}
+	if !opts.From.Before(opts.To) {
+		return nil, ErrInvalidTimeRange
+	}
+
+	// Construit la liste complète des intervalles avant d'interroger la base,
+	// ce qui permet de refuser une période trop longue sans faire la requête.
+	first := bucketStart(opts.From, opts.Interval, opts.Location)
+	var buckets []models.ClickBucket
+	index := make(map[time.Time]int)
+	for start := first; start.Before(opts.To); start = nextBucket(start, opts.Interval) {
+		if len(buckets) == MaxTimeSeriesBuckets {
+			return nil, ErrTooManyBuckets
+		}
+		index[start] = len(buckets)
+		buckets = append(buckets, models.ClickBucket{Start: start})
+	}
+
+	slots, err := s.clickRepo.CountClicksByTimeSlot(repository.ClickFilter{
+		LinkID: linkID,
+		From:   opts.From,
+		To:     opts.To,
+	}, timeSlot)
+	if err != nil {
+		return nil, err
+	}
+
+	total := 0
+	for _, slot := range slots {
+		if i, ok := index[bucketStart(slot.Start, opts.Interval, opts.Location)]; ok {
+			buckets[i].Count += slot.Count
+			total += slot.Count
+		}
+	}
+
+	return &TimeSeries{
+		Interval: opts.Interval,
+		From:     opts.From,
+		To:       opts.To,
+		Location: opts.Location,
+		Buckets:  buckets,
+		Total:    total,
+	}, nil
+}
+
+// bucketStart retourne le début de l'intervalle contenant t, dans le fuseau loc.
+func bucketStart(t time.Time, interval string, loc *time.Location) time.Time {
+	t = t.In(loc)
+	switch interval {
+	case IntervalHour:
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, loc)
+	case IntervalWeek:
+		// time.Weekday commence le dimanche (0) : on recule jusqu'au lundi.
+		offset := (int(t.Weekday()) + 6) % 7
+		return time.Date(t.Year(), t.Month(), t.Day()-offset, 0, 0, 0, 0, loc)
+	default:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+	}
+}
+
+// nextBucket retourne le début de l'intervalle qui suit celui commençant à start.
+// Les jours et semaines sont avancés en date calendaire pour rester corrects aux changements d'heure.
+func nextBucket(start time.Time, interval string) time.Time {
+	switch interval {
+	case IntervalHour:
+		return start.Add(time.Hour)
+	case IntervalWeek:
+		return start.AddDate(0, 0, 7)
+	default:
+		return start.AddDate(0, 0, 1)
+	}
+}
+
+// DefaultTopLimit est le nombre de valeurs retournées par défaut dans un classement.
+const DefaultTopLimit = 10
+
+// MaxTopLimit borne le nombre de valeurs d'un classement.
+const MaxTopLimit = 100
+
+// ReferrerStats est le classement des domaines référents d'un lien sur une période.
+type ReferrerStats struct {
+	Referrers []models.DimensionCount // Domaines référents, par nombre de clics décroissant
+	Direct    int                     // Clics sans référent (accès direct)
+}
+
+// GetTopReferrers retourne les 'limit' domaines qui ont envoyé le plus de clics vers un lien
+// entre 'from' (inclus) et 'to' (exclu), ainsi que le nombre de clics directs.
+// Des bornes à leur valeur zéro ne restreignent pas la période.
+func (s *ClickService) GetTopReferrers(linkID uint, from, to time.Time, limit int) (*ReferrerStats, error) {
+	if !from.IsZero() && !to.IsZero() && !from.Before(to) {
+		return nil, ErrInvalidTimeRange
+	}
+	if limit < 1 {
+		limit = DefaultTopLimit
+	}
+	if limit > MaxTopLimit {
+		limit = MaxTopLimit
+	}
+
+	filter := repository.ClickFilter{LinkID: linkID, From: from, To: to}
+	referrers, err := s.clickRepo.CountClicksByDimension(filter, repository.DimensionReferrer, limit)
+	if err != nil {
+		return nil, err
+	}
+	direct, err := s.clickRepo.CountClicksWithoutDimension(filter, repository.DimensionReferrer)
+	if err != nil {
+		return nil, err
+	}
+	return &ReferrerStats{Referrers: referrers, Direct: direct}, nil
+}
+
+// breakdownDimensions associe les noms de dimension exposés aux clients aux dimensions du repository.
+var breakdownDimensions = map[string]repository.ClickDimension{
+	"browser": repository.DimensionBrowser,
+	"os":      repository.DimensionOS,
+	"device":  repository.DimensionDevice,
+	"country": repository.DimensionCountry,
+	"city":    repository.DimensionCity,
+}
+
+// BreakdownOptions regroupe les paramètres de GetClickBreakdown.
+type BreakdownOptions struct {
+	From        time.Time // Début de la période (incluse), zéro pour ne pas borner
+	To          time.Time // Fin de la période (exclue), zéro pour ne pas borner
+	ExcludeBots bool      // Ignore les clics de robots
+	Limit       int       // Nombre maximal de valeurs, DefaultTopLimit par défaut
+}
+
+// Breakdown est la répartition des clics d'un lien selon une dimension.
+type Breakdown struct {
+	Dimension string
+	Values    []models.DimensionCount // Valeurs connues, par nombre de clics décroissant
+	Unknown   int                     // Clics dont la valeur n'a pas pu être déterminée
+}
+
+// GetClickBreakdown répartit les clics d'un lien selon une dimension : browser, os, device, country ou city.
+func (s *ClickService) GetClickBreakdown(linkID uint, dimension string, opts BreakdownOptions) (*Breakdown, error) {
+	column, ok := breakdownDimensions[dimension]
+	if !ok {
+		return nil, ErrInvalidDimension
+	}
+	if !opts.From.IsZero() && !opts.To.IsZero() && !opts.From.Before(opts.To) {
+		return nil, ErrInvalidTimeRange
+	}
+	if opts.Limit < 1 {
+		opts.Limit = DefaultTopLimit
+	}
+	if opts.Limit > MaxTopLimit {
+		opts.Limit = MaxTopLimit
+	}
+
+	filter := repository.ClickFilter{LinkID: linkID, From: opts.From, To: opts.To, ExcludeBots: opts.ExcludeBots}
+	values, err := s.clickRepo.CountClicksByDimension(filter, column, opts.Limit)
+	if err != nil {
+		return nil, err
+	}
+	unknown, err := s.clickRepo.CountClicksWithoutDimension(filter, column)
+	if err != nil {
+		return nil, err
+	}
+	return &Breakdown{Dimension: dimension, Values: values, Unknown: unknown}, nil
+}
+
+// CountClicks retourne le nombre total de clics d'un lien, en ignorant éventuellement ceux des robots.
+func (s *ClickService) CountClicks(linkID uint, excludeBots bool) (int, error) {
+	return s.clickRepo.CountClicks(repository.ClickFilter{LinkID: linkID, ExcludeBots: excludeBots})
+}
+
+// CountFallbackClicks retourne le nombre de clics d'un lien redirigés vers son URL de secours,
+// en ignorant éventuellement ceux des robots. Les clics agrégés par la politique de rétention n'en font pas partie.
+func (s *ClickService) CountFallbackClicks(linkID uint, excludeBots bool) (int, error) {
+	return s.clickRepo.CountClicks(repository.ClickFilter{LinkID: linkID, ExcludeBots: excludeBots, Fallback: true})
+}
+
+// EraseClicksByIP supprime tous les clics enregistrés pour l'adresse IP 'ip', qu'elle ait été
+// stockée en clair ou sous la forme produite par 'anonymizer', et retourne le nombre de clics supprimés.
+func (s *ClickService) EraseClicksByIP(ip string, anonymizer *privacy.IPAnonymizer) (int64, error) {
+	if net.ParseIP(ip) == nil {
+		return 0, ErrInvalidIP
+	}
+	return s.clickRepo.DeleteClicksByIP(anonymizer.StoredForms(ip))
+}
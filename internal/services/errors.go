@@ -0,0 +1,55 @@
+package services
+
+import "errors"
+
+// Erreurs métier retournées par les services.
+// Les handlers HTTP et les commandes CLI les testent avec errors.Is pour choisir
+// le code de statut ou le message à afficher.
+var (
+	// ErrInvalidAlias est retournée quand l'alias demandé ne respecte pas le format autorisé.
+	ErrInvalidAlias = errors.New("invalid alias: use 3 to 32 characters among letters, digits, '-' and '_'")
+	// ErrReservedAlias est retournée quand l'alias demandé entre en conflit avec une route du service.
+	ErrReservedAlias = errors.New("alias is reserved")
+	// ErrAliasTaken est retournée quand l'alias demandé est déjà utilisé par un autre lien.
+	ErrAliasTaken = errors.New("alias already in use")
+	// ErrInvalidExpiration est retournée quand la date d'expiration demandée est déjà passée.
+	ErrInvalidExpiration = errors.New("expiration date must be in the future")
+	// ErrInvalidMaxClicks est retournée quand la limite de clics demandée est négative.
+	ErrInvalidMaxClicks = errors.New("max clicks must be zero (unlimited) or positive")
+	// ErrInvalidFallbackURL est retournée quand l'URL de secours demandée n'est pas une URL absolue http ou https.
+	ErrInvalidFallbackURL = errors.New("invalid fallback URL: use an absolute http or https URL")
+	// ErrLinkExpired est retournée quand un lien a dépassé sa date d'expiration ou sa limite de clics.
+	ErrLinkExpired = errors.New("link has expired")
+	// ErrInvalidSortField est retournée quand la colonne de tri demandée n'est pas autorisée.
+	ErrInvalidSortField = errors.New("invalid sort field: use created_at, short_code or long_url")
+	// ErrInvalidInterval est retournée quand l'intervalle d'agrégation demandé n'est pas reconnu.
+	ErrInvalidInterval = errors.New("invalid interval: use hour, day or week")
+	// ErrInvalidTimeRange est retournée quand la date de début n'est pas antérieure à la date de fin.
+	ErrInvalidTimeRange = errors.New("invalid time range: 'from' must be before 'to'")
+	// ErrTooManyBuckets est retournée quand la période demandée produirait trop d'intervalles.
+	ErrTooManyBuckets = errors.New("time range too large for this interval")
+	// ErrInvalidDimension est retournée quand la dimension de répartition demandée n'est pas reconnue.
+	ErrInvalidDimension = errors.New("invalid dimension: use browser, os, device, country or city")
+	// ErrInvalidIP est retournée quand la valeur fournie n'est pas une adresse IPv4 ou IPv6.
+	ErrInvalidIP = errors.New("invalid IP address")
+	// ErrInvalidAPIKey est retournée quand la clé d'API présentée est inconnue ou révoquée.
+	ErrInvalidAPIKey = errors.New("invalid API key")
+	// ErrInvalidAPIKeyName est retournée quand le nom d'une nouvelle clé d'API est vide ou trop long.
+	ErrInvalidAPIKeyName = errors.New("invalid API key name: use 1 to 100 characters")
+	// ErrForbidden est retournée quand le rôle ou les droits de l'appelant ne permettent pas l'opération.
+	ErrForbidden = errors.New("insufficient permissions for this operation")
+	// ErrNotWorkspaceMember est retournée quand la clé d'API n'est pas membre de l'espace de travail demandé.
+	ErrNotWorkspaceMember = errors.New("API key is not a member of this workspace")
+	// ErrWorkspaceRequired est retournée quand une clé membre d'un espace de travail accède aux liens sans en choisir un.
+	ErrWorkspaceRequired = errors.New("X-Workspace-ID is required: this API key belongs to a workspace")
+	// ErrInvalidWorkspaceName est retournée quand le nom d'un nouvel espace de travail est vide ou trop long.
+	ErrInvalidWorkspaceName = errors.New("invalid workspace name: use 1 to 100 characters")
+	// ErrWorkspaceNameTaken est retournée quand un espace de travail porte déjà le nom demandé.
+	ErrWorkspaceNameTaken = errors.New("workspace name already in use")
+	// ErrInvalidRole est retournée quand le rôle demandé n'est pas reconnu.
+	ErrInvalidRole = errors.New("invalid role: use owner, editor or viewer")
+	// ErrUnknownAPIKey est retournée quand la clé d'API à ajouter à un espace de travail n'existe pas ou est révoquée.
+	ErrUnknownAPIKey = errors.New("unknown or revoked API key")
+	// ErrLastOwner est retournée quand une opération retirerait le dernier propriétaire d'un espace de travail.
+	ErrLastOwner = errors.New("workspace must keep at least one owner")
+)
@@ -0,0 +1,47 @@
+package services
+
+import (
+	"log"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/repository"
+)
+
+// LinkStates donne l'état des URLs longues déclaré par le moniteur d'URLs.
+type LinkStates interface {
+	// IsDown indique si l'URL longue du lien est actuellement déclarée inaccessible.
+	IsDown(linkID uint) bool
+}
+
+// FallbackService choisit l'URL de secours vers laquelle rediriger un lien dont l'URL longue est inaccessible.
+type FallbackService struct {
+	workspaceRepo repository.WorkspaceRepository
+	states        LinkStates
+}
+
+// NewFallbackService crée et retourne une nouvelle instance de FallbackService.
+func NewFallbackService(workspaceRepo repository.WorkspaceRepository, states LinkStates) *FallbackService {
+	return &FallbackService{workspaceRepo: workspaceRepo, states: states}
+}
+
+// FallbackTarget retourne l'URL de secours vers laquelle rediriger 'link' et vrai si le moniteur déclare
+// son URL longue inaccessible. L'URL de secours du lien est prioritaire sur celle de son espace de travail ;
+// sans l'une ni l'autre, ou si l'URL longue est accessible, le lien redirige normalement.
+// L'espace de travail n'est lu que pour les liens déclarés inaccessibles.
+func (s *FallbackService) FallbackTarget(link *models.Link) (string, bool) {
+	if s == nil || !s.states.IsDown(link.ID) {
+		return "", false
+	}
+	if link.FallbackURL != "" {
+		return link.FallbackURL, true
+	}
+	if link.WorkspaceID == nil {
+		return "", false
+	}
+	workspace, err := s.workspaceRepo.GetWorkspaceByID(*link.WorkspaceID)
+	if err != nil {
+		log.Printf("Error retrieving fallback URL of workspace %d for %s: %v", *link.WorkspaceID, link.Shortcode, err)
+		return "", false
+	}
+	return workspace.FallbackURL, workspace.FallbackURL != ""
+}
@@ -0,0 +1,167 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/repository"
+)
+
+const (
+	// defaultHealthPeriod est la période couverte par GetLinkHealth quand 'from' n'est pas précisé.
+	defaultHealthPeriod = 7 * 24 * time.Hour
+	// maxHealthTransitions limite le nombre de changements d'état et de contenu retournés par GetLinkHealth.
+	maxHealthTransitions = 50
+)
+
+// LinkHealth résume l'état de l'URL longue d'un lien d'après l'historique du moniteur.
+type LinkHealth struct {
+	Last          *models.LinkCheck // Dernière vérification, nil si le lien n'a jamais été vérifié
+	From, To      time.Time         // Période couverte par Checks, UptimePercent et Transitions
+	Checks        int               // Nombre de vérifications sur la période
+	UptimePercent float64           // Part des vérifications de la période où l'URL était accessible
+	Transitions   []StateTransition // Changements de l'état déclaré sur la période, du plus récent au plus ancien
+	// TLSExpiresAt est la date d'expiration du certificat TLS relevée par la dernière vérification qui en a présenté un,
+	// nil si aucune ; TLSExpiring indique si elle est assez proche pour être signalée.
+	TLSExpiresAt *time.Time
+	TLSExpiring  bool
+	// ContentHash est la dernière empreinte du contenu relevée, vide si elle n'est pas calculée.
+	ContentHash string
+	// ContentChanges sont les changements d'empreinte du contenu sur la période, du plus récent au plus ancien.
+	ContentChanges []ContentChange
+}
+
+// StateTransition est un changement de l'état déclaré de l'URL longue d'un lien : la vérification qui l'a constaté.
+type StateTransition struct {
+	At         time.Time
+	Up         bool // Nouvel état
+	StatusCode int
+	ErrorClass string
+}
+
+// ContentChange est un changement de l'empreinte du contenu de l'URL longue d'un lien.
+type ContentChange struct {
+	At           time.Time
+	Hash         string
+	PreviousHash string
+}
+
+// HealthService lit l'historique des vérifications enregistré par le moniteur d'URLs.
+type HealthService struct {
+	checkRepo  repository.LinkCheckRepository
+	tlsWarning time.Duration // Délai avant l'expiration d'un certificat à partir duquel elle est signalée (0: jamais)
+}
+
+// NewHealthService crée et retourne une nouvelle instance de HealthService.
+// 'tlsWarning' est le délai avant l'expiration d'un certificat TLS à partir duquel le moniteur la signale.
+func NewHealthService(checkRepo repository.LinkCheckRepository, tlsWarning time.Duration) *HealthService {
+	return &HealthService{checkRepo: checkRepo, tlsWarning: tlsWarning}
+}
+
+// GetLinkHealth calcule l'état d'un lien sur la période [from, to).
+// 'to' vaut maintenant s'il est zéro, 'from' vaut 'to' moins 7 jours s'il est zéro.
+// Le taux de disponibilité est la part des vérifications réussies, sans pondération par leur durée ;
+// les changements d'état sont ceux de l'état déclaré par le moniteur. Un changement d'état au début
+// de la période est détecté par rapport à la dernière vérification qui la précède, de même qu'un changement
+// de contenu si cette vérification a relevé une empreinte.
+func (s *HealthService) GetLinkHealth(linkID uint, from, to time.Time) (*LinkHealth, error) {
+	if to.IsZero() {
+		to = time.Now().UTC()
+	}
+	if from.IsZero() {
+		from = to.Add(-defaultHealthPeriod)
+	}
+	if !from.Before(to) {
+		return nil, ErrInvalidTimeRange
+	}
+
+	health := &LinkHealth{From: from, To: to, Transitions: []StateTransition{}, ContentChanges: []ContentChange{}}
+	last, err := s.checkRepo.GetLastCheck(linkID, time.Time{})
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	health.Last = last
+
+	previous, err := s.checkRepo.GetLastCheck(linkID, from)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	checks, err := s.checkRepo.GetChecks(linkID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	lastHash := ""
+	if previous != nil {
+		lastHash = previous.ContentHash
+	}
+	up := 0
+	for i := range checks {
+		check := &checks[i]
+		if check.Up {
+			up++
+		}
+		if previous != nil && previous.StateUp != check.StateUp {
+			health.Transitions = append(health.Transitions, StateTransition{
+				At:         check.CheckedAt,
+				Up:         check.StateUp,
+				StatusCode: check.StatusCode,
+				ErrorClass: check.ErrorClass,
+			})
+		}
+		if check.ContentHash != "" {
+			if lastHash != "" && check.ContentHash != lastHash {
+				health.ContentChanges = append(health.ContentChanges, ContentChange{
+					At:           check.CheckedAt,
+					Hash:         check.ContentHash,
+					PreviousHash: lastHash,
+				})
+			}
+			lastHash = check.ContentHash
+		}
+		previous = check
+	}
+	health.Checks = len(checks)
+	if health.Checks > 0 {
+		health.UptimePercent = float64(up) * 100 / float64(health.Checks)
+	}
+
+	health.Transitions = newestFirst(health.Transitions)
+	health.ContentChanges = newestFirst(health.ContentChanges)
+
+	// Le certificat et l'empreinte sont ceux de la dernière vérification qui les a relevés :
+	// une vérification en échec n'en relève pas.
+	for _, check := range checks {
+		if check.TLSExpiresAt != nil {
+			health.TLSExpiresAt = check.TLSExpiresAt
+		}
+		if check.ContentHash != "" {
+			health.ContentHash = check.ContentHash
+		}
+	}
+	if last != nil && last.TLSExpiresAt != nil {
+		health.TLSExpiresAt = last.TLSExpiresAt
+	}
+	if last != nil && last.ContentHash != "" {
+		health.ContentHash = last.ContentHash
+	}
+	if health.TLSExpiresAt != nil && s.tlsWarning > 0 {
+		health.TLSExpiring = time.Until(*health.TLSExpiresAt) <= s.tlsWarning
+	}
+	return health, nil
+}
+
+// newestFirst inverse une liste chronologique pour mettre les éléments les plus récents en premier,
+// dans la limite de maxHealthTransitions.
+func newestFirst[T any](items []T) []T {
+	for i, j := 0, len(items)-1; i < j; i, j = i+1, j-1 {
+		items[i], items[j] = items[j], items[i]
+	}
+	if len(items) > maxHealthTransitions {
+		items = items[:maxHealthTransitions]
+	}
+	return items
+}
@@ -5,6 +5,8 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"net/url"
+	"sync"
 	"time"
 
 	"gorm.io/gorm" // Nécessaire pour la gestion spécifique de gorm.ErrRecordNotFound
@@ -21,12 +23,18 @@ const charset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
 // IMPORTANT : Le champ doit être du type de l'interface (non-pointeur).
 type LinkService struct {
 	linkRepo repository.LinkRepository
+
+	// Redirections des liens limités en nombre de clics dont le clic n'est pas encore persisté,
+	// par identifiant de lien. Elles comptent dans la limite en attendant d'être écrites par les workers.
+	pendingMu     sync.Mutex
+	pendingClicks map[uint]int
 }
 
 // NewLinkService crée et retourne une nouvelle instance de LinkService.
 func NewLinkService(linkRepo repository.LinkRepository) *LinkService {
 	return &LinkService{
-		linkRepo: linkRepo,
+		linkRepo:      linkRepo,
+		pendingClicks: make(map[uint]int),
 	}
 }
 
@@ -45,67 +53,367 @@ func (s *LinkService) GenerateShortCode(length int) (string, error) {
 	return string(b), nil
 }
 
-// CreateLink crée un nouveau lien raccourci.
-// Il génère un code court unique, puis persiste le lien dans la base de données.
-func (s *LinkService) CreateLink(longURL string) (*models.Link, error) {
-	// Essayez de générer un code, vérifiez s'il existe déjà en base, et retentez si une collision est trouvée.
-	// Limitez le nombre de tentatives pour éviter une boucle infinie.
+// CreateLinkOptions regroupe les paramètres optionnels de la création d'un lien.
+type CreateLinkOptions struct {
+	// Alias est le code court personnalisé demandé. S'il est vide, un code aléatoire est généré.
+	Alias string
+	// ExpiresAt est la date après laquelle le lien ne redirige plus. nil pour un lien permanent.
+	ExpiresAt *time.Time
+	// MaxClicks est le nombre de clics après lequel le lien expire. 0 pour illimité.
+	MaxClicks int
+	// FallbackURL est l'URL de secours utilisée tant que le moniteur déclare l'URL longue inaccessible.
+	// Vide pour utiliser celle de l'espace de travail du lien, s'il en a une.
+	FallbackURL string
+}
+
+// CreateLink crée un nouveau lien raccourci dans le périmètre de 'caller', qui en devient le créateur.
+// Si un alias est fourni, il est validé puis utilisé tel quel ; sinon un code court unique est généré.
+// Le lien est ensuite persisté dans la base de données.
+// Il renvoie ErrForbidden si le rôle du caller dans son espace de travail ne permet pas de créer des liens.
+func (s *LinkService) CreateLink(caller *Caller, longURL string, opts CreateLinkOptions) (*models.Link, error) {
+	if !caller.canWrite() {
+		return nil, ErrForbidden
+	}
+	if opts.ExpiresAt != nil && !opts.ExpiresAt.After(time.Now()) {
+		return nil, ErrInvalidExpiration
+	}
+	if opts.MaxClicks < 0 {
+		return nil, ErrInvalidMaxClicks
+	}
+	if err := validateFallbackURL(opts.FallbackURL); err != nil {
+		return nil, err
+	}
 
 	var shortCode string
+	var err error
 
+	if opts.Alias != "" {
+		shortCode, err = s.reserveAlias(opts.Alias)
+	} else {
+		shortCode, err = s.generateUniqueShortCode()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	link := &models.Link{
+		Shortcode:   shortCode,
+		LongURL:     longURL,
+		CreatedAt:   time.Now().UTC(),
+		ExpiresAt:   opts.ExpiresAt,
+		MaxClicks:   opts.MaxClicks,
+		FallbackURL: opts.FallbackURL,
+	}
+	caller.assignOwner(link)
+
+	if err := s.linkRepo.CreateLink(link); err != nil {
+		// Un autre lien a pu prendre l'alias entre la vérification et l'insertion :
+		// la contrainte d'unicité de la base fait alors échouer l'insertion.
+		if opts.Alias != "" {
+			if _, lookupErr := s.linkRepo.GetLinkByShortCode(shortCode); lookupErr == nil {
+				return nil, ErrAliasTaken
+			}
+		}
+		return nil, fmt.Errorf("failed to save link: %w", err)
+	}
+
+	return link, nil
+}
+
+// generateUniqueShortCode génère un code court aléatoire qui n'existe pas encore en base.
+func (s *LinkService) generateUniqueShortCode() (string, error) {
+	// Essayez de générer un code, vérifiez s'il existe déjà en base, et retentez si une collision est trouvée.
+	// Limitez le nombre de tentatives pour éviter une boucle infinie.
 	maxRetries := 5
 
 	for i := 0; i < maxRetries; i++ {
-
 		code, err := s.GenerateShortCode(6)
+		if err != nil {
+			return "", fmt.Errorf("failed to generate short code: %w", err)
+		}
 
-		// On ignore la première valeur
 		_, err = s.linkRepo.GetLinkByShortCode(code)
-
 		if err != nil {
 			// Si l'erreur est 'record not found' de GORM, cela signifie que le code est unique.
 			if errors.Is(err, gorm.ErrRecordNotFound) {
-				shortCode = code // Le code est unique, on peut l'utiliser
-				break            // Sort de la boucle de retry
+				return code, nil
 			}
 			// Si c'est une autre erreur de base de données, retourne l'erreur.
-			return nil, fmt.Errorf("database error checking short code uniqueness: %w", err)
+			return "", fmt.Errorf("database error checking short code uniqueness: %w", err)
 		}
 
 		// Si aucune erreur (le code a été trouvé), cela signifie une collision.
 		log.Printf("Short code '%s' already exists, retrying generation (%d/%d)...", code, i+1, maxRetries)
-		// La boucle continuera pour générer un nouveau code.
 	}
 
-	if shortCode == "" {
-		return nil, errors.New("failed to generate a unique short code after maximum retries")
-	}
+	return "", errors.New("failed to generate a unique short code after maximum retries")
+}
 
-	link := &models.Link{
-		Shortcode: shortCode,
-		LongURL:   longURL,
-		CreatedAt: time.Now().String(),
+// reserveAlias valide un alias personnalisé et vérifie qu'il n'est pas déjà utilisé.
+func (s *LinkService) reserveAlias(alias string) (string, error) {
+	if err := ValidateAlias(alias); err != nil {
+		return "", err
 	}
 
-	s.linkRepo.CreateLink(link)
+	_, err := s.linkRepo.GetLinkByShortCode(alias)
+	if err == nil {
+		return "", ErrAliasTaken
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", fmt.Errorf("database error checking alias uniqueness: %w", err)
+	}
+	return alias, nil
+}
 
+// GetLinkByShortCode récupère un lien via son code court.
+// Il délègue l'opération de recherche au repository, puis vérifie que 'caller' a accès au lien :
+// un lien hors de son périmètre est traité comme inexistant (gorm.ErrRecordNotFound).
+func (s *LinkService) GetLinkByShortCode(caller *Caller, shortCode string) (*models.Link, error) {
+	link, err := s.linkRepo.GetLinkByShortCode(shortCode)
+	if err != nil {
+		// Retourner une erreur si non trouvé/problème DB.
+		return nil, err
+	}
+	if !caller.canRead(link) {
+		return nil, gorm.ErrRecordNotFound
+	}
 	return link, nil
 }
 
-// GetLinkByShortCode récupère un lien via son code court.
-// Il délègue l'opération de recherche au repository.
-func (s *LinkService) GetLinkByShortCode(shortCode string) (*models.Link, error) {
+// ResolveLink récupère le lien à utiliser pour une redirection.
+// Contrairement à GetLinkByShortCode, elle retourne ErrLinkExpired si le lien a dépassé
+// sa date d'expiration ou sa limite de clics.
+// Pour un lien limité en nombre de clics, la redirection acceptée est comptée immédiatement,
+// avant que son clic ne soit persisté par les workers : des redirections simultanées ne peuvent
+// pas dépasser la limite. Elle est décomptée par ReleasePendingClicks une fois le clic écrit,
+// ou par ReleaseClicks si le clic n'est pas écrit par les workers (perdu ou envoyé au spool).
+func (s *LinkService) ResolveLink(shortCode string) (*models.Link, error) {
 	link, err := s.linkRepo.GetLinkByShortCode(shortCode)
-	// Retourner le lien trouvé ou une erreur si non trouvé/problème DB.
-	return link, err
+	if err != nil {
+		return nil, err
+	}
+	if link.MaxClicks <= 0 {
+		if IsLinkExpired(link, 0, time.Now()) {
+			return nil, ErrLinkExpired
+		}
+		return link, nil
+	}
+
+	// La redirection est réservée avant le décompte des clics persistés. Un clic écrit entre les deux
+	// est compté deux fois : près de la limite, le lien peut expirer un clic trop tôt, jamais trop tard.
+	earlier := s.reserveClick(link.ID)
+	clickCount, err := s.linkRepo.CountClicksByLinkID(link.ID)
+	if err != nil {
+		s.ReleaseClicks(link.ID, 1)
+		return nil, err
+	}
+	if IsLinkExpired(link, clickCount+earlier, time.Now()) {
+		s.ReleaseClicks(link.ID, 1)
+		return nil, ErrLinkExpired
+	}
+	return link, nil
+}
+
+// reserveClick compte une redirection en attente pour le lien 'linkID' et retourne le nombre
+// de redirections qui étaient déjà en attente.
+func (s *LinkService) reserveClick(linkID uint) int {
+	s.pendingMu.Lock()
+	defer s.pendingMu.Unlock()
+	earlier := s.pendingClicks[linkID]
+	s.pendingClicks[linkID] = earlier + 1
+	return earlier
+}
+
+// ReleaseClicks décompte 'n' redirections en attente pour le lien 'linkID'.
+// Elle est sans effet pour un lien qui n'a pas de redirection en attente.
+func (s *LinkService) ReleaseClicks(linkID uint, n int) {
+	s.pendingMu.Lock()
+	defer s.pendingMu.Unlock()
+	pending, ok := s.pendingClicks[linkID]
+	if !ok {
+		return
+	}
+	if pending <= n {
+		delete(s.pendingClicks, linkID)
+	} else {
+		s.pendingClicks[linkID] = pending - n
+	}
+}
+
+// ReleasePendingClicks décompte les redirections en attente des clics qui viennent d'être persistés.
+func (s *LinkService) ReleasePendingClicks(clicks []models.Click) {
+	perLink := make(map[uint]int)
+	for _, click := range clicks {
+		perLink[click.LinkID]++
+	}
+	for linkID, n := range perLink {
+		s.ReleaseClicks(linkID, n)
+	}
+}
+
+// IsLinkExpired indique si un lien a expiré à l'instant 'now', soit parce que sa date
+// d'expiration est passée, soit parce qu'il a atteint son nombre maximal de clics.
+func IsLinkExpired(link *models.Link, clickCount int, now time.Time) bool {
+	if link.ExpiresAt != nil && !now.Before(*link.ExpiresAt) {
+		return true
+	}
+	return link.MaxClicks > 0 && clickCount >= link.MaxClicks
+}
+
+// Bornes de pagination de ListLinks.
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
+
+// sortColumns associe les champs de tri exposés aux clients aux colonnes de la table 'links'.
+// Seules ces colonnes peuvent être utilisées, la valeur étant injectée dans la clause ORDER BY.
+var sortColumns = map[string]string{
+	"created_at": "created_at",
+	"short_code": "shortcode",
+	"long_url":   "long_url",
+}
+
+// ListLinksOptions regroupe les paramètres de pagination, de tri et de filtrage de ListLinks.
+type ListLinksOptions struct {
+	Page          int        // Numéro de page, à partir de 1
+	PageSize      int        // Nombre de liens par page, borné à MaxPageSize
+	SortBy        string     // created_at (par défaut), short_code ou long_url
+	Desc          bool       // Tri décroissant
+	URLContains   string     // Filtre sur une sous-chaîne de l'URL longue
+	CreatedAfter  *time.Time // Filtre sur la date de création (incluse)
+	CreatedBefore *time.Time // Filtre sur la date de création (exclue)
+}
+
+// LinkPage est une page de résultats de ListLinks.
+type LinkPage struct {
+	Links    []models.Link
+	Page     int
+	PageSize int
+	Total    int64 // Nombre total de liens correspondant aux filtres
+}
+
+// ListLinks retourne une page des liens accessibles à 'caller' selon les options de pagination, de tri et de filtrage.
+// Les valeurs de pagination hors bornes sont ramenées aux valeurs par défaut.
+func (s *LinkService) ListLinks(caller *Caller, opts ListLinksOptions) (*LinkPage, error) {
+	if opts.Page < 1 {
+		opts.Page = 1
+	}
+	if opts.PageSize < 1 {
+		opts.PageSize = DefaultPageSize
+	}
+	if opts.PageSize > MaxPageSize {
+		opts.PageSize = MaxPageSize
+	}
+	if opts.SortBy == "" {
+		opts.SortBy = "created_at"
+	}
+	column, ok := sortColumns[opts.SortBy]
+	if !ok {
+		return nil, ErrInvalidSortField
+	}
+
+	filter := repository.LinkFilter{
+		URLContains: opts.URLContains,
+		SortBy:      column,
+		SortDesc:    opts.Desc,
+		Limit:       opts.PageSize,
+		Offset:      (opts.Page - 1) * opts.PageSize,
+		Scope:       caller.scope(),
+	}
+	if opts.CreatedAfter != nil {
+		filter.CreatedAfter = *opts.CreatedAfter
+	}
+	if opts.CreatedBefore != nil {
+		filter.CreatedBefore = *opts.CreatedBefore
+	}
+
+	links, total, err := s.linkRepo.ListLinks(filter)
+	if err != nil {
+		return nil, err
+	}
+	return &LinkPage{Links: links, Page: opts.Page, PageSize: opts.PageSize, Total: total}, nil
+}
+
+// UpdateLinkOptions regroupe les champs modifiables d'un lien. Un champ nil n'est pas modifié.
+type UpdateLinkOptions struct {
+	// LongURL est la nouvelle URL vers laquelle redirige le lien.
+	LongURL *string
+	// FallbackURL est la nouvelle URL de secours du lien, vide pour la supprimer.
+	FallbackURL *string
+}
+
+// UpdateLink modifie l'URL longue et/ou l'URL de secours d'un lien existant.
+// Il renvoie gorm.ErrRecordNotFound si aucun lien accessible à 'caller' n'existe avec ce shortCode,
+// ErrForbidden si son rôle ne permet que la consultation et ErrInvalidFallbackURL si l'URL de secours est invalide.
+func (s *LinkService) UpdateLink(caller *Caller, shortCode string, opts UpdateLinkOptions) (*models.Link, error) {
+	if opts.FallbackURL != nil {
+		if err := validateFallbackURL(*opts.FallbackURL); err != nil {
+			return nil, err
+		}
+	}
+	link, err := s.GetLinkByShortCode(caller, shortCode)
+	if err != nil {
+		return nil, err
+	}
+	if !caller.canWrite() {
+		return nil, ErrForbidden
+	}
+
+	if opts.LongURL != nil {
+		link.LongURL = *opts.LongURL
+	}
+	if opts.FallbackURL != nil {
+		link.FallbackURL = *opts.FallbackURL
+	}
+	if err := s.linkRepo.UpdateLink(link); err != nil {
+		return nil, fmt.Errorf("failed to update link: %w", err)
+	}
+	return link, nil
+}
+
+// validateFallbackURL vérifie qu'une URL de secours est vide ou absolue en http ou https.
+func validateFallbackURL(raw string) error {
+	if raw == "" {
+		return nil
+	}
+	parsed, err := url.Parse(raw)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return ErrInvalidFallbackURL
+	}
+	return nil
+}
+
+// DeleteLink supprime un lien et ses clics.
+// Il renvoie gorm.ErrRecordNotFound si aucun lien accessible à 'caller' n'existe avec ce shortCode,
+// et ErrForbidden si son rôle ne permet que la consultation.
+func (s *LinkService) DeleteLink(caller *Caller, shortCode string) error {
+	link, err := s.GetLinkByShortCode(caller, shortCode)
+	if err != nil {
+		return err
+	}
+	if !caller.canWrite() {
+		return ErrForbidden
+	}
+
+	if err := s.linkRepo.DeleteLink(link); err != nil {
+		return fmt.Errorf("failed to delete link: %w", err)
+	}
+	return nil
 }
 
 // GetLinkStats récupère les statistiques pour un lien donné (nombre total de clics).
 // Il interagit avec le LinkRepository pour obtenir le lien, puis avec le ClickRepository
-func (s *LinkService) GetLinkStats(shortCode string) (*models.Link, int, error) {
-	link, err := s.linkRepo.GetLinkByShortCode(shortCode)
+func (s *LinkService) GetLinkStats(caller *Caller, shortCode string) (*models.Link, int, error) {
+	link, err := s.GetLinkByShortCode(caller, shortCode)
+	if err != nil {
+		return nil, 0, err
+	}
 
 	clickCount, err := s.linkRepo.CountClicksByLinkID(link.ID)
+	if err != nil {
+		return nil, 0, err
+	}
 
-	return link, clickCount, err
+	return link, clickCount, nil
 }
@@ -0,0 +1,185 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"sync"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/analytics"
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/repository"
+)
+
+// VisitorService estime le nombre de visiteurs uniques des liens.
+// Un visiteur est identifié par une empreinte : un HMAC de son adresse IP et de son User-Agent
+// avec un sel qui change chaque jour. Les empreintes ne sont pas stockées : elles alimentent
+// un sketch HyperLogLog par lien et par jour, qui se fusionnent pour couvrir une période.
+// Un même visiteur est donc compté une fois par jour, mais peut être recompté sur une période
+// de plusieurs jours, le sel ne permettant pas de le reconnaître d'un jour à l'autre.
+type VisitorService struct {
+	visitorRepo repository.VisitorRepository
+
+	saltMu sync.Mutex           // Protège salts, Fingerprint étant appelée par plusieurs workers
+	salts  map[time.Time][]byte // Sels déjà chargés, par jour
+
+	recordMu sync.Mutex // Sérialise les mises à jour de sketches, qui se font en lecture-modification-écriture
+}
+
+// DailyVisitors est le nombre estimé de visiteurs uniques d'un lien pour une journée (UTC).
+type DailyVisitors struct {
+	Day   time.Time
+	Count uint64
+}
+
+// UniqueVisitors est l'estimation des visiteurs uniques d'un lien sur une période.
+type UniqueVisitors struct {
+	Total uint64          // Visiteurs uniques sur toute la période (union des sketches quotidiens)
+	Days  []DailyVisitors // Détail des jours ayant au moins une visite
+}
+
+// NewVisitorService crée et retourne une nouvelle instance de VisitorService.
+func NewVisitorService(visitorRepo repository.VisitorRepository) *VisitorService {
+	return &VisitorService{
+		visitorRepo: visitorRepo,
+		salts:       make(map[time.Time][]byte),
+	}
+}
+
+// utcDay retourne minuit UTC du jour de 't'.
+func utcDay(t time.Time) time.Time {
+	return t.UTC().Truncate(24 * time.Hour)
+}
+
+// saltsKeptFrom retourne le premier jour dont le sel est conservé à l'instant 'now' : la veille.
+func saltsKeptFrom(now time.Time) time.Time {
+	return utcDay(now).AddDate(0, 0, -1)
+}
+
+// Fingerprint retourne l'empreinte d'un visiteur pour le jour (UTC) de 'at'.
+// Un clic plus ancien que la veille (rejoué tardivement depuis le spool) n'a plus de sel :
+// l'empreinte retournée vaut 0 et le clic n'est pas compté comme visiteur.
+func (s *VisitorService) Fingerprint(ipAddress, userAgent string, at time.Time) (uint64, error) {
+	day := utcDay(at)
+	if day.Before(saltsKeptFrom(time.Now())) {
+		return 0, nil
+	}
+	salt, err := s.salt(day)
+	if err != nil {
+		return 0, err
+	}
+	mac := hmac.New(sha256.New, salt)
+	mac.Write([]byte(ipAddress))
+	mac.Write([]byte{0})
+	mac.Write([]byte(userAgent))
+	return binary.BigEndian.Uint64(mac.Sum(nil)), nil
+}
+
+// salt retourne le sel du jour 'day', depuis le cache ou la base.
+// À la création du sel d'un nouveau jour, les sels de plus d'un jour sont supprimés de la base et du cache :
+// la veille reste disponible pour les clics rejoués depuis le spool peu après minuit.
+func (s *VisitorService) salt(day time.Time) ([]byte, error) {
+	s.saltMu.Lock()
+	defer s.saltMu.Unlock()
+
+	if salt, ok := s.salts[day]; ok {
+		return salt, nil
+	}
+	salt, err := s.visitorRepo.GetOrCreateSalt(day)
+	if err != nil {
+		return nil, err
+	}
+
+	keepFrom := saltsKeptFrom(time.Now())
+	if err := s.visitorRepo.DeleteSaltsBefore(keepFrom); err != nil {
+		return nil, err
+	}
+	for cached := range s.salts {
+		if cached.Before(keepFrom) {
+			delete(s.salts, cached)
+		}
+	}
+	s.salts[day] = salt
+	return salt, nil
+}
+
+// DeleteVisitorsBefore supprime les sketches des jours entièrement antérieurs à 'cutoff'
+// et retourne le nombre de sketches supprimés.
+func (s *VisitorService) DeleteVisitorsBefore(cutoff time.Time) (int64, error) {
+	return s.visitorRepo.DeleteSketchesBefore(utcDay(cutoff))
+}
+
+// RecordVisitors ajoute les empreintes des clics aux sketches de leur lien et de leur jour.
+// Les clics de robots et ceux sans empreinte sont ignorés.
+func (s *VisitorService) RecordVisitors(clicks []models.Click) error {
+	type sketchKey struct {
+		linkID uint
+		day    time.Time
+	}
+	sketches := make(map[sketchKey]*analytics.HyperLogLog)
+	for _, click := range clicks {
+		if click.IsBot || click.VisitorHash == 0 {
+			continue
+		}
+		key := sketchKey{linkID: click.LinkID, day: utcDay(click.Timestamp)}
+		if sketches[key] == nil {
+			sketches[key] = analytics.NewHyperLogLog()
+		}
+		sketches[key].Add(click.VisitorHash)
+	}
+
+	s.recordMu.Lock()
+	defer s.recordMu.Unlock()
+
+	for key, batch := range sketches {
+		err := s.visitorRepo.UpdateSketch(key.linkID, key.day, func(current []byte) ([]byte, error) {
+			if len(current) > 0 {
+				stored := analytics.NewHyperLogLog()
+				if err := stored.UnmarshalBinary(current); err != nil {
+					return nil, err
+				}
+				batch.Merge(stored)
+			}
+			return batch.MarshalBinary()
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetUniqueVisitors estime le nombre de visiteurs uniques d'un lien, au total et par jour.
+// Les sketches étant quotidiens, la période est étendue aux journées UTC entières :
+// du jour de 'from' inclus au jour de 'to' exclu (sauf si 'to' n'est pas minuit).
+// Les bornes laissées à leur valeur zéro ne filtrent pas.
+func (s *VisitorService) GetUniqueVisitors(linkID uint, from, to time.Time) (*UniqueVisitors, error) {
+	if !from.IsZero() && !to.IsZero() && !from.Before(to) {
+		return nil, ErrInvalidTimeRange
+	}
+	if !from.IsZero() {
+		from = utcDay(from)
+	}
+	if !to.IsZero() && !utcDay(to).Equal(to) {
+		to = utcDay(to).AddDate(0, 0, 1)
+	}
+
+	sketches, err := s.visitorRepo.GetSketches(linkID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	total := analytics.NewHyperLogLog()
+	result := &UniqueVisitors{Days: make([]DailyVisitors, 0, len(sketches))}
+	for _, stored := range sketches {
+		sketch := analytics.NewHyperLogLog()
+		if err := sketch.UnmarshalBinary(stored.Sketch); err != nil {
+			return nil, err
+		}
+		result.Days = append(result.Days, DailyVisitors{Day: stored.Day.UTC(), Count: sketch.Estimate()})
+		total.Merge(sketch)
+	}
+	result.Total = total.Estimate()
+	return result, nil
+}
@@ -0,0 +1,261 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/repository"
+)
+
+// maxWorkspaceNameLength correspond à la taille de la colonne 'name' de models.Workspace.
+const maxWorkspaceNameLength = 100
+
+// validRoles contient les rôles qu'un membre peut avoir dans un espace de travail.
+var validRoles = map[string]struct{}{
+	models.RoleOwner:  {},
+	models.RoleEditor: {},
+	models.RoleViewer: {},
+}
+
+// WorkspaceService gère les espaces de travail, leurs membres et la résolution du périmètre d'une requête.
+// La création et la suppression d'espaces sont réservées aux clés d'administration ;
+// les membres d'un espace sont gérés par ses propriétaires.
+type WorkspaceService struct {
+	workspaceRepo repository.WorkspaceRepository
+	apiKeyRepo    repository.APIKeyRepository
+}
+
+// NewWorkspaceService crée et retourne une nouvelle instance de WorkspaceService.
+func NewWorkspaceService(workspaceRepo repository.WorkspaceRepository, apiKeyRepo repository.APIKeyRepository) *WorkspaceService {
+	return &WorkspaceService{
+		workspaceRepo: workspaceRepo,
+		apiKeyRepo:    apiKeyRepo,
+	}
+}
+
+// ResolveCaller construit le Caller d'une requête authentifiée par 'key' et portant sur l'espace de travail
+// 'workspaceID' (0 pour les liens personnels de la clé).
+// Il renvoie gorm.ErrRecordNotFound si l'espace n'existe pas, et ErrNotWorkspaceMember si la clé
+// n'en est pas membre. Une clé d'administration agit comme propriétaire de tous les espaces.
+func (s *WorkspaceService) ResolveCaller(key *models.APIKey, workspaceID uint) (*Caller, error) {
+	caller := &Caller{APIKeyID: key.ID, Admin: key.IsAdmin}
+	if workspaceID == 0 {
+		if key.IsAdmin {
+			return caller, nil
+		}
+		workspaces, err := s.workspaceRepo.ListWorkspacesForAPIKey(key.ID)
+		if err != nil {
+			return nil, err
+		}
+		caller.HasWorkspaces = len(workspaces) > 0
+		return caller, nil
+	}
+	if _, err := s.workspaceRepo.GetWorkspaceByID(workspaceID); err != nil {
+		return nil, err
+	}
+	caller.WorkspaceID = workspaceID
+
+	if key.IsAdmin {
+		caller.Role = models.RoleOwner
+		return caller, nil
+	}
+	member, err := s.workspaceRepo.GetMember(workspaceID, key.ID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotWorkspaceMember
+		}
+		return nil, err
+	}
+	caller.Role = member.Role
+	return caller, nil
+}
+
+// CreateWorkspace crée un espace de travail. Réservé aux clés d'administration.
+func (s *WorkspaceService) CreateWorkspace(caller *Caller, name string) (*models.Workspace, error) {
+	if !isAdmin(caller) {
+		return nil, ErrForbidden
+	}
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > maxWorkspaceNameLength {
+		return nil, ErrInvalidWorkspaceName
+	}
+
+	_, err := s.workspaceRepo.GetWorkspaceByName(name)
+	if err == nil {
+		return nil, ErrWorkspaceNameTaken
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("database error checking workspace name: %w", err)
+	}
+
+	workspace := &models.Workspace{Name: name, CreatedAt: time.Now().UTC()}
+	if err := s.workspaceRepo.CreateWorkspace(workspace); err != nil {
+		return nil, fmt.Errorf("failed to save workspace: %w", err)
+	}
+	return workspace, nil
+}
+
+// ListWorkspaces retourne les espaces de travail visibles par le caller : tous pour une clé d'administration
+// (sans rôle), sinon ceux dont il est membre, avec son rôle.
+func (s *WorkspaceService) ListWorkspaces(caller *Caller) ([]repository.WorkspaceWithRole, error) {
+	if !isAdmin(caller) {
+		return s.workspaceRepo.ListWorkspacesForAPIKey(caller.APIKeyID)
+	}
+	workspaces, err := s.workspaceRepo.ListWorkspaces()
+	if err != nil {
+		return nil, err
+	}
+	result := make([]repository.WorkspaceWithRole, len(workspaces))
+	for i, workspace := range workspaces {
+		result[i] = repository.WorkspaceWithRole{Workspace: workspace}
+	}
+	return result, nil
+}
+
+// DeleteWorkspace supprime un espace de travail et ses membres. Réservé aux clés d'administration.
+// Il renvoie repository.ErrWorkspaceNotEmpty si l'espace possède encore des liens.
+func (s *WorkspaceService) DeleteWorkspace(caller *Caller, workspaceID uint) error {
+	if !isAdmin(caller) {
+		return ErrForbidden
+	}
+	return s.workspaceRepo.DeleteWorkspace(workspaceID)
+}
+
+// SetFallbackURL définit l'URL de secours par défaut des liens d'un espace de travail, ou la supprime
+// si 'fallbackURL' est vide. Réservé aux propriétaires de l'espace.
+func (s *WorkspaceService) SetFallbackURL(caller *Caller, workspaceID uint, fallbackURL string) (*models.Workspace, error) {
+	fallbackURL = strings.TrimSpace(fallbackURL)
+	if err := validateFallbackURL(fallbackURL); err != nil {
+		return nil, err
+	}
+	if err := s.requireOwner(caller, workspaceID); err != nil {
+		return nil, err
+	}
+
+	workspace, err := s.workspaceRepo.GetWorkspaceByID(workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	workspace.FallbackURL = fallbackURL
+	if err := s.workspaceRepo.UpdateWorkspace(workspace); err != nil {
+		return nil, fmt.Errorf("failed to update workspace: %w", err)
+	}
+	return workspace, nil
+}
+
+// ListMembers retourne les membres d'un espace de travail. Réservé à ses propriétaires.
+func (s *WorkspaceService) ListMembers(caller *Caller, workspaceID uint) ([]models.WorkspaceMember, error) {
+	if err := s.requireOwner(caller, workspaceID); err != nil {
+		return nil, err
+	}
+	return s.workspaceRepo.ListMembers(workspaceID)
+}
+
+// SetMember ajoute une clé d'API à un espace de travail avec le rôle 'role', ou change son rôle.
+// Réservé aux propriétaires de l'espace. Il renvoie ErrLastOwner si le changement retirerait
+// le dernier propriétaire de l'espace.
+func (s *WorkspaceService) SetMember(caller *Caller, workspaceID, apiKeyID uint, role string) (*models.WorkspaceMember, error) {
+	if _, ok := validRoles[role]; !ok {
+		return nil, ErrInvalidRole
+	}
+	if err := s.requireOwner(caller, workspaceID); err != nil {
+		return nil, err
+	}
+
+	key, err := s.apiKeyRepo.GetAPIKeyByID(apiKeyID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUnknownAPIKey
+		}
+		return nil, err
+	}
+	if key.RevokedAt != nil {
+		return nil, ErrUnknownAPIKey
+	}
+
+	if role != models.RoleOwner {
+		if err := s.checkNotLastOwner(workspaceID, apiKeyID); err != nil {
+			return nil, err
+		}
+	}
+
+	member := &models.WorkspaceMember{
+		WorkspaceID: workspaceID,
+		APIKeyID:    apiKeyID,
+		Role:        role,
+		CreatedAt:   time.Now().UTC(),
+	}
+	if err := s.workspaceRepo.SaveMember(member); err != nil {
+		return nil, fmt.Errorf("failed to save workspace member: %w", err)
+	}
+	// Relit le membre : en cas de changement de rôle, l'identifiant et la date d'ajout sont ceux d'origine.
+	return s.workspaceRepo.GetMember(workspaceID, apiKeyID)
+}
+
+// RemoveMember retire une clé d'API d'un espace de travail. Réservé aux propriétaires de l'espace.
+// Il renvoie gorm.ErrRecordNotFound si la clé n'en était pas membre, et ErrLastOwner s'il s'agit
+// du dernier propriétaire.
+func (s *WorkspaceService) RemoveMember(caller *Caller, workspaceID, apiKeyID uint) error {
+	if err := s.requireOwner(caller, workspaceID); err != nil {
+		return err
+	}
+	if err := s.checkNotLastOwner(workspaceID, apiKeyID); err != nil {
+		return err
+	}
+	return s.workspaceRepo.DeleteMember(workspaceID, apiKeyID)
+}
+
+// requireOwner vérifie que l'espace de travail existe et que le caller en est propriétaire
+// (ou est une clé d'administration).
+func (s *WorkspaceService) requireOwner(caller *Caller, workspaceID uint) error {
+	if _, err := s.workspaceRepo.GetWorkspaceByID(workspaceID); err != nil {
+		return err
+	}
+	if isAdmin(caller) {
+		return nil
+	}
+	member, err := s.workspaceRepo.GetMember(workspaceID, caller.APIKeyID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrForbidden
+		}
+		return err
+	}
+	if member.Role != models.RoleOwner {
+		return ErrForbidden
+	}
+	return nil
+}
+
+// checkNotLastOwner renvoie ErrLastOwner si 'apiKeyID' est le seul propriétaire de l'espace de travail.
+func (s *WorkspaceService) checkNotLastOwner(workspaceID, apiKeyID uint) error {
+	member, err := s.workspaceRepo.GetMember(workspaceID, apiKeyID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if member.Role != models.RoleOwner {
+		return nil
+	}
+	owners, err := s.workspaceRepo.CountOwners(workspaceID)
+	if err != nil {
+		return err
+	}
+	if owners <= 1 {
+		return ErrLastOwner
+	}
+	return nil
+}
+
+// isAdmin indique si le caller peut administrer les espaces de travail :
+// clé d'administration, ou CLI et API sans authentification (caller nil).
+func isAdmin(caller *Caller) bool {
+	return caller == nil || caller.Admin
+}
@@ -0,0 +1,342 @@
+package spool
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/privacy"
+)
+
+// Nommage des segments : clicks-0000000001.spool, clicks-0000000002.spool, ...
+// Le numéro de séquence sur 10 chiffres garde l'ordre lexicographique égal à l'ordre d'écriture.
+// Un segment dont le rejeu a échoué est renommé avec son nombre d'échecs : clicks-0000000001.2.spool.
+const (
+	segmentPrefix = "clicks-"
+	segmentSuffix = ".spool"
+)
+
+// doneSuffix est ajouté au nom d'un segment juste avant que ses événements ne soient persistés.
+// Un segment ainsi renommé n'est plus jamais rejoué, même si sa suppression échoue ensuite :
+// ses clics ne peuvent pas être comptés deux fois.
+const doneSuffix = ".done"
+
+// quarantineDir est le sous-répertoire où sont déplacés les segments dont le rejeu échoue
+// maxReplayAttempts fois de suite. Ils n'y sont plus rejoués et peuvent être examinés à la main.
+const quarantineDir = "quarantine"
+
+// maxReplayAttempts est le nombre de démarrages où le rejeu d'un segment peut échouer avant sa mise en quarantaine.
+const maxReplayAttempts = 3
+
+// headerSize est la taille de l'en-tête de chaque enregistrement :
+// longueur de la charge utile (uint32) puis somme de contrôle CRC-32C de la charge utile (uint32).
+const headerSize = 8
+
+// maxRecordSize protège la relecture contre une longueur corrompue démesurée.
+const maxRecordSize = 1 << 20
+
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+// errCorruptRecord signale un enregistrement tronqué ou dont la somme de contrôle ne correspond pas.
+var errCorruptRecord = errors.New("corrupt spool record")
+
+// Spool est un journal local en ajout seul (append-only) d'événements de clic.
+// Il reçoit les clics qui ne peuvent pas être persistés immédiatement (channel plein,
+// échec d'écriture en base, arrêt du serveur) pour qu'ils soient rejoués au démarrage suivant.
+//
+// Quand l'anonymisation des adresses IP est activée, les adresses sont anonymisées avant
+// l'écriture : aucune adresse en clair n'est conservée sur disque. Les clics rejoués sont
+// alors géolocalisés et comptés comme visiteurs à partir de l'adresse anonymisée.
+//
+// Les événements sont écrits dans des fichiers segments. Un segment n'est jamais rouvert
+// en écriture : chaque démarrage écrit dans un nouveau segment, et les segments existants
+// à l'ouverture sont les seuls rejoués par Replay.
+type Spool struct {
+	dir             string
+	maxSegmentBytes int64
+	anonymizer      *privacy.IPAnonymizer // nil : adresses IP écrites telles quelles
+	sealed          []segment             // Segments présents à l'ouverture, à rejouer
+
+	mu          sync.Mutex // Protège les champs ci-dessous, Append étant appelé par plusieurs goroutines
+	current     *os.File   // Segment en cours d'écriture, créé paresseusement au premier Append
+	currentSize int64
+	nextSeq     uint64
+	closed      bool
+}
+
+// Open ouvre (ou crée) le répertoire de spool 'dir'.
+// Un nouveau segment est commencé dès que le segment courant dépasse 'maxSegmentBytes'.
+// 'anonymizer' (optionnel) anonymise les adresses IP des événements avant leur écriture.
+func Open(dir string, maxSegmentBytes int64, anonymizer *privacy.IPAnonymizer) (*Spool, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create spool directory '%s': %w", dir, err)
+	}
+
+	sealed, lastSeq, err := listSegments(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Spool{
+		dir:             dir,
+		maxSegmentBytes: maxSegmentBytes,
+		anonymizer:      anonymizer,
+		sealed:          sealed,
+		nextSeq:         lastSeq + 1,
+	}, nil
+}
+
+// segment décrit un fichier segment à rejouer.
+type segment struct {
+	path     string
+	seq      uint64
+	attempts int // Nombre de rejeux déjà échoués
+}
+
+// listSegments retourne les segments présents dans 'dir', triés par ordre d'écriture,
+// ainsi que le plus grand numéro de séquence rencontré.
+func listSegments(dir string) ([]segment, uint64, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read spool directory '%s': %w", dir, err)
+	}
+
+	var segments []segment
+	var lastSeq uint64
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, segmentPrefix) || !strings.HasSuffix(name, segmentSuffix) {
+			continue
+		}
+		seqPart, attemptsPart, retried := strings.Cut(strings.TrimSuffix(strings.TrimPrefix(name, segmentPrefix), segmentSuffix), ".")
+		seq, err := strconv.ParseUint(seqPart, 10, 64)
+		if err != nil {
+			continue
+		}
+		attempts := 0
+		if retried {
+			if attempts, err = strconv.Atoi(attemptsPart); err != nil {
+				continue
+			}
+		}
+		if seq > lastSeq {
+			lastSeq = seq
+		}
+		segments = append(segments, segment{path: filepath.Join(dir, name), seq: seq, attempts: attempts})
+	}
+	sort.Slice(segments, func(i, j int) bool { return segments[i].seq < segments[j].seq })
+	return segments, lastSeq, nil
+}
+
+// segmentPath retourne le chemin du segment de numéro 'seq' après 'attempts' rejeux échoués.
+func segmentPath(dir string, seq uint64, attempts int) string {
+	if attempts == 0 {
+		return filepath.Join(dir, fmt.Sprintf("%s%010d%s", segmentPrefix, seq, segmentSuffix))
+	}
+	return filepath.Join(dir, fmt.Sprintf("%s%010d.%d%s", segmentPrefix, seq, attempts, segmentSuffix))
+}
+
+// Append ajoute des événements à la fin du segment courant.
+// Les données sont écrites sans fsync : elles survivent à un arrêt du processus
+// mais pas nécessairement à une coupure de la machine, ce qui garde Append assez rapide
+// pour être appelée depuis une requête de redirection.
+func (s *Spool) Append(events ...models.ClickEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	var buf []byte
+	for _, event := range events {
+		if s.anonymizer != nil && s.anonymizer.Enabled() && !event.IPAnonymized {
+			event.IPAddress = s.anonymizer.Anonymize(event.IPAddress)
+			event.IPAnonymized = true
+		}
+		payload, err := json.Marshal(event)
+		if err != nil {
+			return fmt.Errorf("failed to encode click event: %w", err)
+		}
+		var header [headerSize]byte
+		binary.LittleEndian.PutUint32(header[0:4], uint32(len(payload)))
+		binary.LittleEndian.PutUint32(header[4:8], crc32.Checksum(payload, crcTable))
+		buf = append(buf, header[:]...)
+		buf = append(buf, payload...)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return errors.New("spool is closed")
+	}
+	if s.current == nil || s.currentSize >= s.maxSegmentBytes {
+		if err := s.rotate(); err != nil {
+			return err
+		}
+	}
+
+	n, err := s.current.Write(buf)
+	s.currentSize += int64(n)
+	if err != nil {
+		return fmt.Errorf("failed to write to spool segment: %w", err)
+	}
+	return nil
+}
+
+// rotate ferme le segment courant et en ouvre un nouveau. L'appelant doit détenir le verrou.
+func (s *Spool) rotate() error {
+	if s.current != nil {
+		if err := s.current.Close(); err != nil {
+			log.Printf("[SPOOL] Erreur lors de la fermeture du segment %s: %v", s.current.Name(), err)
+		}
+	}
+
+	path := segmentPath(s.dir, s.nextSeq, 0)
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND|os.O_EXCL, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to create spool segment '%s': %w", path, err)
+	}
+	s.current = f
+	s.currentSize = 0
+	s.nextSeq++
+	return nil
+}
+
+// Close synchronise et ferme le segment courant. Les Append suivants échouent.
+func (s *Spool) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.closed = true
+	if s.current == nil {
+		return nil
+	}
+	syncErr := s.current.Sync()
+	closeErr := s.current.Close()
+	s.current = nil
+	return errors.Join(syncErr, closeErr)
+}
+
+// Replay relit les segments présents à l'ouverture du spool, dans l'ordre d'écriture,
+// et passe les événements de chaque segment à 'persist' en un seul appel.
+// Avant l'appel, le segment est renommé avec le suffixe doneSuffix, puis supprimé une fois
+// 'persist' réussi. En cas d'échec, il reprend un nom de segment pour le prochain démarrage et
+// le rejeu continue avec les segments suivants : un segment dont le rejeu échoue
+// maxReplayAttempts fois est déplacé dans le sous-répertoire de quarantaine.
+// Un enregistrement corrompu (écriture interrompue, somme de contrôle invalide) termine
+// la lecture de son segment : les enregistrements valides qui le précèdent sont rejoués.
+// Replay retourne le nombre d'événements rejoués et les erreurs des segments non rejoués.
+func (s *Spool) Replay(persist func(events []models.ClickEvent) error) (int, error) {
+	replayed := 0
+	var errs []error
+	for _, seg := range s.sealed {
+		events, err := readSegment(seg.path)
+		if err != nil {
+			if !errors.Is(err, errCorruptRecord) {
+				errs = append(errs, err)
+				continue
+			}
+			log.Printf("[SPOOL] Segment %s corrompu après %d événement(s) valide(s), la suite est ignorée: %v",
+				seg.path, len(events), err)
+		}
+
+		done := seg.path + doneSuffix
+		if err := os.Rename(seg.path, done); err != nil {
+			errs = append(errs, fmt.Errorf("failed to mark spool segment '%s' as replayed: %w", seg.path, err))
+			continue
+		}
+		if len(events) > 0 {
+			if err := persist(events); err != nil {
+				errs = append(errs, fmt.Errorf("failed to persist spooled events from '%s': %w", seg.path, err))
+				if err := s.retryLater(seg, done); err != nil {
+					errs = append(errs, err)
+				}
+				continue
+			}
+		}
+		if err := os.Remove(done); err != nil {
+			log.Printf("[SPOOL] Segment rejoué %s non supprimé, il ne sera pas rejoué à nouveau: %v", done, err)
+		}
+		replayed += len(events)
+	}
+	s.sealed = nil
+	return replayed, errors.Join(errs...)
+}
+
+// retryLater enregistre l'échec du rejeu d'un segment, actuellement nommé 'current', en le renommant
+// avec son nombre d'échecs, ou le déplace en quarantaine quand il a échoué maxReplayAttempts fois.
+func (s *Spool) retryLater(seg segment, current string) error {
+	attempts := seg.attempts + 1
+	if attempts < maxReplayAttempts {
+		if err := os.Rename(current, segmentPath(s.dir, seg.seq, attempts)); err != nil {
+			return fmt.Errorf("failed to record replay failure of spool segment '%s': %w", seg.path, err)
+		}
+		return nil
+	}
+
+	dir := filepath.Join(s.dir, quarantineDir)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		// Le segment garde son nom pour être retenté au prochain démarrage.
+		return errors.Join(fmt.Errorf("failed to create spool quarantine directory '%s': %w", dir, err),
+			os.Rename(current, seg.path))
+	}
+	target := filepath.Join(dir, filepath.Base(segmentPath(s.dir, seg.seq, 0)))
+	if err := os.Rename(current, target); err != nil {
+		return fmt.Errorf("failed to quarantine spool segment '%s': %w", seg.path, err)
+	}
+	log.Printf("[SPOOL] Rejeu du segment %s échoué %d fois, segment mis en quarantaine dans %s", seg.path, attempts, target)
+	return nil
+}
+
+// readSegment décode tous les enregistrements valides d'un segment.
+// En cas d'enregistrement corrompu, les événements lus jusque-là sont retournés avec errCorruptRecord.
+func readSegment(path string) ([]models.ClickEvent, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open spool segment '%s': %w", path, err)
+	}
+	defer f.Close()
+
+	reader := bufio.NewReader(f)
+	var events []models.ClickEvent
+	var header [headerSize]byte
+	for {
+		if _, err := io.ReadFull(reader, header[:]); err != nil {
+			if errors.Is(err, io.EOF) {
+				return events, nil
+			}
+			return events, fmt.Errorf("%w: truncated header", errCorruptRecord)
+		}
+
+		length := binary.LittleEndian.Uint32(header[0:4])
+		checksum := binary.LittleEndian.Uint32(header[4:8])
+		if length > maxRecordSize {
+			return events, fmt.Errorf("%w: record length %d exceeds limit", errCorruptRecord, length)
+		}
+
+		payload := make([]byte, length)
+		if _, err := io.ReadFull(reader, payload); err != nil {
+			return events, fmt.Errorf("%w: truncated payload", errCorruptRecord)
+		}
+		if crc32.Checksum(payload, crcTable) != checksum {
+			return events, fmt.Errorf("%w: checksum mismatch", errCorruptRecord)
+		}
+
+		var event models.ClickEvent
+		if err := json.Unmarshal(payload, &event); err != nil {
+			return events, fmt.Errorf("%w: %v", errCorruptRecord, err)
+		}
+		events = append(events, event)
+	}
+}
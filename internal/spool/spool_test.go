@@ -0,0 +1,304 @@
+package spool
+
+import (
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/privacy"
+)
+
+// writeSegments ouvre un spool dans 'dir', y ajoute 'events' puis le ferme.
+func writeSegments(t *testing.T, dir string, maxSegmentBytes int64, events ...models.ClickEvent) {
+	t.Helper()
+	s, err := Open(dir, maxSegmentBytes, nil)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	for _, event := range events {
+		if err := s.Append(event); err != nil {
+			t.Fatalf("Append: %v", err)
+		}
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+}
+
+// replayAll rouvre le spool de 'dir' et retourne les événements rejoués avec succès.
+func replayAll(t *testing.T, dir string, persist func([]models.ClickEvent) error) ([]models.ClickEvent, error) {
+	t.Helper()
+	s, err := Open(dir, 1<<20, nil)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer s.Close()
+	var got []models.ClickEvent
+	_, err = s.Replay(func(events []models.ClickEvent) error {
+		if persist != nil {
+			if err := persist(events); err != nil {
+				return err
+			}
+		}
+		got = append(got, events...)
+		return nil
+	})
+	return got, err
+}
+
+// spoolFiles retourne les noms des fichiers de 'dir', hors sous-répertoires.
+func spoolFiles(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("ReadDir: %v", err)
+	}
+	var names []string
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			names = append(names, entry.Name())
+		}
+	}
+	return names
+}
+
+func TestAppendAndReplay(t *testing.T) {
+	tests := []struct {
+		name            string
+		maxSegmentBytes int64
+		events          int
+		wantSegments    int
+	}{
+		{"single segment", 1 << 20, 5, 1},
+		{"one segment per event", 1, 3, 3},
+		{"empty spool", 1 << 20, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			var events []models.ClickEvent
+			for i := 0; i < tt.events; i++ {
+				events = append(events, models.ClickEvent{
+					LinkID:    uint(i + 1),
+					Timestamp: time.Date(2024, 1, 1, 0, 0, i, 0, time.UTC),
+					UserAgent: "agent",
+					IPAddress: "192.0.2.1",
+				})
+			}
+			writeSegments(t, dir, tt.maxSegmentBytes, events...)
+			if got := len(spoolFiles(t, dir)); got != tt.wantSegments {
+				t.Fatalf("segments = %d, want %d", got, tt.wantSegments)
+			}
+
+			got, err := replayAll(t, dir, nil)
+			if err != nil {
+				t.Fatalf("Replay: %v", err)
+			}
+			if len(got) != len(events) {
+				t.Fatalf("replayed %d events, want %d", len(got), len(events))
+			}
+			for i := range events {
+				if got[i].LinkID != events[i].LinkID || !got[i].Timestamp.Equal(events[i].Timestamp) {
+					t.Errorf("event %d = %+v, want %+v", i, got[i], events[i])
+				}
+			}
+			if files := spoolFiles(t, dir); len(files) != 0 {
+				t.Errorf("files left after replay: %v", files)
+			}
+		})
+	}
+}
+
+func TestReplayCorruptSegment(t *testing.T) {
+	tests := []struct {
+		name    string
+		corrupt func(data []byte) []byte
+	}{
+		{"truncated header", func(data []byte) []byte { return append(data, 1, 2, 3) }},
+		{"truncated payload", func(data []byte) []byte {
+			var header [headerSize]byte
+			binary.LittleEndian.PutUint32(header[0:4], 100)
+			return append(append(data, header[:]...), '{')
+		}},
+		{"checksum mismatch", func(data []byte) []byte {
+			data[len(data)-2] ^= 0xff // Dernier enregistrement : un octet de sa charge utile
+			return data
+		}},
+		{"oversized length", func(data []byte) []byte {
+			var header [headerSize]byte
+			binary.LittleEndian.PutUint32(header[0:4], maxRecordSize+1)
+			return append(data, header[:]...)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeSegments(t, dir, 1<<20, models.ClickEvent{LinkID: 1}, models.ClickEvent{LinkID: 2})
+			path := filepath.Join(dir, spoolFiles(t, dir)[0])
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(path, tt.corrupt(data), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := replayAll(t, dir, nil)
+			if err != nil {
+				t.Fatalf("Replay: %v", err)
+			}
+			if len(got) == 0 || got[0].LinkID != 1 {
+				t.Fatalf("replayed %+v, want the valid records before the corruption", got)
+			}
+			if files := spoolFiles(t, dir); len(files) != 0 {
+				t.Errorf("files left after replay: %v", files)
+			}
+		})
+	}
+}
+
+func TestReplayFailureRetriesThenQuarantines(t *testing.T) {
+	dir := t.TempDir()
+	writeSegments(t, dir, 1, models.ClickEvent{LinkID: 1}, models.ClickEvent{LinkID: 2})
+	failLink1 := func(events []models.ClickEvent) error {
+		if events[0].LinkID == 1 {
+			return errors.New("persist failed")
+		}
+		return nil
+	}
+
+	for attempt := 1; attempt <= maxReplayAttempts; attempt++ {
+		got, err := replayAll(t, dir, failLink1)
+		if err == nil {
+			t.Fatalf("attempt %d: Replay returned no error", attempt)
+		}
+		if attempt == 1 && (len(got) != 1 || got[0].LinkID != 2) {
+			t.Fatalf("attempt %d: replayed %+v, want only the healthy segment", attempt, got)
+		}
+		files := spoolFiles(t, dir)
+		for _, name := range files {
+			if strings.HasSuffix(name, doneSuffix) {
+				t.Fatalf("attempt %d: segment left marked as replayed: %s", attempt, name)
+			}
+		}
+		if attempt < maxReplayAttempts {
+			want := filepath.Base(segmentPath(dir, 1, attempt))
+			if len(files) != 1 || files[0] != want {
+				t.Fatalf("attempt %d: files = %v, want [%s]", attempt, files, want)
+			}
+		} else if len(files) != 0 {
+			t.Fatalf("attempt %d: files = %v, want the segment in quarantine", attempt, files)
+		}
+	}
+
+	quarantined, err := os.ReadDir(filepath.Join(dir, quarantineDir))
+	if err != nil || len(quarantined) != 1 {
+		t.Fatalf("quarantine = %v (%v), want one segment", quarantined, err)
+	}
+	if got, err := replayAll(t, dir, failLink1); err != nil || len(got) != 0 {
+		t.Fatalf("replay after quarantine = %+v, %v; want nothing", got, err)
+	}
+}
+
+func TestReplayIgnoresReplayedMarker(t *testing.T) {
+	dir := t.TempDir()
+	writeSegments(t, dir, 1<<20, models.ClickEvent{LinkID: 1})
+	path := filepath.Join(dir, spoolFiles(t, dir)[0])
+	// Segment persisté dont la suppression a échoué.
+	if err := os.Rename(path, path+doneSuffix); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := replayAll(t, dir, nil)
+	if err != nil || len(got) != 0 {
+		t.Fatalf("Replay = %+v, %v; want the replayed segment to be skipped", got, err)
+	}
+}
+
+func TestListSegmentsOrderAndSequence(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{
+		"clicks-0000000003.spool",
+		"clicks-0000000001.2.spool",
+		"clicks-0000000002.spool.done",
+		"clicks-0000000010.spool",
+		"other.txt",
+		"clicks-abc.spool",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	segments, lastSeq, err := listSegments(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []segment{
+		{path: filepath.Join(dir, "clicks-0000000001.2.spool"), seq: 1, attempts: 2},
+		{path: filepath.Join(dir, "clicks-0000000003.spool"), seq: 3},
+		{path: filepath.Join(dir, "clicks-0000000010.spool"), seq: 10},
+	}
+	if len(segments) != len(want) {
+		t.Fatalf("segments = %+v, want %+v", segments, want)
+	}
+	for i := range want {
+		if segments[i] != want[i] {
+			t.Errorf("segment %d = %+v, want %+v", i, segments[i], want[i])
+		}
+	}
+	if lastSeq != 10 {
+		t.Errorf("lastSeq = %d, want 10", lastSeq)
+	}
+}
+
+func TestAppendAnonymizesIPAddresses(t *testing.T) {
+	anonymizer, err := privacy.NewIPAnonymizer(privacy.IPModeTruncate, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	s, err := Open(dir, 1<<20, anonymizer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Append(models.ClickEvent{LinkID: 1, IPAddress: "192.0.2.55"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, spoolFiles(t, dir)[0]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "192.0.2.55") {
+		t.Fatal("clear IP address written to the spool")
+	}
+	got, err := replayAll(t, dir, nil)
+	if err != nil || len(got) != 1 {
+		t.Fatalf("Replay = %+v, %v", got, err)
+	}
+	if got[0].IPAddress != "192.0.2.0" || !got[0].IPAnonymized {
+		t.Errorf("replayed event = %+v, want an anonymized address", got[0])
+	}
+}
+
+func TestAppendAfterClose(t *testing.T) {
+	s, err := Open(t.TempDir(), 1<<20, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Append(models.ClickEvent{LinkID: 1}); err == nil {
+		t.Fatal("Append after Close succeeded")
+	}
+}
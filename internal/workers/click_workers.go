@@ -2,47 +2,214 @@ package workers
 
 import (
 	"log"
+	"sync"
+	"time"
+	"unicode/utf8"
 
+	"github.com/axellelanca/urlshortener/internal/metrics"
 	"github.com/axellelanca/urlshortener/internal/models"
 	"github.com/axellelanca/urlshortener/internal/repository" // Nécessaire pour interagir avec le ClickRepository
+	"github.com/axellelanca/urlshortener/internal/spool"
 )
 
+// ClickWorkerConfig regroupe les paramètres du pool de workers de clics.
+type ClickWorkerConfig struct {
+	WorkerCount   int           // Nombre de goroutines qui consomment le channel
+	BatchSize     int           // Nombre de clics au-delà duquel un lot est écrit immédiatement
+	FlushInterval time.Duration // Délai maximal avant l'écriture d'un lot incomplet
+	Spool         *spool.Spool  // Optionnel : reçoit les lots dont l'écriture en base a échoué
+	Enrichers     []ClickEnricher
+	Observers     []ClickObserver // Notifiés des clics après leur persistance
+}
+
 // StartClickWorkers lance un pool de goroutines "workers" pour traiter les événements de clic.
 // Chaque worker lira depuis le même 'clickEventsChan' et utilisera le 'clickRepo' pour la persistance.
-func StartClickWorkers(workerCount int, clickEventsChan <-chan models.ClickEvent, clickRepo repository.ClickRepository) {
-	log.Printf("Starting %d click worker(s)...", workerCount)
-	for i := 0; i < workerCount; i++ {
+// Les workers se terminent quand le channel est fermé ; le WaitGroup retourné permet d'attendre
+// qu'ils aient écrit leurs derniers lots.
+func StartClickWorkers(cfg ClickWorkerConfig, clickEventsChan <-chan models.ClickEvent, clickRepo repository.ClickRepository) *sync.WaitGroup {
+	if cfg.BatchSize < 1 {
+		cfg.BatchSize = 1
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = time.Second
+	}
+
+	log.Printf("Starting %d click worker(s) (batch size %d, flush interval %v)...",
+		cfg.WorkerCount, cfg.BatchSize, cfg.FlushInterval)
+	var wg sync.WaitGroup
+	for i := 0; i < cfg.WorkerCount; i++ {
 		// Lance chaque worker dans sa propre goroutine.
 		// Le channel est passé en lecture seule (<-chan) pour renforcer l'immutabilité du channel à l'intérieur du worker.
-		go clickWorker(clickEventsChan, clickRepo)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			clickWorker(cfg, clickEventsChan, clickRepo)
+		}()
 	}
+	return &wg
 }
 
-// clickWorker est la fonction exécutée par chaque goroutine worker.
-// Elle tourne indéfiniment, lisant les événements de clic dès qu'ils sont disponibles dans le channel.
-func clickWorker(clickEventsChan <-chan models.ClickEvent, clickRepo repository.ClickRepository) {
-	for event := range clickEventsChan { // Boucle qui lit les événements du channel
-		click := &models.Click{
-			LinkID:    event.LinkID,
-			Timestamp: event.Timestamp,
-			UserAgent: event.UserAgent,
-			IPAddress: event.IPAddress,
+// ReplaySpool persiste les événements restés dans cfg.Spool lors d'une exécution précédente,
+// en leur appliquant les mêmes enrichissements qu'aux nouveaux clics.
+// Les événements des liens supprimés depuis sont écartés : ils ne pourraient jamais être écrits.
+// Elle doit être appelée au démarrage, avant que de nouveaux clics n'arrivent.
+func ReplaySpool(cfg ClickWorkerConfig, clickRepo repository.ClickRepository) (int, error) {
+	return cfg.Spool.Replay(func(events []models.ClickEvent) error {
+		events, err := withExistingLinks(events, clickRepo)
+		if err != nil {
+			return err
+		}
+		if len(events) == 0 {
+			return nil
+		}
+		clicks := clicksFromEvents(events, cfg.Enrichers)
+		if err := clickRepo.CreateClicks(clicks); err != nil {
+			return err
 		}
-		err := clickRepo.CreateClick(click)
+		metrics.ClicksPersisted.Add(float64(len(clicks)))
+		notifyObservers(cfg.Observers, clicks)
+		return nil
+	})
+}
 
-		// Implémentez ici une gestion d'erreur simple : loggez l'erreur si la persistance échoue.
-		// Pour un système en production, une logique de retry
+// withExistingLinks retourne les événements de 'events' dont le lien existe encore en base.
+func withExistingLinks(events []models.ClickEvent, clickRepo repository.ClickRepository) ([]models.ClickEvent, error) {
+	seen := make(map[uint]bool)
+	var linkIDs []uint
+	for _, event := range events {
+		if !seen[event.LinkID] {
+			seen[event.LinkID] = true
+			linkIDs = append(linkIDs, event.LinkID)
+		}
+	}
+	existing, err := clickRepo.ExistingLinkIDs(linkIDs)
+	if err != nil {
+		return nil, err
+	}
 
-		if err != nil {
-			// Si une erreur se produit lors de l'enregistrement, logguez-la.
-			// L'événement est "perdu" pour ce TP, mais dans un vrai système,
-			// vous pourriez le remettre dans une file de retry ou une file d'erreurs.
-			log.Printf("ERROR: Failed to save click for LinkID %d (UserAgent: %s, IP: %s): %v",
-				event.LinkID, event.UserAgent, event.IPAddress, err)
+	kept := events[:0]
+	for _, event := range events {
+		if existing[event.LinkID] {
+			kept = append(kept, event)
+		}
+	}
+	if dropped := len(events) - len(kept); dropped > 0 {
+		metrics.ClickEventsDropped.Add(float64(dropped))
+		log.Printf("WARNING: %d spooled click(s) dropped, their link no longer exists", dropped)
+	}
+	return kept, nil
+}
 
+// clickWorker est la fonction exécutée par chaque goroutine worker.
+// Elle accumule les événements de clic dans un lot, écrit en base dès qu'il atteint
+// cfg.BatchSize clics ou, au plus tard, à chaque cfg.FlushInterval.
+// Quand le channel est fermé, le lot en cours est écrit avant de terminer.
+func clickWorker(cfg ClickWorkerConfig, clickEventsChan <-chan models.ClickEvent, clickRepo repository.ClickRepository) {
+	batch := make([]models.ClickEvent, 0, cfg.BatchSize)
+	ticker := time.NewTicker(cfg.FlushInterval)
+	defer ticker.Stop()
+
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		clicks := clicksFromEvents(batch, cfg.Enrichers)
+		if err := clickRepo.CreateClicks(clicks); err != nil {
+			metrics.ClickPersistFailures.Inc()
+			log.Printf("ERROR: Failed to save batch of %d click(s): %v", len(batch), err)
+			spoolEvents(cfg.Spool, batch)
+			notifyUnpersisted(cfg.Observers, batch)
 		} else {
-			// Log optionnel pour confirmer l'enregistrement (utile pour le débogage)
-			log.Printf("Click recorded successfully for LinkID %d", event.LinkID)
+			metrics.ClicksPersisted.Add(float64(len(clicks)))
+			log.Printf("Batch of %d click(s) recorded successfully", len(batch))
+			notifyObservers(cfg.Observers, clicks)
 		}
+		batch = batch[:0]
+	}
+
+	for {
+		select {
+		case event, ok := <-clickEventsChan:
+			if !ok {
+				flush()
+				return
+			}
+			batch = append(batch, event)
+			if len(batch) >= cfg.BatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+// spoolEvents conserve dans le spool les événements d'un lot qui n'a pas pu être écrit en base,
+// pour qu'ils soient rejoués au prochain démarrage. Sans spool, les événements sont perdus.
+func spoolEvents(clickSpool *spool.Spool, events []models.ClickEvent) {
+	if clickSpool == nil {
+		metrics.ClickEventsDropped.Add(float64(len(events)))
+		log.Printf("WARNING: No spool configured, %d click(s) lost", len(events))
+		return
+	}
+	if err := clickSpool.Append(events...); err != nil {
+		metrics.ClickEventsDropped.Add(float64(len(events)))
+		log.Printf("ERROR: Failed to spool %d click(s), they are lost: %v", len(events), err)
+		return
+	}
+	log.Printf("%d click(s) spooled to disk for a later replay", len(events))
+}
+
+// notifyObservers transmet aux observateurs un lot de clics qui vient d'être persisté.
+func notifyObservers(observers []ClickObserver, clicks []models.Click) {
+	for _, observer := range observers {
+		observer.ObserveClicks(clicks)
+	}
+}
+
+// notifyUnpersisted transmet aux observateurs concernés les événements d'un lot qui n'a pas été écrit en base.
+func notifyUnpersisted(observers []ClickObserver, events []models.ClickEvent) {
+	for _, observer := range observers {
+		if unpersisted, ok := observer.(UnpersistedClickObserver); ok {
+			unpersisted.ObserveUnpersisted(events)
+		}
+	}
+}
+
+// maxUserAgentLength correspond à la taille de la colonne 'user_agent'.
+const maxUserAgentLength = 255
+
+// clicksFromEvents convertit des événements de clic bruts en modèles GORM prêts à être persistés,
+// puis applique les enrichisseurs à chaque clic.
+func clicksFromEvents(events []models.ClickEvent, enrichers []ClickEnricher) []models.Click {
+	clicks := make([]models.Click, len(events))
+	for i, event := range events {
+		clicks[i] = models.Click{
+			LinkID:       event.LinkID,
+			Timestamp:    event.Timestamp,
+			UserAgent:    event.UserAgent,
+			IPAddress:    event.IPAddress,
+			Referrer:     event.Referrer,
+			Fallback:     event.Fallback,
+			IPAnonymized: event.IPAnonymized,
+		}
+		for _, enricher := range enrichers {
+			enricher.Enrich(&clicks[i])
+		}
+		// La troncature a lieu après l'enrichissement, qui analyse le User-Agent complet.
+		clicks[i].UserAgent = truncateUTF8(clicks[i].UserAgent, maxUserAgentLength)
+	}
+	return clicks
+}
+
+// truncateUTF8 ramène 's' à au plus 'maxBytes' octets sans couper un caractère UTF-8 en deux.
+func truncateUTF8(s string, maxBytes int) string {
+	if len(s) <= maxBytes {
+		return s
+	}
+	cut := maxBytes
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
 	}
+	return s[:cut]
 }
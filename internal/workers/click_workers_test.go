@@ -0,0 +1,179 @@
+package workers
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/repository"
+	"github.com/axellelanca/urlshortener/internal/spool"
+)
+
+// fakeClickRepository enregistre les lots écrits. Seules les méthodes utilisées par les workers sont implémentées.
+type fakeClickRepository struct {
+	repository.ClickRepository
+
+	mu      sync.Mutex
+	batches [][]models.Click
+	fail    bool
+	links   map[uint]bool // Liens existants, pour ExistingLinkIDs
+}
+
+func (f *fakeClickRepository) CreateClicks(clicks []models.Click) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.fail {
+		return errors.New("database unavailable")
+	}
+	f.batches = append(f.batches, append([]models.Click(nil), clicks...))
+	return nil
+}
+
+func (f *fakeClickRepository) ExistingLinkIDs(linkIDs []uint) (map[uint]bool, error) {
+	existing := make(map[uint]bool)
+	for _, id := range linkIDs {
+		if f.links[id] {
+			existing[id] = true
+		}
+	}
+	return existing, nil
+}
+
+func (f *fakeClickRepository) batchSizes() []int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var sizes []int
+	for _, batch := range f.batches {
+		sizes = append(sizes, len(batch))
+	}
+	return sizes
+}
+
+// recordingObserver compte les clics persistés et les événements non persistés qui lui sont notifiés.
+type recordingObserver struct {
+	mu          sync.Mutex
+	persisted   int
+	unpersisted int
+}
+
+func (o *recordingObserver) ObserveClicks(clicks []models.Click) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.persisted += len(clicks)
+}
+
+func (o *recordingObserver) ObserveUnpersisted(events []models.ClickEvent) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.unpersisted += len(events)
+}
+
+func TestClickWorkerBatching(t *testing.T) {
+	tests := []struct {
+		name          string
+		batchSize     int
+		flushInterval time.Duration
+		events        int
+		waitForFlush  bool // Attendre l'écriture par le ticker avant de fermer le channel
+		want          []int
+	}{
+		{"full batches then remainder on close", 3, time.Hour, 7, false, []int{3, 3, 1}},
+		{"exact batches", 2, time.Hour, 4, false, []int{2, 2}},
+		{"batch size below one", 0, time.Hour, 2, false, []int{1, 1}},
+		{"incomplete batch flushed by the ticker", 100, 10 * time.Millisecond, 2, true, []int{2}},
+		{"nothing to write", 3, time.Hour, 0, false, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeClickRepository{}
+			events := make(chan models.ClickEvent)
+			wg := StartClickWorkers(ClickWorkerConfig{WorkerCount: 1, BatchSize: tt.batchSize, FlushInterval: tt.flushInterval}, events, repo)
+
+			for i := 0; i < tt.events; i++ {
+				events <- models.ClickEvent{LinkID: 1, Timestamp: time.Now()}
+			}
+			if tt.waitForFlush {
+				deadline := time.Now().Add(5 * time.Second)
+				for len(repo.batchSizes()) == 0 && time.Now().Before(deadline) {
+					time.Sleep(5 * time.Millisecond)
+				}
+			}
+			close(events)
+			wg.Wait()
+
+			if got := repo.batchSizes(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("batches = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFailedBatchIsSpooledAndReplayed(t *testing.T) {
+	dir := t.TempDir()
+	clickSpool, err := spool.Open(dir, 1<<20, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	repo := &fakeClickRepository{fail: true, links: map[uint]bool{1: true}}
+	observer := &recordingObserver{}
+	cfg := ClickWorkerConfig{WorkerCount: 1, BatchSize: 3, FlushInterval: time.Hour, Spool: clickSpool, Observers: []ClickObserver{observer}}
+
+	events := make(chan models.ClickEvent)
+	wg := StartClickWorkers(cfg, events, repo)
+	for _, linkID := range []uint{1, 1, 2} { // Le lien 2 a été supprimé entre-temps
+		events <- models.ClickEvent{LinkID: linkID, Timestamp: time.Now()}
+	}
+	close(events)
+	wg.Wait()
+
+	if observer.persisted != 0 || observer.unpersisted != 3 {
+		t.Fatalf("observer = %+v, want 3 unpersisted events", observer)
+	}
+
+	// Redémarrage : le spool rouvert rejoue le segment écrit par l'exécution précédente.
+	if err := clickSpool.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Spool, err = spool.Open(dir, 1<<20, nil); err != nil {
+		t.Fatal(err)
+	}
+	defer cfg.Spool.Close()
+	repo.fail = false
+	if _, err := ReplaySpool(cfg, repo); err != nil {
+		t.Fatalf("ReplaySpool: %v", err)
+	}
+	if got := repo.batchSizes(); !reflect.DeepEqual(got, []int{2}) {
+		t.Errorf("replayed batches = %v, want [2] (events of the deleted link dropped)", got)
+	}
+	if observer.persisted != 2 {
+		t.Errorf("observer notified of %d persisted clicks, want 2", observer.persisted)
+	}
+}
+
+func TestTruncateUTF8(t *testing.T) {
+	tests := []struct {
+		name     string
+		s        string
+		maxBytes int
+		want     string
+	}{
+		{"short enough", "abc", 5, "abc"},
+		{"exact length", "abc", 3, "abc"},
+		{"ascii cut", "abcdef", 4, "abcd"},
+		{"cut inside a two-byte rune", "aé", 2, "a"},
+		{"cut after a two-byte rune", "aéb", 3, "aé"},
+		{"cut inside a four-byte rune", "ab😀", 5, "ab"},
+		{"zero bytes", "abc", 0, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := truncateUTF8(tt.s, tt.maxBytes); got != tt.want {
+				t.Errorf("truncateUTF8(%q, %d) = %q, want %q", tt.s, tt.maxBytes, got, tt.want)
+			}
+		})
+	}
+}
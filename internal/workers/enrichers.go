@@ -0,0 +1,113 @@
+package workers
+
+import (
+	"log"
+
+	"github.com/axellelanca/urlshortener/internal/analytics"
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/privacy"
+	"github.com/axellelanca/urlshortener/internal/services"
+)
+
+// ClickEnricher complète un clic avant sa persistance, à partir des données brutes de l'événement.
+// Les enrichisseurs sont appliqués dans l'ordre de ClickWorkerConfig.Enrichers.
+type ClickEnricher interface {
+	Enrich(click *models.Click)
+}
+
+// UserAgentEnricher renseigne le navigateur, le système, la classe d'appareil et le drapeau robot
+// d'un clic à partir de son User-Agent.
+type UserAgentEnricher struct{}
+
+// Enrich implémente ClickEnricher.
+func (UserAgentEnricher) Enrich(click *models.Click) {
+	info := analytics.ParseUserAgent(click.UserAgent)
+	click.Browser = info.Browser
+	click.OS = info.OS
+	click.DeviceClass = info.DeviceClass
+	click.IsBot = info.IsBot
+}
+
+// GeoIPEnricher renseigne le pays et la ville d'un clic à partir de son adresse IP.
+type GeoIPEnricher struct {
+	Resolver *analytics.GeoIPResolver
+}
+
+// Enrich implémente ClickEnricher.
+func (e GeoIPEnricher) Enrich(click *models.Click) {
+	click.Country, click.City = e.Resolver.Lookup(click.IPAddress)
+}
+
+// VisitorEnricher calcule l'empreinte du visiteur d'un clic, utilisée par UniqueVisitorObserver.
+// Il doit précéder IPAnonymizerEnricher, l'empreinte étant calculée sur l'adresse IP en clair.
+type VisitorEnricher struct {
+	Visitors *services.VisitorService
+}
+
+// Enrich implémente ClickEnricher. En cas d'erreur, le clic est enregistré sans être compté comme visiteur.
+func (e VisitorEnricher) Enrich(click *models.Click) {
+	hash, err := e.Visitors.Fingerprint(click.IPAddress, click.UserAgent, click.Timestamp)
+	if err != nil {
+		log.Printf("ERROR: Failed to compute visitor fingerprint: %v", err)
+		return
+	}
+	click.VisitorHash = hash
+}
+
+// IPAnonymizerEnricher remplace l'adresse IP d'un clic par sa forme anonymisée.
+// Il doit être le dernier enrichisseur, les précédents pouvant avoir besoin de l'adresse en clair.
+// Les clics rejoués depuis le spool ont déjà une adresse anonymisée et ne sont pas modifiés.
+type IPAnonymizerEnricher struct {
+	Anonymizer *privacy.IPAnonymizer
+}
+
+// Enrich implémente ClickEnricher.
+func (e IPAnonymizerEnricher) Enrich(click *models.Click) {
+	if click.IPAnonymized {
+		return
+	}
+	click.IPAddress = e.Anonymizer.Anonymize(click.IPAddress)
+	click.IPAnonymized = true
+}
+
+// ClickObserver est notifié des clics d'un lot après leur persistance.
+type ClickObserver interface {
+	ObserveClicks(clicks []models.Click)
+}
+
+// UnpersistedClickObserver est implémenté par les observateurs qui doivent aussi être notifiés
+// des événements d'un lot qui n'a pas pu être écrit en base (envoyés au spool ou perdus).
+type UnpersistedClickObserver interface {
+	ObserveUnpersisted(events []models.ClickEvent)
+}
+
+// UniqueVisitorObserver ajoute les empreintes des visiteurs aux sketches de visiteurs uniques.
+type UniqueVisitorObserver struct {
+	Visitors *services.VisitorService
+}
+
+// ObserveClicks implémente ClickObserver. Les clics sont déjà persistés : en cas d'erreur,
+// seuls leurs visiteurs sont perdus pour l'estimation.
+func (o UniqueVisitorObserver) ObserveClicks(clicks []models.Click) {
+	if err := o.Visitors.RecordVisitors(clicks); err != nil {
+		log.Printf("ERROR: Failed to record unique visitors of %d click(s): %v", len(clicks), err)
+	}
+}
+
+// PendingClicksObserver décompte les redirections en attente des liens limités en nombre de clics
+// dès que leurs clics sont persistés, ou que leur lot part dans le spool.
+type PendingClicksObserver struct {
+	Links *services.LinkService
+}
+
+// ObserveClicks implémente ClickObserver.
+func (o PendingClicksObserver) ObserveClicks(clicks []models.Click) {
+	o.Links.ReleasePendingClicks(clicks)
+}
+
+// ObserveUnpersisted implémente UnpersistedClickObserver.
+func (o PendingClicksObserver) ObserveUnpersisted(events []models.ClickEvent) {
+	for _, event := range events {
+		o.Links.ReleaseClicks(event.LinkID, 1)
+	}
+}
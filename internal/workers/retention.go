@@ -0,0 +1,97 @@
+package workers
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/repository"
+	"github.com/axellelanca/urlshortener/internal/services"
+)
+
+// Actions de la politique de rétention appliquées aux clics trop anciens.
+const (
+	RetentionDelete    = "delete"    // Les clics sont supprimés
+	RetentionAggregate = "aggregate" // Les clics sont remplacés par leur nombre par lien et par jour
+)
+
+// RetentionJob applique périodiquement la politique de rétention des clics :
+// les clics plus anciens que maxAge sont supprimés ou agrégés, et les sketches de visiteurs
+// uniques des jours plus anciens que maxAge sont supprimés quelle que soit l'action.
+type RetentionJob struct {
+	clickRepo repository.ClickRepository
+	visitors  *services.VisitorService // Optionnel
+	maxAge    time.Duration
+	action    string
+	interval  time.Duration
+}
+
+// DefaultRetentionInterval est l'intervalle entre deux applications de la politique de rétention, par défaut.
+const DefaultRetentionInterval = time.Hour
+
+// NewRetentionJob crée un RetentionJob. 'action' vaut RetentionDelete ou RetentionAggregate.
+// Un intervalle nul ou négatif est remplacé par DefaultRetentionInterval.
+func NewRetentionJob(clickRepo repository.ClickRepository, visitors *services.VisitorService, maxAge time.Duration, action string, interval time.Duration) *RetentionJob {
+	if interval <= 0 {
+		interval = DefaultRetentionInterval
+	}
+	return &RetentionJob{
+		clickRepo: clickRepo,
+		visitors:  visitors,
+		maxAge:    maxAge,
+		action:    action,
+		interval:  interval,
+	}
+}
+
+// Start applique la politique de rétention immédiatement puis à chaque intervalle.
+// Cette fonction est conçue pour être lancée dans une goroutine séparée ; elle se termine quand ctx est annulé.
+func (j *RetentionJob) Start(ctx context.Context) {
+	log.Printf("[RETENTION] Conservation des clics pendant %v (action: %s), vérification toutes les %v.",
+		j.maxAge, j.action, j.interval)
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	j.Run(time.Now())
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("[RETENTION] Arrêt de la politique de rétention.")
+			return
+		case <-ticker.C:
+			j.Run(time.Now())
+		}
+	}
+}
+
+// Run supprime ou agrège les clics enregistrés avant 'now' moins maxAge, supprime les sketches
+// de visiteurs des jours antérieurs et retourne le nombre de clics traités.
+func (j *RetentionJob) Run(now time.Time) int64 {
+	cutoff := now.Add(-j.maxAge)
+
+	var removed int64
+	var err error
+	if j.action == RetentionAggregate {
+		removed, err = j.clickRepo.AggregateClicksBefore(cutoff)
+	} else {
+		removed, err = j.clickRepo.DeleteClicksBefore(cutoff)
+	}
+	if err != nil {
+		log.Printf("[RETENTION] ERREUR lors de l'application de la politique de rétention: %v", err)
+		removed = 0
+	} else if removed > 0 {
+		log.Printf("[RETENTION] %d clic(s) antérieur(s) au %s traité(s) (action: %s).",
+			removed, cutoff.UTC().Format(time.RFC3339), j.action)
+	}
+
+	if j.visitors != nil {
+		sketches, err := j.visitors.DeleteVisitorsBefore(cutoff)
+		if err != nil {
+			log.Printf("[RETENTION] ERREUR lors de la suppression des sketches de visiteurs: %v", err)
+		} else if sketches > 0 {
+			log.Printf("[RETENTION] %d sketch(es) de visiteurs uniques antérieur(s) au %s supprimé(s).",
+				sketches, cutoff.UTC().Format(time.RFC3339))
+		}
+	}
+	return removed
+}